@@ -0,0 +1,31 @@
+package kor
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yonahd/kor/pkg/kor"
+)
+
+var aggregateCmd = &cobra.Command{
+	Use:   "aggregate [result-file...]",
+	Short: "Merge json results of successive runs into a rolling report",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		report, err := kor.AggregateResultFiles(args)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if response, err := kor.FormatRollingReport(report, outputFormat); err != nil {
+			fmt.Println(err)
+		} else {
+			fmt.Println(response)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aggregateCmd)
+}
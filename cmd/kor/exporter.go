@@ -1,22 +1,35 @@
 package kor
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/yonahd/kor/pkg/kor"
 )
 
 var resourceList []string
+var exportFinalizers bool
 
 var exporterCmd = &cobra.Command{
 	Use:   "exporter",
 	Short: "start prometheus exporter",
 	Args:  cobra.ExactArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
+		// The selectors are only passed to the list requests of the resources pending deletion
+		if !exportFinalizers && (filterOptions.LabelSelector != "" || filterOptions.FieldSelector != "") {
+			fmt.Fprintln(os.Stderr, "--label-selector and --field-selector require --finalizers")
+			os.Exit(1)
+		}
 		clientset := kor.GetKubeClient(kubeconfig)
 		apiExtClient := kor.GetAPIExtensionsClient(kubeconfig)
 		dynamicClient := kor.GetDynamicClient(kubeconfig)
 
+		if exportFinalizers {
+			kor.FinalizerExporter(filterOptions, clientset, dynamicClient, opts)
+			return
+		}
 		kor.Exporter(filterOptions, clientset, apiExtClient, dynamicClient, "json", opts, resourceList)
 
 	},
@@ -24,5 +37,8 @@ var exporterCmd = &cobra.Command{
 
 func init() {
 	exporterCmd.Flags().StringSliceVarP(&resourceList, "resources", "r", nil, "Comma-separated list of resources to monitor (e.g., deployment,service)")
+	exporterCmd.Flags().BoolVar(&exportFinalizers, "finalizers", false, "Export kor_finalizers_pending, the number of resources pending deletion because of their finalizers per namespace and resource type, instead of the unused resources")
+	exporterCmd.Flags().StringVar(&filterOptions.LabelSelector, "label-selector", "", "With --finalizers, label selector passed to the API server when listing resources pending deletion. Example: --label-selector app.kubernetes.io/managed-by=argocd")
+	exporterCmd.Flags().StringVar(&filterOptions.FieldSelector, "field-selector", "", "With --finalizers, field selector passed to the API server when listing resources pending deletion. Example: --field-selector metadata.name!=kube-root-ca.crt")
 	rootCmd.AddCommand(exporterCmd)
 }
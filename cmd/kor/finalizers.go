@@ -2,22 +2,91 @@ package kor
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/yonahd/kor/pkg/kor"
 )
 
+var (
+	kubeContexts []string
+	kubeconfigs  []string
+)
+
 var finalizerCmd = &cobra.Command{
 	Use:     "finalizer",
 	Aliases: []string{"fin", "finalizers"},
 	Short:   "Gets resources waiting for finalizers to delete",
-	Args:    cobra.NoArgs,
+	Long: `Gets resources waiting for finalizers to delete.
+In addition to the common output formats, the finalizer command supports:
+  cloudevents - a json batch with one CloudEvents 1.0 envelope per resource
+  hash        - a stable sha256 of the findings, to detect changes between runs
+  grafana     - a Grafana table for the JSON datasource, with optional label columns
+  histogram   - the number of stuck resources per resource type across all namespaces
+  ndjson      - one json object per stuck resource and line, written as the resources are found
+  shell       - a single line of key=value pairs: stuck, namespaces, resource_types, advisory and skipped
+  summary     - the number of stuck resources per resource type, namespace and finalizer, and the oldest of them
+  tree        - the stuck resources of each namespace below the owners blocking or blocked by their deletion`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
+		// Interrupting the scan cancels its in-flight requests and stops its deletions, interrupting it
+		// again exits right away
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
+
+		if len(kubeContexts) > 0 || len(kubeconfigs) > 0 {
+			clusters := append(kor.ContextTargets(kubeconfig, kubeContexts), kor.KubeconfigTargets(kubeconfigs)...)
+			response, err := kor.GetUnusedFinalizersMultiCluster(ctx, filterOptions, clusters, outputFormat, opts)
+			if response != "" {
+				fmt.Println(response)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		clientset := kor.GetKubeClient(kubeconfig)
 		dynamicClient := kor.GetDynamicClient(kubeconfig)
+		if opts.ClusterName == "" {
+			if config, err := kor.GetConfig(kubeconfig); err == nil {
+				opts.ClusterName = config.Host
+			}
+		}
 
-		if response, err := kor.GetUnusedfinalizers(filterOptions, clientset, dynamicClient, outputFormat, opts); err != nil {
+		if opts.DiscoveryCacheDir != "" {
+			discoveryClient, err := kor.GetCachedDiscoveryClient(kubeconfig, opts.DiscoveryCacheDir, opts.DiscoveryCacheTTL)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			opts.DiscoveryClient = discoveryClient
+		}
+
+		if outputFormat == "ndjson" {
+			if err := kor.StreamUnusedFinalizers(ctx, filterOptions, clientset, dynamicClient, os.Stdout, opts); err != nil && kor.HasOutput(err) {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			} else if err != nil {
+				fmt.Println(err)
+			}
+			return
+		}
+
+		if response, err := kor.GetUnusedfinalizers(ctx, filterOptions, clientset, dynamicClient, outputFormat, opts); err != nil && kor.HasOutput(err) {
+			fmt.Println(response)
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		} else if err != nil {
 			fmt.Println(err)
 		} else {
 			fmt.Println(response)
@@ -26,5 +95,64 @@ var finalizerCmd = &cobra.Command{
 }
 
 func init() {
+	finalizerCmd.Flags().BoolVar(&opts.CheckFinalizerFormat, "check-format", false, "Report finalizers that contain whitespace or do not match the domain/name format instead of resources pending deletion")
+	finalizerCmd.Flags().BoolVar(&opts.ShowOwners, "show-owners", false, "Resolve the owner chain of each resource and include it in the reason")
+	finalizerCmd.Flags().IntVar(&opts.OwnerDepth, "owner-depth", 5, "Maximum number of owner references to follow when resolving the owner chain")
+	finalizerCmd.Flags().BoolVar(&filterOptions.DanglingFinalizers, "dangling-finalizers", false, "Also report resources with finalizers of an API group that is no longer served, e.g. of an uninstalled operator, even when their deletion was not requested")
+	finalizerCmd.Flags().StringVar(&filterOptions.ActiveSince, "active-since", "", "Only report namespaces whose objects changed within the given duration, based on object metadata. Example: --active-since=24h")
+	finalizerCmd.Flags().DurationVar(&opts.SeverityWarn, "severity-warn", 0, "Resources stuck in deletion for at least this duration get the warn severity (shown with --show-reason). Example: --severity-warn=1h")
+	finalizerCmd.Flags().DurationVar(&opts.SeverityCrit, "severity-crit", 0, "Resources stuck in deletion for at least this duration get the crit severity (shown with --show-reason). Example: --severity-crit=24h")
+	finalizerCmd.Flags().StringVar(&opts.ClusterName, "cluster-name", "", "Name identifying the scanned cluster, used as the cloudevents source (default is the API server host)")
+	finalizerCmd.Flags().BoolVar(&opts.ShowHash, "show-hash", false, "Print the scan result hash above the table output")
+	finalizerCmd.Flags().BoolVar(&opts.Explain, "explain", false, "Replace the reason with an explanation of why each resource was flagged: its finalizers, how long ago deletion was requested and its owners")
+	finalizerCmd.Flags().IntVar(&opts.Concurrency, "concurrency", 10, "Maximum number of concurrent API calls used to enrich the results, e.g. when resolving owners")
+	finalizerCmd.Flags().StringVar(&opts.MetricsTextfile, "metrics-textfile", "", "Also write the number of results per namespace and resource type as the kor_finalizers_pending metrics of the exporter to this .prom file, for the node_exporter textfile collector")
+	finalizerCmd.Flags().BoolVar(&opts.VerifyDeletion, "verify-deletion", false, "After deleting, re-list the resources and report which ones are gone, still terminating or still present")
+	finalizerCmd.Flags().StringSliceVar(&opts.GrafanaLabelColumns, "grafana-label-columns", nil, "Labels added as columns of the grafana output. Example: --grafana-label-columns app,team")
+	finalizerCmd.Flags().BoolVar(&opts.TerminatingNamespaces, "terminating-namespaces", false, "Only report the resources blocking the termination of namespaces stuck in the Terminating phase")
+	finalizerCmd.Flags().StringVar(&opts.ResourceVersionFile, "resource-version-file", "", "File storing the last seen resourceVersion and the resources pending deletion per resource type, so repeated scans only watch the changes since the previous scan")
+	finalizerCmd.Flags().BoolVar(&opts.ShowFinalizerManagers, "show-finalizer-managers", false, "Include the field managers that set the finalizers, and when, in the reason, based on the managedFields of each resource")
+	finalizerCmd.Flags().StringSliceVar(&opts.AdvisoryResourceTypes, "advisory-resource-types", nil, "Resource types using finalizers as a long-lived protocol, reported in a separate informational section and never deleted. Example: --advisory-resource-types certificates.cert-manager.io,volumesnapshots")
+	finalizerCmd.Flags().StringVar(&opts.Timezone, "timezone", "", "IANA timezone timestamps are displayed in, e.g. Europe/Berlin (default UTC). Json timestamps stay in UTC")
+	finalizerCmd.Flags().StringVar(&opts.DiscoveryCacheDir, "discovery-cache-dir", "", "Directory to cache the API discovery in, so repeated runs do not query discovery every time. Example: --discovery-cache-dir ~/.kube/cache/kor")
+	finalizerCmd.Flags().DurationVar(&opts.DiscoveryCacheTTL, "discovery-cache-ttl", 10*time.Minute, "How long the cached API discovery is used before it is refreshed")
+	finalizerCmd.Flags().BoolVar(&opts.ShowProtectedStuck, "show-protected-stuck", false, "Report resources marked as used with the --used-label-key label or annotation that are stuck pending deletion in a separate section")
+	finalizerCmd.Flags().StringToStringVar(&opts.StatusPaths, "status-path", nil, "JSONPath per resource type into the status explaining why a resource is stuck, added to the reason. Example: --status-path 'widgets.example.com=.status.conditions[?(@.reason==\"DeletionBlocked\")].message'")
+	finalizerCmd.Flags().BoolVar(&opts.SeparateDeleteResults, "separate-delete-results", false, "Report the resources found unchanged, and what the delete run did with them in a separate actions taken section")
+	finalizerCmd.Flags().StringVar(&opts.MarkLabel, "mark-label", "", "Label set on every stuck resource to when its deletion was requested, instead of deleting it. Rate limited like deletions. Example: --mark-label kor/stuck-since")
+	finalizerCmd.Flags().StringVar(&opts.MarkAnnotation, "mark-annotation", "", "Annotation set on every stuck resource to when its deletion was requested, instead of deleting it. Example: --mark-annotation kor/stuck-since")
+	finalizerCmd.Flags().BoolVar(&opts.RemoveFinalizers, "remove-finalizers", false, "Remove every finalizer of the resources pending deletion, including the garbage collector ones, so their requested deletion completes. Prompts for confirmation like --delete")
+	finalizerCmd.Flags().BoolVar(&opts.SkipOwned, "skip-owned", false, "Skip resources whose controlling owner still exists, since they are deleted through their owner rather than stuck on their own")
+	finalizerCmd.Flags().Int64Var(&opts.PageSize, "page-size", 500, "Number of resources listed per request, lower it when listing huge resource types times out")
+	finalizerCmd.Flags().IntVar(&opts.ListAttempts, "list-attempts", 3, "Number of times a list request is made when it fails with a transient error, e.g. throttling or a connection reset, before the resource type is skipped")
+	finalizerCmd.Flags().StringVar(&filterOptions.MinStuckDuration, "min-stuck-duration", "", "Only report resources pending deletion for at least the given duration, fresh terminations usually complete on their own. Example: --min-stuck-duration=1h")
+	finalizerCmd.Flags().IntVar(&filterOptions.MinFinalizerCount, "min-finalizer-count", 1, "Only report resources pending deletion on at least this many finalizers, to triage the ones piling up dead finalizers first")
+	finalizerCmd.Flags().StringSliceVar(&filterOptions.FinalizerMatch, "finalizer-match", nil, "Only report resources blocked by a finalizer matching one of the given names or glob patterns, e.g. to retire the finalizer of an operator. Example: --finalizer-match 'mycompany.io/*'")
+	finalizerCmd.Flags().StringVar(&filterOptions.StatusMatchPath, "status-match-path", "", "JSONPath of a field resources must have --status-match-value at to be reported, resources without it are skipped. Example: --status-match-path .status.phase --status-match-value Terminating")
+	finalizerCmd.Flags().StringVar(&filterOptions.StatusMatchValue, "status-match-value", "", "Value the --status-match-path field must have, any non-empty value when not set")
+	finalizerCmd.Flags().StringVar(&filterOptions.LabelSelector, "label-selector", "", "Label selector passed to the API server when listing resources pending deletion, so only matching resources are fetched. Example: --label-selector app.kubernetes.io/managed-by=argocd")
+	finalizerCmd.Flags().StringVar(&filterOptions.FieldSelector, "field-selector", "", "Field selector passed to the API server when listing resources pending deletion. Resource types not supporting the selected fields fail to list and are skipped. Example: --field-selector metadata.name!=kube-root-ca.crt")
+	finalizerCmd.Flags().StringSliceVar(&filterOptions.ResourceTypes, "resource-types", nil, "Only scan the given resource types instead of every discovered one. Example: --resource-types persistentvolumeclaims,certificates.cert-manager.io")
+	finalizerCmd.Flags().StringSliceVar(&filterOptions.IncludeGroups, "include-groups", nil, "Only scan the resource types of the given API groups and their subgroups, skipping the list requests of every other group. The core group is core. Example: --include-groups cert-manager.io")
+	finalizerCmd.Flags().StringSliceVar(&filterOptions.ExcludeResourceTypes, "exclude-resource-types", nil, "Resource types never listed, even when given in --resource-types. Example: --exclude-resource-types events,events.events.k8s.io")
+	finalizerCmd.Flags().StringVar(&opts.SlackSummaryWebhookURL, "slack-summary-webhook-url", "", "Slack webhook URL a summary of the stuck resources is posted to, with their count per namespace and the ones stuck the longest")
+	finalizerCmd.Flags().StringVar(&opts.OutputFile, "output-file", "", "Also write the output to this file, in any output format. {timestamp} is replaced with the scan time to archive every scan. Example: --output-file reports/finalizers-{timestamp}.json")
+	finalizerCmd.Flags().BoolVar(&opts.BatchConfirmation, "batch-confirm", false, "Show a summary of every resource about to be deleted and confirm them all at once, instead of prompting for each resource")
+	finalizerCmd.Flags().DurationVar(&opts.WaitForDeletion, "wait-for-deletion", 0, "After deleting, wait up to this long for the resources to be gone, report the ones remaining and exit with status 1 if any. Example: --wait-for-deletion=5m")
+	finalizerCmd.Flags().StringVar(&opts.FindingHookURL, "finding-hook-url", "", "URL every stuck resource is posted to as json, e.g. to open an incident for it")
+	finalizerCmd.Flags().StringVar(&opts.FindingHookCommand, "finding-hook-command", "", "Command run with sh for every stuck resource, with the resource as json on stdin and $KOR_NAMESPACE, $KOR_GROUP, $KOR_VERSION, $KOR_RESOURCE, $KOR_KIND, $KOR_NAME and $KOR_FINALIZERS set. Example: --finding-hook-command 'remediate.sh \"$KOR_NAMESPACE/$KOR_NAME\"'")
+	finalizerCmd.Flags().BoolVar(&opts.FindingHookFatal, "finding-hook-fatal", false, "Fail the scan when a finding hook fails, before anything is deleted, instead of only reporting the failure")
+	finalizerCmd.Flags().StringVar(&opts.BackupDir, "backup-dir", "", "Directory the resources are saved to as YAML before --delete or --remove-finalizers removes their finalizers, each with a .provenance.json recording who ran kor, when, on which cluster and the finalizers removed. Resources that cannot be backed up are left untouched")
+	finalizerCmd.Flags().BoolVar(&opts.NotifyDryRun, "notify-dry-run", false, "Print the Slack summary and the finding hooks instead of sending them")
+	finalizerCmd.Flags().StringVar(&opts.ConfirmationToken, "confirmation-token", "", "Required to delete or remove finalizers with --no-interactive, must be "+kor.RequiredConfirmationToken+" to confirm the irreversible changes are intended")
+	finalizerCmd.Flags().StringSliceVar(&opts.ProtectedFinalizers, "protected-finalizers", kor.DefaultProtectedFinalizers, "Patterns of the finalizers never removed by --delete or --remove-finalizers, resources only blocked by them are skipped and reported as protected. Set it empty to remove any finalizer. Example: --protected-finalizers 'kubernetes.io/*,example.com/backup'")
+	finalizerCmd.Flags().BoolVar(&opts.IncludeSubresources, "include-subresources", false, "Also attempt to list the discovered subresources with the list verb, e.g. pods/status, which are skipped by default")
+	finalizerCmd.Flags().BoolVar(&opts.ShowProgress, "progress", false, "Report the progress of the scan on stderr: the resource types listed out of the total and the resources flagged so far")
+	finalizerCmd.Flags().BoolVar(&opts.RedactNames, "redact-names", false, "Report resource names as a stable keyed hash, keeping their namespace and resource type, to share reports outside the team")
+	finalizerCmd.Flags().StringVar(&opts.RedactionKey, "redaction-key", "", "Key of the redacted names, generated and printed on stderr when empty")
+	finalizerCmd.Flags().StringSliceVar(&opts.Unredact, "unredact", nil, "Redacted names of an earlier report to map back to the resources they redact, with its --redaction-key")
+	finalizerCmd.Flags().BoolVar(&opts.FailOnFindings, "fail-on-findings", false, "Exit with status 1 when any resource stuck pending deletion is reported, e.g. to fail a CI pipeline. No findings exit with status 0")
+	finalizerCmd.Flags().StringSliceVar(&kubeContexts, "contexts", nil, "Kubeconfig contexts of the clusters to scan concurrently in one run, combined in a single report identifying the cluster of every resource. Example: --contexts prod-eu,prod-us")
+	finalizerCmd.Flags().StringSliceVar(&kubeconfigs, "kubeconfigs", nil, "Kubeconfig files of the clusters to scan concurrently with their current context, like --contexts. Example: --kubeconfigs ~/.kube/prod,~/.kube/staging")
 	rootCmd.AddCommand(finalizerCmd)
 }
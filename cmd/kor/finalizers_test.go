@@ -0,0 +1,30 @@
+package kor
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// The flags are registered in init, a flag registered twice panics before any test runs
+func TestFinalizerFlags(t *testing.T) {
+	flags := finalizerCmd.Flags()
+	for _, name := range []string{"status-path", "status-match-path", "status-match-value", "label-selector", "field-selector"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("Expected the finalizer command to define --%s", name)
+		}
+	}
+	// The selectors are only passed to the list requests of finalizer scans
+	for _, name := range []string{"label-selector", "field-selector"} {
+		if rootCmd.PersistentFlags().Lookup(name) != nil {
+			t.Errorf("Expected --%s not to be accepted by every command", name)
+		}
+	}
+
+	// A local flag named like a persistent flag of the root command would silently shadow it
+	finalizerCmd.LocalNonPersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if rootCmd.PersistentFlags().Lookup(flag.Name) != nil {
+			t.Errorf("Flag --%s of the finalizer command shadows the root flag", flag.Name)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -52,18 +53,56 @@ var (
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&kubeconfig, "kubeconfig", "k", "", "Path to kubeconfig file (optional)")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json or yaml)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml or csv)")
 	rootCmd.PersistentFlags().StringVar(&opts.WebhookURL, "slack-webhook-url", "", "Slack webhook URL to send notifications to")
 	rootCmd.PersistentFlags().StringVar(&opts.Channel, "slack-channel", "", "Slack channel to send notifications to. --slack-channel requires --slack-auth-token to be set.")
 	rootCmd.PersistentFlags().StringVar(&opts.Token, "slack-auth-token", "", "Slack auth token to send notifications to. --slack-auth-token requires --slack-channel to be set.")
 	rootCmd.PersistentFlags().BoolVar(&opts.DeleteFlag, "delete", false, "Delete unused resources")
+	rootCmd.PersistentFlags().BoolVar(&opts.DryRun, "dry-run", false, "Send the delete and patch requests as server-side dry runs, exercising RBAC and admission webhooks without changing anything")
+	rootCmd.PersistentFlags().Float64Var(&opts.DeleteRate, "delete-rate", 0, "Maximum number of deletions per second, lowered automatically while the API server throttles requests. 0 means no limit")
 	rootCmd.PersistentFlags().BoolVar(&opts.NoInteractive, "no-interactive", false, "Do not prompt for confirmation when deleting resources. Be careful using this flag!")
-	rootCmd.PersistentFlags().BoolVarP(&opts.Verbose, "verbose", "v", false, "Verbose output (print empty namespaces)")
+	rootCmd.PersistentFlags().StringSliceVar(&opts.NoConfirmResourceTypes, "no-confirm-resource-types", nil, "Resource types deleted without prompting for confirmation, while other resource types still prompt. Example: --no-confirm-resource-types ConfigMap,pods")
+	rootCmd.PersistentFlags().IntVar(&opts.ConfirmationRetries, "confirmation-retries", 3, "Number of times to ask again when the answer to a delete confirmation is not y(es) or n(o), after which the resource is not deleted")
+	rootCmd.PersistentFlags().Var(&gracePeriodValue{seconds: &opts.GracePeriodSeconds}, "grace-period", "Seconds given to resources to terminate gracefully when deleting them, 0 deletes immediately. -1 uses the default of the resource type. Not supported by the finalizer command, whose resources are already being deleted")
+	rootCmd.PersistentFlags().StringToStringVar(&opts.PropagationPolicies, "propagation-policy", nil, "Deletion propagation policy per resource type (Background, Foreground or Orphan), defaults to Background. Example: --propagation-policy Deployment=Foreground,jobs=Orphan")
+	rootCmd.PersistentFlags().BoolVarP(&opts.Verbose, "verbose", "v", false, "Verbose output (print empty namespaces, and the list duration and counts per resource type of finalizer scans)")
 	rootCmd.PersistentFlags().StringVar(&opts.GroupBy, "group-by", "namespace", "Group output by (namespace, resource)")
 	rootCmd.PersistentFlags().BoolVar(&opts.ShowReason, "show-reason", false, "Print reason resource is considered unused")
+	rootCmd.PersistentFlags().Float32Var(&kor.ClientQPS, "qps", kor.DefaultQPS, "Maximum queries per second to the API server, raise it when scans are slowed down by client-side throttling")
+	rootCmd.PersistentFlags().IntVar(&kor.ClientBurst, "burst", kor.DefaultBurst, "Maximum burst of queries to the API server above --qps")
 	addFilterOptionsFlag(rootCmd, filterOptions)
 }
 
+// gracePeriodValue sets the grace period of the options when the flag is given, it is left nil for -1 so
+// delete requests use the default of the resource type
+type gracePeriodValue struct {
+	seconds **int64
+}
+
+func (v *gracePeriodValue) String() string {
+	if v.seconds == nil || *v.seconds == nil {
+		return "-1"
+	}
+	return strconv.FormatInt(**v.seconds, 10)
+}
+
+func (v *gracePeriodValue) Set(value string) error {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	if seconds < 0 {
+		*v.seconds = nil
+		return nil
+	}
+	*v.seconds = &seconds
+	return nil
+}
+
+func (v *gracePeriodValue) Type() string {
+	return "int"
+}
+
 func Execute() {
 	_ = rootCmd.ParseFlags(os.Args)
 	if err := filterOptions.Validate(); err != nil {
@@ -79,9 +118,18 @@ func Execute() {
 
 func addFilterOptionsFlag(cmd *cobra.Command, opts *filters.Options) {
 	cmd.PersistentFlags().StringSliceVarP(&opts.ExcludeLabels, "exclude-labels", "l", opts.ExcludeLabels, "Selector to filter out, Example: --exclude-labels key1=value1,key2=value2. If --include-labels is set, --exclude-labels will be ignored.")
-	cmd.PersistentFlags().StringVar(&opts.NewerThan, "newer-than", opts.NewerThan, "The maximum age of the resources to be considered unused. This flag cannot be used together with older-than flag. Example: --newer-than=1h2m")
-	cmd.PersistentFlags().StringVar(&opts.OlderThan, "older-than", opts.OlderThan, "The minimum age of the resources to be considered unused. This flag cannot be used together with newer-than flag. Example: --older-than=1h2m")
+	cmd.PersistentFlags().StringVar(&opts.NewerThan, "newer-than", opts.NewerThan, "The maximum age of the resources to be considered unused, exclusive. Combined with older-than, only the resources aged within both bounds are considered. Example: --newer-than=1h2m")
+	cmd.PersistentFlags().StringVar(&opts.OlderThan, "older-than", opts.OlderThan, "The minimum age of the resources to be considered unused, exclusive. Combined with newer-than, only the resources aged within both bounds are considered. Example: --older-than=1h2m")
 	cmd.PersistentFlags().StringVar(&opts.IncludeLabels, "include-labels", opts.IncludeLabels, "Selector to filter in, Example: --include-labels key1=value1.(currently supports one label)")
 	cmd.PersistentFlags().StringSliceVarP(&opts.ExcludeNamespaces, "exclude-namespaces", "e", opts.ExcludeNamespaces, "Namespaces to be excluded, split by commas. Example: --exclude-namespaces ns1,ns2,ns3. If --include-namespaces is set, --exclude-namespaces will be ignored.")
+	cmd.PersistentFlags().StringVar(&opts.ExcludeNamespacesRegex, "exclude-namespaces-regex", opts.ExcludeNamespacesRegex, "Regular expression matching the names of namespaces to be excluded, in addition to --exclude-namespaces. Example: --exclude-namespaces-regex '^team-.*-dev$'")
+	cmd.PersistentFlags().StringVar(&opts.IncludeNamespacesRegex, "include-namespaces-regex", opts.IncludeNamespacesRegex, "Regular expression matching the names of namespaces to run on, in addition to --include-namespaces. Example: --include-namespaces-regex '^team-.*-prod$'. If set, non-namespaced resources will be ignored.")
+	cmd.PersistentFlags().StringVar(&opts.NamespaceSelector, "namespace-selector", opts.NamespaceSelector, "Label selector the namespaces to run on must match, combined with --include-namespaces-regex and the exclude namespace flags. It cannot be used with --include-namespaces. Example: --namespace-selector env=staging")
+	cmd.PersistentFlags().StringSliceVar(&opts.SystemNamespaces, "system-namespaces", nil, "Control plane namespaces excluded unless --include-system-namespaces is set or they are named by --include-namespaces. The finalizer command excludes "+strings.Join(filters.DefaultSystemNamespaces, ",")+" when not set")
+	cmd.PersistentFlags().BoolVar(&opts.IncludeSystemNamespaces, "include-system-namespaces", false, "Also run on the --system-namespaces")
+	cmd.PersistentFlags().StringVar(&opts.MinNamespaceAge, "min-namespace-age", opts.MinNamespaceAge, "Skip namespaces younger than the given duration, to avoid transient resources of namespaces being provisioned. Example: --min-namespace-age=1h")
+	cmd.PersistentFlags().StringVar(&opts.ExcludeExpr, "exclude-expr", opts.ExcludeExpr, "JSONPath filter predicate evaluated against each resource, matching resources are excluded. Example: --exclude-expr '@.spec.replicas == 0'")
+	cmd.PersistentFlags().StringVar(&opts.UsedLabelKey, "used-label-key", filters.DefaultUsedLabelKey, "Label marking resources as used, so they are never reported. Only the finalizer command also honors it as an annotation, the other commands only honor the label. Example: --used-label-key ops.example.com/retain")
+	cmd.PersistentFlags().StringSliceVar(&opts.UsedLabelValues, "used-label-values", []string{"true"}, "Values of the used label marking resources as used, matched case-insensitively by the finalizer command. Example: --used-label-values true,retain,keep")
 	cmd.PersistentFlags().StringSliceVarP(&opts.IncludeNamespaces, "include-namespaces", "n", opts.IncludeNamespaces, "Namespaces to run on, split by commas. Example: --include-namespaces ns1,ns2,ns3. If set, non-namespaced resources will be ignored.")
 }
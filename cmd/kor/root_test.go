@@ -0,0 +1,22 @@
+package kor
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestGracePeriodFlag(t *testing.T) {
+	var seconds *int64
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Var(&gracePeriodValue{seconds: &seconds}, "grace-period", "")
+	if err := flags.Parse(nil); err != nil || seconds != nil {
+		t.Fatalf("Expected no grace period by default, got %v: %v", seconds, err)
+	}
+	if err := flags.Parse([]string{"--grace-period", "0"}); err != nil || seconds == nil || *seconds != 0 {
+		t.Errorf("Expected a grace period of 0 seconds, got %v: %v", seconds, err)
+	}
+	if err := flags.Parse([]string{"--grace-period", "-1"}); err != nil || seconds != nil {
+		t.Errorf("Expected -1 to use the default of the resource type, got %v: %v", seconds, err)
+	}
+}
@@ -2,9 +2,12 @@ package filters
 
 import (
 	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -13,18 +16,52 @@ const (
 	LabelFilterName    = "label"
 	AgeFilterName      = "age"
 	KorLabelFilterName = "korlabel"
+	ExprFilterName     = "expr"
 )
 
-// KorLabelFilter is a filter that filters out resources that are ["kor/used"] != "true"
+// DefaultUsedLabelKey is the label marking resources as used, unless another one is configured
+const DefaultUsedLabelKey = "kor/used"
+
+// KorLabelFilter is a filter that filters out resources marked as used with the used label, by default
+// ["kor/used"] == "true". Finalizer scans honor the broader UsedMark instead.
 func KorLabelFilter(object runtime.Object, opts *Options) bool {
 	if meta, ok := object.(metav1.Object); ok {
-		if meta.GetLabels()["kor/used"] == "true" {
-			return true
-		}
+		key, values := opts.UsedLabel()
+		value, found := meta.GetLabels()[key]
+		return found && slices.Contains(values, value)
 	}
 	return false
 }
 
+// UsedMark returns where finalizer scans find the object marked as used: "label" or "annotation" when the
+// used label key is set to a used value as a label or as an annotation, for resources whose labels are used
+// by selectors, and "" when it is not marked. The used values are matched case-insensitively.
+func UsedMark(object runtime.Object, opts *Options) string {
+	meta, ok := object.(metav1.Object)
+	if !ok {
+		return ""
+	}
+	key, values := opts.UsedLabel()
+	for _, mark := range []struct {
+		kind   string
+		values map[string]string
+	}{
+		{"label", meta.GetLabels()},
+		{"annotation", meta.GetAnnotations()},
+	} {
+		value, found := mark.values[key]
+		if !found {
+			continue
+		}
+		for _, used := range values {
+			if strings.EqualFold(value, used) {
+				return mark.kind
+			}
+		}
+	}
+	return ""
+}
+
 // LabelFilter is a filter that filters out resources by label
 func LabelFilter(object runtime.Object, opts *Options) bool {
 	if meta, ok := object.(metav1.Object); ok {
@@ -45,6 +82,28 @@ func AgeFilter(object runtime.Object, opts *Options) bool {
 	return false
 }
 
+// ExprFilter is a filter that filters out resources matching the exclude expression
+func ExprFilter(object runtime.Object, opts *Options) bool {
+	path, err := opts.excludeExprPath()
+	if err != nil || path == nil {
+		return false
+	}
+
+	var content map[string]interface{}
+	if u, ok := object.(*unstructured.Unstructured); ok {
+		content = u.UnstructuredContent()
+	} else if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(object); err != nil {
+		return false
+	}
+
+	// The predicate is a JSONPath filter, so the object is wrapped in a list and matches when it is selected
+	results, err := path.FindResults([]interface{}{content})
+	if err != nil {
+		return false
+	}
+	return len(results) > 0 && len(results[0]) > 0
+}
+
 // HasExcludedLabel parses the excluded selector into a label selector object
 func HasExcludedLabel(resourcelabels map[string]string, excludeSelector []string) (bool, error) {
 	excludes := make([]labels.Selector, 0)
@@ -71,29 +130,25 @@ func HasExcludedLabel(resourcelabels map[string]string, excludeSelector []string
 }
 
 // HasIncludedAge checks if a resource has an age that matches the included criteria specified by the filter options
-// A resource is considered to have an included age if its age (measured from the last modified time) is within the
+// A resource is considered to have an included age if its age (measured from the creation time) is within the
 // range specified by older-than and newer-than flags.
 // If older-than or newer-than is zero, no age limit is applied.
-// If both flags are set, an error is returned.
+// If both flags are set, they form a window and the age must satisfy both bounds. Both bounds are exclusive:
+// --older-than=1h --newer-than=1d includes the resources older than 1 hour and newer than 1 day, not the ones
+// aged exactly 1 hour or 1 day. If the window is empty, i.e. older-than is not less than newer-than, an error is returned.
 func HasIncludedAge(creationTime metav1.Time, filterOpts *Options) (bool, error) {
 	if filterOpts.OlderThan == "" && filterOpts.NewerThan == "" {
 		return true, nil
 	}
-	// The function returns an error if both flags are set is because it does not make sense to
-	// query for resources that are both older than and newer than a certain duration.
-	// For example, if you set --older-than=1h and --newer-than=30m, you are asking for resources
-	// that are older than 1 hour and newer than 30 minutes, which is impossible!
-	if filterOpts.OlderThan != "" && filterOpts.NewerThan != "" {
-		return false, errors.New("invalid flags: older-than and newer-than cannot be used together")
-	}
+	age := time.Since(creationTime.Time)
 
 	// Parse the older-than flag value into a time.Duration value
+	var olderThan time.Duration
 	if filterOpts.OlderThan != "" {
-		olderThan, err := time.ParseDuration(filterOpts.OlderThan)
-		if err != nil {
+		var err error
+		if olderThan, err = time.ParseDuration(filterOpts.OlderThan); err != nil {
 			return false, err
 		}
-		return time.Since(creationTime.Time) > olderThan, nil
 	}
 
 	// Parse the newer-than flag value into a time.Duration value
@@ -102,8 +157,14 @@ func HasIncludedAge(creationTime metav1.Time, filterOpts *Options) (bool, error)
 		if err != nil {
 			return false, err
 		}
-		return time.Since(creationTime.Time) < newerThan, nil
+		// The bounds are only compared when both are set
+		if filterOpts.OlderThan != "" && olderThan >= newerThan {
+			return false, errors.New("invalid flags: older-than must be less than newer-than")
+		}
+		if age >= newerThan {
+			return false, nil
+		}
 	}
 
-	return true, nil
+	return filterOpts.OlderThan == "" || age > olderThan, nil
 }
@@ -4,9 +4,12 @@ import (
 	"testing"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
 )
 
 func TestLabelFilter(t *testing.T) {
@@ -96,7 +99,7 @@ func TestAgeFilter(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "two flags are provided",
+			name: "older than the window",
 			args: args{
 				object: &corev1.Node{
 					ObjectMeta: metav1.ObjectMeta{
@@ -108,8 +111,67 @@ func TestAgeFilter(t *testing.T) {
 					OlderThan: "2h",
 				},
 			},
+			want: true,
+		},
+		{
+			name: "within the window",
+			args: args{
+				object: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						CreationTimestamp: metav1.Time{Time: now.Add(-150 * time.Minute)},
+					},
+				},
+				opts: &Options{
+					NewerThan: "3h",
+					OlderThan: "2h",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "newer than the window",
+			args: args{
+				object: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						CreationTimestamp: metav1.Time{Time: now.Add(-time.Hour)},
+					},
+				},
+				opts: &Options{
+					NewerThan: "3h",
+					OlderThan: "2h",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "empty window",
+			args: args{
+				object: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						CreationTimestamp: metav1.Time{Time: now.Add(-150 * time.Minute)},
+					},
+				},
+				opts: &Options{
+					NewerThan: "2h",
+					OlderThan: "3h",
+				},
+			},
 			want: false,
 		},
+		{
+			name: "newer than 0s alone",
+			args: args{
+				object: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						CreationTimestamp: metav1.Time{Time: now.Add(-time.Hour)},
+					},
+				},
+				opts: &Options{
+					NewerThan: "0s",
+				},
+			},
+			want: true,
+		},
 	}
 	for _, tt := range tests {
 		got := AgeFilter(tt.args.object, tt.args.opts)
@@ -168,6 +230,57 @@ func TestKorLabelFilter(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "have custom used label with accepted value",
+			args: args{
+				object: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							"ops.example.com/retain": "keep",
+						},
+					},
+				},
+				opts: &Options{UsedLabelKey: "ops.example.com/retain", UsedLabelValues: []string{"retain", "keep"}},
+			},
+			want: true,
+		},
+		{
+			name: "have kor/used label with another casing",
+			args: args{
+				object: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"kor/used": "TRUE"},
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "have kor/used annotation true",
+			args: args{
+				object: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels:      map[string]string{"app": "selected"},
+						Annotations: map[string]string{"kor/used": "true"},
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "have kor/used label with custom used label configured",
+			args: args{
+				object: &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							"kor/used": "true",
+						},
+					},
+				},
+				opts: &Options{UsedLabelKey: "ops.example.com/retain"},
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -177,3 +290,83 @@ func TestKorLabelFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestUsedMark(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		opts        *Options
+		want        string
+	}{
+		{name: "not marked", labels: map[string]string{"foo": "bar"}},
+		{name: "label true", labels: map[string]string{"kor/used": "true"}, want: "label"},
+		{name: "label with another casing", labels: map[string]string{"kor/used": "TRUE"}, want: "label"},
+		{name: "label false", labels: map[string]string{"kor/used": "false"}},
+		{name: "annotation true", labels: map[string]string{"app": "selected"}, annotations: map[string]string{"kor/used": "true"}, want: "annotation"},
+		{name: "label false and annotation true", labels: map[string]string{"kor/used": "false"}, annotations: map[string]string{"kor/used": "True"}, want: "annotation"},
+		{
+			name:        "custom annotation with accepted value",
+			annotations: map[string]string{"ops.example.com/retain": "Keep"},
+			opts:        &Options{UsedLabelKey: "ops.example.com/retain", UsedLabelValues: []string{"retain", "keep"}},
+			want:        "annotation",
+		},
+		{name: "default annotation with custom key configured", annotations: map[string]string{"kor/used": "true"}, opts: &Options{UsedLabelKey: "ops.example.com/retain"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: tt.labels, Annotations: tt.annotations}}
+			if got := UsedMark(object, tt.opts); got != tt.want {
+				t.Errorf("UsedMark() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprFilter(t *testing.T) {
+	scaledDown := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "scaled-down", Labels: map[string]string{"app": "test"}},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](0)},
+	}
+	running := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "running"},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+	}
+	custom := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "custom"},
+		"spec":     map[string]interface{}{"paused": true},
+	}}
+
+	tests := []struct {
+		name   string
+		object runtime.Object
+		expr   string
+		want   bool
+	}{
+		{"no expression", scaledDown, "", false},
+		{"matching typed object", scaledDown, "@.spec.replicas == 0", true},
+		{"not matching typed object", running, "@.spec.replicas == 0", false},
+		{"without the current object prefix", scaledDown, "spec.replicas == 0", true},
+		{"string comparison", scaledDown, `@.metadata.labels.app == "test"`, true},
+		{"field exists on unstructured object", custom, "@.spec.paused", true},
+		{"missing field", running, "@.spec.paused", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &Options{ExcludeExpr: tt.expr}
+			if err := opts.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if got := ExprFilter(tt.object, opts); got != tt.want {
+				t.Errorf("ExprFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExprFilterInvalidExpression(t *testing.T) {
+	opts := &Options{ExcludeExpr: "@.spec.replicas == (0"}
+	if err := opts.Validate(); err == nil {
+		t.Error("Validate() expected an error for an invalid expression")
+	}
+}
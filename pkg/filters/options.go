@@ -5,24 +5,32 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/jsonpath"
 )
 
 // Options represents the flags and options for filtering unused Kubernetes resources, such as pods, services, or configmaps.
 // A resource is considered unused if it meets the following conditions:
 //   - Its age (measured from the last modified time) is within the range specified by older-than and newer-than flags.
 //     If older-than or newer-than is zero, no age limit is applied.
-//     If both flags are set, an error is returned.
+//     If both flags are set, the age must be within both bounds, and older-than must be less than newer-than.
 //   - Its size (measured in bytes) is within the range specified by MinSize and MaxSize flags.
 //     If MinSize or MaxSize is zero, no size limit is applied.
 //   - It does not have any labels that match the ExcludeLabels flag. The ExcludeLabels flag supports '=', '==', and '!=' operators,
 //     and multiple label pairs can be separated by commas. For example, -l key1=value1,key2!=value2.
+//   - It does not match the ExcludeExpr JSONPath predicate.
 type Options struct {
 	// OlderThan is the minimum age of the resources to be considered unused
 	OlderThan string
@@ -33,14 +41,95 @@ type Options struct {
 	ExcludeLabels []string
 	// IncludeLabels is a label selector to include resources with matching labels
 	IncludeLabels string
+	// LabelSelector is passed to the API server when listing resources of a finalizer scan, so only the
+	// matching resources are transferred. Empty lists every resource.
+	LabelSelector string
+	// FieldSelector is passed to the API server when listing resources of a finalizer scan. Which fields are
+	// supported depends on the resource type, the finalizer checks still run on every listed resource.
+	FieldSelector string
+	// ResourceTypes limits a finalizer scan to the given resource types, by their plural name optionally
+	// qualified with their group, e.g. persistentvolumeclaims or certificates.cert-manager.io. Empty scans
+	// every resource type.
+	ResourceTypes []string
+	// ExcludeResourceTypes are never listed by a finalizer scan, even when they are in ResourceTypes
+	ExcludeResourceTypes []string
+	// IncludeGroups limits a finalizer scan to the resource types of the given API groups and their
+	// subgroups, matched case-insensitively on the group suffix. The core group is given as core
+	IncludeGroups []string
 	// ExcludeNamespaces is a namespace selector to exclude resources in matching namespaces
 	// IncludeNamespaces conflicts with it, and when setting IncludeNamespaces, ExcludeNamespaces is ignored and set to empty
 	ExcludeNamespaces []string
 	// IncludeNamespaces is a namespace selector to include resources in matching namespaces
 	IncludeNamespaces []string
+	// ExcludeNamespacesRegex excludes the namespaces whose name matches the regular expression, in addition to ExcludeNamespaces
+	ExcludeNamespacesRegex string
+	// IncludeNamespacesRegex includes the namespaces whose name matches the regular expression, in addition to IncludeNamespaces.
+	// Like IncludeNamespaces, it conflicts with the exclude namespace options, which are then ignored.
+	IncludeNamespacesRegex string
+	// SystemNamespaces are excluded unless IncludeSystemNamespaces is set or they are explicitly included.
+	// None are excluded when nil, finalizer scans exclude DefaultSystemNamespaces then.
+	SystemNamespaces []string
+	// IncludeSystemNamespaces scans the SystemNamespaces like any other namespace
+	IncludeSystemNamespaces bool
+	// NamespaceSelector is a label selector the namespaces must match, on top of IncludeNamespacesRegex and the exclude
+	// namespace options. It cannot be combined with IncludeNamespaces.
+	NamespaceSelector string
+	// MinNamespaceAge skips namespaces younger than the given duration
+	MinNamespaceAge string
+	// ActiveSince limits the scan to namespaces with object changes within the given duration
+	ActiveSince string
+	// ExcludeExpr is a JSONPath filter predicate evaluated against each object, matching objects are excluded.
+	// For example, @.spec.replicas == 0
+	ExcludeExpr string
+	// DanglingFinalizers also reports objects whose finalizers belong to an API group that is no longer served,
+	// even when their deletion has not been requested yet
+	DanglingFinalizers bool
+	// MinStuckDuration skips resources pending deletion for less than the given duration, since fresh
+	// terminations usually complete on their own
+	MinStuckDuration string
+	// MinFinalizerCount skips resources pending deletion on fewer finalizers, a single finalizer usually
+	// resolves while resources piling up several are the worst offenders. 1 when zero
+	MinFinalizerCount int
+	// FinalizerMatch only keeps resources with a finalizer matching one of the given names or glob patterns,
+	// e.g. mycompany.io/cleanup or mycompany.io/*. Any finalizer matches when empty
+	FinalizerMatch []string
+	// StatusMatchPath is the JSONPath of an object field, e.g. .status.phase, only resources with StatusMatchValue at it are kept.
+	// Resources without the field are skipped. It is not applied when empty
+	StatusMatchPath string
+	// StatusMatchValue is the value StatusMatchPath must have, any non-empty value when empty
+	StatusMatchValue string
+	// UsedLabelKey is the label marking resources as used, defaults to kor/used
+	UsedLabelKey string
+	// UsedLabelValues are the values of UsedLabelKey marking a resource as used, defaults to true
+	UsedLabelValues []string
 
-	namespace []string
-	once      sync.Once
+	namespace    []string
+	namespaceErr error
+	once         sync.Once
+
+	includeNamespacesRegex *regexp.Regexp
+	excludeNamespacesRegex *regexp.Regexp
+	namespacesRegexErr     error
+	namespacesRegexOnce    sync.Once
+
+	excludeExpr     *jsonpath.JSONPath
+	excludeExprErr  error
+	excludeExprOnce sync.Once
+
+	statusPath     *jsonpath.JSONPath
+	statusPathErr  error
+	statusPathOnce sync.Once
+}
+
+// DefaultSystemNamespaces are the control plane namespaces finalizer scans exclude by default
+var DefaultSystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// excludedSystemNamespaces returns the system namespaces excluded from the scan, none when they are included
+func (o *Options) excludedSystemNamespaces() []string {
+	if o.IncludeSystemNamespaces {
+		return nil
+	}
+	return o.SystemNamespaces
 }
 
 // NewFilterOptions returns a new FilterOptions instance with default values
@@ -77,6 +166,28 @@ func (o *Options) Validate() error {
 		}
 	}
 
+	if o.LabelSelector != "" {
+		if _, err := labels.Parse(o.LabelSelector); err != nil {
+			return fmt.Errorf("invalid label selector %q: %w", o.LabelSelector, err)
+		}
+	}
+
+	if o.FieldSelector != "" {
+		if _, err := fields.ParseSelector(o.FieldSelector); err != nil {
+			return fmt.Errorf("invalid field selector %q: %w", o.FieldSelector, err)
+		}
+	}
+
+	if o.NamespaceSelector != "" {
+		if _, err := labels.Parse(o.NamespaceSelector); err != nil {
+			return fmt.Errorf("invalid namespace selector %q: %w", o.NamespaceSelector, err)
+		}
+		// Named namespaces are run on as given, a selector could only silently drop some of them
+		if len(o.IncludeNamespaces) > 0 {
+			return errors.New("IncludeNamespaces and NamespaceSelector cannot be used together, use IncludeNamespacesRegex to narrow the selected namespaces down by name")
+		}
+	}
+
 	// Parse the older-than flag value into a time.Duration value
 	if o.OlderThan != "" {
 		olderThan, err := time.ParseDuration(o.OlderThan)
@@ -97,67 +208,396 @@ func (o *Options) Validate() error {
 		if newerThan < 0 {
 			return errors.New("NewerThan must be a non-negative duration")
 		}
+		if o.OlderThan != "" {
+			// OlderThan was parsed successfully above
+			if olderThan, _ := time.ParseDuration(o.OlderThan); olderThan >= newerThan {
+				return fmt.Errorf("OlderThan (%s) must be less than NewerThan (%s)", o.OlderThan, o.NewerThan)
+			}
+		}
+	}
+
+	// Parse the min-namespace-age flag value into a time.Duration value
+	if o.MinNamespaceAge != "" {
+		minNamespaceAge, err := time.ParseDuration(o.MinNamespaceAge)
+		if err != nil {
+			return err
+		}
+		if minNamespaceAge < 0 {
+			return errors.New("MinNamespaceAge must be a non-negative duration")
+		}
+	}
+
+	// Parse the active-since flag value into a time.Duration value
+	if o.ActiveSince != "" {
+		activeSince, err := time.ParseDuration(o.ActiveSince)
+		if err != nil {
+			return err
+		}
+		if activeSince < 0 {
+			return errors.New("ActiveSince must be a non-negative duration")
+		}
+	}
+
+	// Parse the min-stuck-duration flag value into a time.Duration value
+	if _, err := o.MinStuckDurationValue(); err != nil {
+		return err
+	}
+
+	if o.MinFinalizerCount < 0 {
+		return errors.New("MinFinalizerCount must not be negative")
+	}
+
+	for _, pattern := range o.FinalizerMatch {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid finalizer match %q: %w", pattern, err)
+		}
+	}
+
+	// Compile the namespace regular expressions once, so they are not compiled for every namespace
+	if _, _, err := o.namespacesRegexes(); err != nil {
+		return err
+	}
+
+	// Compile the exclude-expr predicate once, so it is not parsed for every object
+	if _, err := o.excludeExprPath(); err != nil {
+		return err
+	}
+
+	// Compile the status path once, so it is not parsed for every object
+	if o.StatusMatchValue != "" && o.StatusMatchPath == "" {
+		return errors.New("StatusMatchValue requires StatusMatchPath")
+	}
+	if _, err := o.statusJSONPath(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// excludeExprPath returns the compiled ExcludeExpr predicate, or nil when ExcludeExpr is not set.
+// The predicate is compiled on the first call only.
+func (o *Options) excludeExprPath() (*jsonpath.JSONPath, error) {
+	o.excludeExprOnce.Do(func() {
+		if o.ExcludeExpr == "" {
+			return
+		}
+		expr := strings.TrimPrefix(strings.TrimSpace(o.ExcludeExpr), ".")
+		if !strings.HasPrefix(expr, "@") {
+			expr = "@." + expr
+		}
+		path := jsonpath.New("exclude-expr").AllowMissingKeys(true)
+		if err := path.Parse(fmt.Sprintf("{[?(%s)]}", expr)); err != nil {
+			o.excludeExprErr = fmt.Errorf("invalid exclude expression %q: %w", o.ExcludeExpr, err)
+			return
+		}
+		o.excludeExpr = path
+	})
+	return o.excludeExpr, o.excludeExprErr
+}
+
+// statusJSONPath returns the compiled StatusMatchPath, or nil when StatusMatchPath is not set. The braces of the
+// JSONPath template are optional. The path is compiled on the first call only.
+func (o *Options) statusJSONPath() (*jsonpath.JSONPath, error) {
+	o.statusPathOnce.Do(func() {
+		if o.StatusMatchPath == "" {
+			return
+		}
+		template := strings.TrimSpace(o.StatusMatchPath)
+		if !strings.HasPrefix(template, "{") {
+			template = "{" + template + "}"
+		}
+		path := jsonpath.New("status-match-path").AllowMissingKeys(true)
+		if err := path.Parse(template); err != nil {
+			o.statusPathErr = fmt.Errorf("invalid status path %q: %w", o.StatusMatchPath, err)
+			return
+		}
+		o.statusPath = path
+	})
+	return o.statusPath, o.statusPathErr
+}
+
+// namespacesRegexes returns the compiled include and exclude namespace regular expressions, nil when
+// they are not set. The regular expressions are compiled on the first call only.
+func (o *Options) namespacesRegexes() (include, exclude *regexp.Regexp, err error) {
+	o.namespacesRegexOnce.Do(func() {
+		for _, regex := range []struct {
+			name    string
+			pattern string
+			into    **regexp.Regexp
+		}{
+			{"include", o.IncludeNamespacesRegex, &o.includeNamespacesRegex},
+			{"exclude", o.ExcludeNamespacesRegex, &o.excludeNamespacesRegex},
+		} {
+			if regex.pattern == "" {
+				continue
+			}
+			compiled, err := regexp.Compile(regex.pattern)
+			if err != nil {
+				o.namespacesRegexErr = fmt.Errorf("invalid %s namespaces regex %q: %w", regex.name, regex.pattern, err)
+				return
+			}
+			*regex.into = compiled
+		}
+	})
+	return o.includeNamespacesRegex, o.excludeNamespacesRegex, o.namespacesRegexErr
+}
+
+// HasIncludedNamespaces checks if the scan is limited to included namespaces, by name or regular expression
+func (o *Options) HasIncludedNamespaces() bool {
+	return len(o.IncludeNamespaces) > 0 || o.IncludeNamespacesRegex != ""
+}
+
+// UsedLabel returns the label key marking resources as used, and the values it accepts
+func (o *Options) UsedLabel() (string, []string) {
+	var key string
+	var values []string
+	if o != nil {
+		key, values = o.UsedLabelKey, o.UsedLabelValues
+	}
+	if key == "" {
+		key = DefaultUsedLabelKey
+	}
+	if len(values) == 0 {
+		values = []string{"true"}
+	}
+	return key, values
+}
+
+// ListLabelSelector returns the label selector resources are listed with, combining IncludeLabels and LabelSelector
+func (o *Options) ListLabelSelector() string {
+	var selectors []string
+	for _, selector := range []string{o.IncludeLabels, o.LabelSelector} {
+		if selector != "" {
+			selectors = append(selectors, selector)
+		}
+	}
+	return strings.Join(selectors, ",")
+}
+
+// MinStuckDurationValue returns how long resources must be pending deletion to be reported, zero when
+// MinStuckDuration is not set
+func (o *Options) MinStuckDurationValue() (time.Duration, error) {
+	if o == nil || o.MinStuckDuration == "" {
+		return 0, nil
+	}
+	minStuckDuration, err := time.ParseDuration(o.MinStuckDuration)
+	if err != nil {
+		return 0, err
+	}
+	if minStuckDuration < 0 {
+		return 0, errors.New("MinStuckDuration must be a non-negative duration")
+	}
+	return minStuckDuration, nil
+}
+
+// MatchesFinalizer reports whether one of the finalizers matches FinalizerMatch, always true when it is empty
+func (o *Options) MatchesFinalizer(finalizers []string) bool {
+	if o == nil || len(o.FinalizerMatch) == 0 {
+		return true
+	}
+	for _, finalizer := range finalizers {
+		for _, pattern := range o.FinalizerMatch {
+			if matched, _ := path.Match(pattern, finalizer); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchesStatus reports whether the object content has StatusMatchValue at StatusMatchPath, or any non-empty value
+// when StatusMatchValue is empty. It is always true when StatusMatchPath is empty, and false when the object does not
+// have the field or the path is invalid. A path matching several values, e.g. of conditions, matches when
+// one of them does.
+func (o *Options) MatchesStatus(content map[string]interface{}) bool {
+	if o == nil || o.StatusMatchPath == "" {
+		return true
+	}
+	path, err := o.statusJSONPath()
+	if err != nil {
+		return false
+	}
+	results, err := path.FindResults(content)
+	if err != nil {
+		return false
+	}
+	for _, result := range results {
+		for _, value := range result {
+			text := fmt.Sprint(value.Interface())
+			if (o.StatusMatchValue == "" && text != "") || (o.StatusMatchValue != "" && text == o.StatusMatchValue) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ActiveSinceTime returns the time namespaces must have changed after to be scanned.
+// The zero time is returned when ActiveSince is not set.
+func (o *Options) ActiveSinceTime() (time.Time, error) {
+	if o.ActiveSince == "" {
+		return time.Time{}, nil
+	}
+	activeSince, err := time.ParseDuration(o.ActiveSince)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-activeSince), nil
+}
+
+// Clone returns a copy of the options without the state cached while scanning, e.g. the selected
+// namespaces, so the copy can be used to scan another cluster
+func (o *Options) Clone() *Options {
+	clone := &Options{}
+	src, dst := reflect.ValueOf(o).Elem(), reflect.ValueOf(clone).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		if src.Type().Field(i).IsExported() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	return clone
+}
+
 // Modify modifies the options
 func (o *Options) Modify() {
 	o.modifyLabels()
 }
 
-// Namespaces returns the namespaces, only called once
-func (o *Options) Namespaces(clientset kubernetes.Interface) []string {
+// Namespaces returns the namespaces, only selected once. Namespaces are selected in this order:
+//   - IncludeNamespaces and IncludeNamespacesRegex select the namespaces, the exclude options and the
+//     system namespaces are then ignored, so explicitly included system namespaces are scanned.
+//   - Otherwise every namespace is selected, except ExcludeNamespaces, the ones matching
+//     ExcludeNamespacesRegex and the SystemNamespaces unless IncludeSystemNamespaces is set.
+//   - Either way the namespaces must match NamespaceSelector, which cannot be combined with
+//     IncludeNamespaces, and be at least MinNamespaceAge old.
+func (o *Options) Namespaces(clientset kubernetes.Interface) ([]string, error) {
 	o.once.Do(func() {
-		namespaces := make([]string, 0)
-		namespacesMap := make(map[string]bool)
-		if len(o.IncludeNamespaces) > 0 && len(o.ExcludeNamespaces) > 0 {
-			fmt.Fprintf(os.Stderr, "Exclude namespaces can't be used together with include namespaces. Ignoring --exclude-namespaces (-e) flag\n")
-			o.ExcludeNamespaces = nil
+		o.namespace, o.namespaceErr = o.selectNamespaces(clientset)
+	})
+	return o.namespace, o.namespaceErr
+}
+
+func (o *Options) selectNamespaces(clientset kubernetes.Interface) ([]string, error) {
+	namespaces := make([]string, 0)
+	namespacesMap := make(map[string]bool)
+	if o.HasIncludedNamespaces() && (len(o.ExcludeNamespaces) > 0 || o.ExcludeNamespacesRegex != "") {
+		fmt.Fprintf(os.Stderr, "Exclude namespaces can't be used together with include namespaces. Ignoring --exclude-namespaces (-e) and --exclude-namespaces-regex flags\n")
+		o.ExcludeNamespaces = nil
+	}
+	if len(o.IncludeNamespaces) > 0 && o.NamespaceSelector != "" {
+		return nil, errors.New("IncludeNamespaces and NamespaceSelector cannot be used together, use IncludeNamespacesRegex to narrow the selected namespaces down by name")
+	}
+	includeNamespaces := o.IncludeNamespaces
+	excludeNamespaces := o.ExcludeNamespaces
+	includeRegex, excludeRegex, err := o.namespacesRegexes()
+	if err != nil {
+		return nil, err
+	}
+	if o.HasIncludedNamespaces() {
+		excludeRegex = nil
+	}
+	if _, err := labels.Parse(o.NamespaceSelector); err != nil {
+		return nil, fmt.Errorf("invalid namespace selector %q: %w", o.NamespaceSelector, err)
+	}
+
+	if includeRegex != nil {
+		namespaceList, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: o.NamespaceSelector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve namespaces: %w", err)
 		}
-		includeNamespaces := o.IncludeNamespaces
-		excludeNamespaces := o.ExcludeNamespaces
+		for _, ns := range namespaceList.Items {
+			if includeRegex.MatchString(ns.Name) {
+				namespacesMap[ns.Name] = o.hasMinNamespaceAge(ns.CreationTimestamp)
+			}
+		}
+	}
 
-		if len(o.IncludeNamespaces) > 0 {
+	if o.HasIncludedNamespaces() {
 
-			for _, ns := range includeNamespaces {
+		for _, ns := range includeNamespaces {
 
-				_, err := clientset.CoreV1().Namespaces().Get(context.TODO(), ns, metav1.GetOptions{})
-				if err == nil {
-					namespacesMap[ns] = true
-				} else {
-					fmt.Fprintf(os.Stderr, "namespace [%s] not found\n", ns)
-				}
-			}
-		} else {
-			namespaceList, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+			namespace, err := clientset.CoreV1().Namespaces().Get(context.TODO(), ns, metav1.GetOptions{})
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to retrieve namespaces: %v\n", err)
-				os.Exit(1)
+				fmt.Fprintf(os.Stderr, "namespace [%s] not found\n", ns)
+			} else {
+				namespacesMap[ns] = o.hasMinNamespaceAge(namespace.CreationTimestamp)
 			}
+		}
+	} else {
+		namespaceList, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: o.NamespaceSelector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve namespaces: %w", err)
+		}
 
-			for _, ns := range namespaceList.Items {
-				namespacesMap[ns.Name] = false
-			}
+		for _, ns := range namespaceList.Items {
+			namespacesMap[ns.Name] = false
+		}
 
-			for _, ns := range namespaceList.Items {
-				namespacesMap[ns.Name] = true
+		for _, ns := range namespaceList.Items {
+			namespacesMap[ns.Name] = o.hasMinNamespaceAge(ns.CreationTimestamp)
+		}
+		for _, ns := range excludeNamespaces {
+			if _, exists := namespacesMap[ns]; exists {
+				namespacesMap[ns] = false
 			}
-			for _, ns := range excludeNamespaces {
-				if _, exists := namespacesMap[ns]; exists {
+		}
+		for _, ns := range o.excludedSystemNamespaces() {
+			if _, exists := namespacesMap[ns]; exists {
+				namespacesMap[ns] = false
+			}
+		}
+		if excludeRegex != nil {
+			for ns := range namespacesMap {
+				if excludeRegex.MatchString(ns) {
 					namespacesMap[ns] = false
 				}
 			}
 		}
-		for ns := range namespacesMap {
-			if namespacesMap[ns] {
-				namespaces = append(namespaces, ns)
-			}
+	}
+	for ns := range namespacesMap {
+		if namespacesMap[ns] {
+			namespaces = append(namespaces, ns)
 		}
-		o.namespace = namespaces
-	})
-	return o.namespace
+	}
+	return namespaces, nil
+}
+
+// IncludesNamespace checks a namespace against the include and exclude namespace lists and regular
+// expressions, without looking the namespace up in the cluster. The include options take precedence
+// over the exclude options. NamespaceSelector is not checked, since it needs the namespace labels.
+func (o *Options) IncludesNamespace(namespace string) bool {
+	includeRegex, excludeRegex, _ := o.namespacesRegexes()
+	if o.HasIncludedNamespaces() {
+		return slices.Contains(o.IncludeNamespaces, namespace) || (includeRegex != nil && includeRegex.MatchString(namespace))
+	}
+	return !slices.Contains(o.ExcludeNamespaces, namespace) && !slices.Contains(o.excludedSystemNamespaces(), namespace) &&
+		(excludeRegex == nil || !excludeRegex.MatchString(namespace))
+}
+
+// SelectsNamespace applies every namespace filter Namespaces selects the namespaces with to a single
+// namespace: the include and exclude options, NamespaceSelector and MinNamespaceAge
+func (o *Options) SelectsNamespace(namespace *corev1.Namespace) bool {
+	if !o.IncludesNamespace(namespace.Name) {
+		return false
+	}
+	namespaceSelector, err := labels.Parse(o.NamespaceSelector)
+	if err != nil || !namespaceSelector.Matches(labels.Set(namespace.Labels)) {
+		return false
+	}
+	return o.hasMinNamespaceAge(namespace.CreationTimestamp)
+}
+
+// hasMinNamespaceAge checks if a namespace is at least MinNamespaceAge old.
+// Every namespace passes when MinNamespaceAge is not set.
+func (o *Options) hasMinNamespaceAge(creationTime metav1.Time) bool {
+	if o.MinNamespaceAge == "" {
+		return true
+	}
+	minNamespaceAge, err := time.ParseDuration(o.MinNamespaceAge)
+	if err != nil {
+		return true
+	}
+	return time.Since(creationTime.Time) >= minNamespaceAge
 }
 
 func (o *Options) modifyLabels() {
@@ -0,0 +1,290 @@
+package filters
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/strings/slices"
+)
+
+func newTestNamespace(name string, age time.Duration) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.Time{Time: time.Now().Add(-age)},
+		},
+	}
+}
+
+func TestNamespacesMinNamespaceAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *Options
+		expected []string
+	}{
+		{"NoMinimumAge", &Options{}, []string{"new", "old"}},
+		{"MinimumAge", &Options{MinNamespaceAge: "1h"}, []string{"old"}},
+		{"MinimumAgeWithIncludeNamespaces", &Options{MinNamespaceAge: "1h", IncludeNamespaces: []string{"new", "old"}}, []string{"old"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(newTestNamespace("new", time.Minute), newTestNamespace("old", 24*time.Hour))
+			namespaces, err := tt.opts.Namespaces(clientset)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			sort.Strings(namespaces)
+			if !slices.Equal(namespaces, tt.expected) {
+				t.Errorf("Expected namespaces %v, got %v", tt.expected, namespaces)
+			}
+		})
+	}
+}
+
+func TestListSelectors(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          *Options
+		expected      string
+		expectedError bool
+	}{
+		{"Empty", &Options{}, "", false},
+		{"IncludeLabels", &Options{IncludeLabels: "app=web"}, "app=web", false},
+		{"LabelSelector", &Options{LabelSelector: "app.kubernetes.io/managed-by=argocd"}, "app.kubernetes.io/managed-by=argocd", false},
+		{"Both", &Options{IncludeLabels: "app=web", LabelSelector: "tier in (frontend,backend)"}, "app=web,tier in (frontend,backend)", false},
+		{"Invalid", &Options{LabelSelector: "app in web"}, "", true},
+		{"FieldSelector", &Options{LabelSelector: "app=web", FieldSelector: "metadata.name!=settings"}, "app=web", false},
+		{"InvalidFieldSelector", &Options{FieldSelector: "metadata.name"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("Expected error: %v, got: %v", tt.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if selector := tt.opts.ListLabelSelector(); selector != tt.expected {
+				t.Errorf("Expected label selector %q, got %q", tt.expected, selector)
+			}
+		})
+	}
+}
+
+func TestNamespacesRegex(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *Options
+		expected []string
+	}{
+		{"IncludeRegex", &Options{IncludeNamespacesRegex: "^team-.*-prod$"}, []string{"team-a-prod", "team-b-prod"}},
+		{"IncludeRegexAndNames", &Options{IncludeNamespacesRegex: "^team-a-", IncludeNamespaces: []string{"default"}}, []string{"default", "team-a-dev", "team-a-prod"}},
+		{"ExcludeRegex", &Options{ExcludeNamespacesRegex: "^team-"}, []string{"default"}},
+		{"ExcludeRegexAndNames", &Options{ExcludeNamespacesRegex: "-dev$", ExcludeNamespaces: []string{"default"}}, []string{"team-a-prod", "team-b-prod"}},
+		{"IncludeTakesPrecedence", &Options{IncludeNamespacesRegex: "^team-a-", ExcludeNamespacesRegex: "-dev$"}, []string{"team-a-dev", "team-a-prod"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			clientset := fake.NewSimpleClientset(
+				newTestNamespace("default", time.Hour),
+				newTestNamespace("team-a-dev", time.Hour),
+				newTestNamespace("team-a-prod", time.Hour),
+				newTestNamespace("team-b-prod", time.Hour),
+			)
+			namespaces, err := tt.opts.Namespaces(clientset)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			sort.Strings(namespaces)
+			if !slices.Equal(namespaces, tt.expected) {
+				t.Errorf("Expected namespaces %v, got %v", tt.expected, namespaces)
+			}
+			for _, namespace := range []string{"default", "team-a-dev", "team-a-prod", "team-b-prod"} {
+				if included := tt.opts.IncludesNamespace(namespace); included != slices.Contains(tt.expected, namespace) {
+					t.Errorf("Expected IncludesNamespace(%s) to be %v", namespace, !included)
+				}
+			}
+		})
+	}
+}
+
+func TestNamespacesRegexInvalid(t *testing.T) {
+	for _, opts := range []*Options{{IncludeNamespacesRegex: "team-("}, {ExcludeNamespacesRegex: "[a-"}} {
+		if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "namespaces regex") {
+			t.Errorf("Expected an invalid namespaces regex error, got %v", err)
+		}
+		// Namespaces are not selected without validating the options first either
+		if _, err := opts.Namespaces(fake.NewSimpleClientset()); err == nil || !strings.Contains(err.Error(), "namespaces regex") {
+			t.Errorf("Expected the namespaces selection to fail, got %v", err)
+		}
+	}
+}
+
+func TestNamespacesSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *Options
+		expected []string
+	}{
+		{"Selector", &Options{NamespaceSelector: "env=staging"}, []string{"team-a-staging", "team-b-staging"}},
+		{"SelectorAndIncludeRegex", &Options{NamespaceSelector: "env=staging", IncludeNamespacesRegex: "^team-b-"}, []string{"team-b-staging"}},
+		{"SelectorAndExcludeNamespaces", &Options{NamespaceSelector: "env=staging", ExcludeNamespaces: []string{"team-a-staging"}}, []string{"team-b-staging"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var objects []runtime.Object
+			for name, env := range map[string]string{"team-a-staging": "staging", "team-b-staging": "staging", "team-a-prod": "prod", "team-b-prod": "prod"} {
+				namespace := newTestNamespace(name, time.Hour)
+				namespace.Labels = map[string]string{"env": env}
+				objects = append(objects, namespace)
+			}
+			namespaces, err := tt.opts.Namespaces(fake.NewSimpleClientset(objects...))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			sort.Strings(namespaces)
+			if !slices.Equal(namespaces, tt.expected) {
+				t.Errorf("Expected namespaces %v, got %v", tt.expected, namespaces)
+			}
+		})
+	}
+
+	if err := (&Options{NamespaceSelector: "env in staging"}).Validate(); err == nil || !strings.Contains(err.Error(), "invalid namespace selector") {
+		t.Errorf("Expected an invalid namespace selector error, got %v", err)
+	}
+
+	// Namespaces included by name cannot be narrowed down by a selector, it could only drop some of them
+	includeAndSelector := &Options{NamespaceSelector: "env=staging", IncludeNamespaces: []string{"team-a-staging", "team-a-prod"}}
+	if err := includeAndSelector.Validate(); err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+		t.Errorf("Expected the include namespaces and selector to be refused, got %v", err)
+	}
+	if _, err := includeAndSelector.Namespaces(fake.NewSimpleClientset()); err == nil {
+		t.Error("Expected the namespaces not to be selected for the include namespaces and selector")
+	}
+}
+
+func TestNamespacesSystemNamespaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     *Options
+		expected []string
+	}{
+		{"NoneByDefault", &Options{}, []string{"default", "kube-node-lease", "kube-public", "kube-system", "platform"}},
+		{"DefaultSystemNamespaces", &Options{SystemNamespaces: DefaultSystemNamespaces}, []string{"default", "platform"}},
+		{"ExplicitlyIncluded", &Options{SystemNamespaces: DefaultSystemNamespaces, IncludeNamespaces: []string{"kube-system", "default"}}, []string{"default", "kube-system"}},
+		{"IncludeSystemNamespaces", &Options{SystemNamespaces: DefaultSystemNamespaces, IncludeSystemNamespaces: true}, []string{"default", "kube-node-lease", "kube-public", "kube-system", "platform"}},
+		{"CustomSystemNamespaces", &Options{SystemNamespaces: []string{"platform"}}, []string{"default", "kube-node-lease", "kube-public", "kube-system"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(
+				newTestNamespace("default", time.Hour),
+				newTestNamespace("platform", time.Hour),
+				newTestNamespace("kube-system", time.Hour),
+				newTestNamespace("kube-public", time.Hour),
+				newTestNamespace("kube-node-lease", time.Hour),
+			)
+			namespaces, err := tt.opts.Namespaces(clientset)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			sort.Strings(namespaces)
+			if !slices.Equal(namespaces, tt.expected) {
+				t.Errorf("Expected namespaces %v, got %v", tt.expected, namespaces)
+			}
+			for _, namespace := range []string{"default", "platform", "kube-system", "kube-public", "kube-node-lease"} {
+				if included := tt.opts.IncludesNamespace(namespace); included != slices.Contains(tt.expected, namespace) {
+					t.Errorf("Expected IncludesNamespace(%q) to be %v, got %v", namespace, !included, included)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchesFinalizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		match      []string
+		finalizers []string
+		expected   bool
+	}{
+		{"NoMatch", nil, []string{"example.com/cleanup"}, true},
+		{"ExactName", []string{"mycompany.io/cleanup"}, []string{"example.com/cleanup", "mycompany.io/cleanup"}, true},
+		{"Domain", []string{"mycompany.io/*"}, []string{"mycompany.io/backup"}, true},
+		{"OtherDomain", []string{"mycompany.io/*"}, []string{"example.com/cleanup"}, false},
+		{"NoFinalizers", []string{"*"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matched := (&Options{FinalizerMatch: tt.match}).MatchesFinalizer(tt.finalizers); matched != tt.expected {
+				t.Errorf("Expected %v to match %v: %v, got %v", tt.finalizers, tt.match, tt.expected, matched)
+			}
+		})
+	}
+
+	if err := (&Options{FinalizerMatch: []string{"mycompany.io/[cleanup"}}).Validate(); err == nil || !strings.Contains(err.Error(), "invalid finalizer match") {
+		t.Errorf("Expected an invalid finalizer match error, got %v", err)
+	}
+}
+
+func TestMatchesStatus(t *testing.T) {
+	terminating := map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase": "Terminating",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False"},
+				map[string]interface{}{"type": "Terminating", "status": "True"},
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		path     string
+		value    string
+		content  map[string]interface{}
+		expected bool
+	}{
+		{"NoPath", "", "", terminating, true},
+		{"Phase", ".status.phase", "Terminating", terminating, true},
+		{"Braces", "{.status.phase}", "Terminating", terminating, true},
+		{"OtherPhase", ".status.phase", "Bound", terminating, false},
+		{"Condition", `.status.conditions[?(@.type=="Terminating")].status`, "True", terminating, true},
+		{"AnyValue", ".status.phase", "", terminating, true},
+		{"MissingPath", ".status.phase", "Terminating", map[string]interface{}{"spec": map[string]interface{}{}}, false},
+		{"MissingValue", ".status.reason", "", terminating, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matched := (&Options{StatusMatchPath: tt.path, StatusMatchValue: tt.value}).MatchesStatus(tt.content); matched != tt.expected {
+				t.Errorf("Expected %s == %q to be %v, got %v", tt.path, tt.value, tt.expected, matched)
+			}
+		})
+	}
+
+	if err := (&Options{StatusMatchPath: ".status.conditions[?(@.type=="}).Validate(); err == nil || !strings.Contains(err.Error(), "invalid status path") {
+		t.Errorf("Expected an invalid status path error, got %v", err)
+	}
+	if err := (&Options{StatusMatchValue: "Terminating"}).Validate(); err == nil {
+		t.Error("Expected StatusMatchValue without StatusMatchPath to be rejected")
+	}
+}
@@ -7,6 +7,7 @@ func NewDefaultRegistry() Registry {
 		LabelFilterName:    LabelFilter,
 		AgeFilterName:      AgeFilter,
 		KorLabelFilterName: KorLabelFilter,
+		ExprFilterName:     ExprFilter,
 	}
 }
 
@@ -0,0 +1,162 @@
+package kor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+	"sigs.k8s.io/yaml"
+)
+
+// PersistenceEntry counts in how many of the aggregated runs a single object was reported
+type PersistenceEntry struct {
+	Namespace    string `json:"namespace"`
+	ResourceType string `json:"resourceType"`
+	Name         string `json:"name"`
+	Occurrences  int    `json:"occurrences"`
+}
+
+// RollingReport merges the results of successive runs. Objects reported in every run are
+// persistent offenders, all others are transient.
+type RollingReport struct {
+	Runs       int                `json:"runs"`
+	Persistent []PersistenceEntry `json:"persistent"`
+	Transient  []PersistenceEntry `json:"transient"`
+}
+
+// parseResultResources reads a kor json output, with or without --show-reason, into
+// map[namespace]map[resourceType][]resourceNames
+func parseResultResources(data []byte) (map[string]map[string][]string, error) {
+	var raw map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	resources := make(map[string]map[string][]string)
+	for namespace, resourceMap := range raw {
+		resources[namespace] = make(map[string][]string)
+		for resourceType, entries := range resourceMap {
+			var names []string
+			if err := json.Unmarshal(entries, &names); err == nil {
+				resources[namespace][resourceType] = names
+				continue
+			}
+			var infos []ResourceInfo
+			if err := json.Unmarshal(entries, &infos); err != nil {
+				return nil, fmt.Errorf("unexpected entries for %s in namespace %s: %w", resourceType, namespace, err)
+			}
+			for _, info := range infos {
+				resources[namespace][resourceType] = append(resources[namespace][resourceType], info.Name)
+			}
+		}
+	}
+	return resources, nil
+}
+
+// AggregateResults builds a rolling report from the json outputs of successive runs
+func AggregateResults(results [][]byte) (*RollingReport, error) {
+	occurrences := make(map[PersistenceEntry]int)
+	for i, data := range results {
+		resources, err := parseResultResources(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse result %d: %w", i+1, err)
+		}
+		// An object listed twice in the same run still counts as a single occurrence
+		seen := make(map[PersistenceEntry]bool)
+		for namespace, resourceMap := range resources {
+			for resourceType, names := range resourceMap {
+				for _, name := range names {
+					key := PersistenceEntry{Namespace: namespace, ResourceType: resourceType, Name: name}
+					if !seen[key] {
+						seen[key] = true
+						occurrences[key]++
+					}
+				}
+			}
+		}
+	}
+
+	report := &RollingReport{Runs: len(results)}
+	for key, count := range occurrences {
+		key.Occurrences = count
+		if count == len(results) {
+			report.Persistent = append(report.Persistent, key)
+		} else {
+			report.Transient = append(report.Transient, key)
+		}
+	}
+	sortPersistenceEntries(report.Persistent)
+	sortPersistenceEntries(report.Transient)
+	return report, nil
+}
+
+// AggregateResultFiles builds a rolling report from json output files of successive runs
+func AggregateResultFiles(paths []string) (*RollingReport, error) {
+	results := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read result file: %w", err)
+		}
+		results = append(results, data)
+	}
+	return AggregateResults(results)
+}
+
+// sortPersistenceEntries orders the most frequent offenders first
+func sortPersistenceEntries(entries []PersistenceEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Occurrences != entries[j].Occurrences {
+			return entries[i].Occurrences > entries[j].Occurrences
+		}
+		if entries[i].Namespace != entries[j].Namespace {
+			return entries[i].Namespace < entries[j].Namespace
+		}
+		if entries[i].ResourceType != entries[j].ResourceType {
+			return entries[i].ResourceType < entries[j].ResourceType
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// FormatRollingReport renders the report in the requested output format
+func FormatRollingReport(report *RollingReport, outputFormat string) (string, error) {
+	switch outputFormat {
+	case "table":
+		var buf bytes.Buffer
+		table := tablewriter.NewWriter(&buf)
+		table.SetColWidth(60)
+		table.SetHeader([]string{"#", "NAMESPACE", "RESOURCE TYPE", "RESOURCE NAME", "OCCURRENCES", "STATUS"})
+		var index int
+		for _, section := range []struct {
+			status  string
+			entries []PersistenceEntry
+		}{{"persistent", report.Persistent}, {"transient", report.Transient}} {
+			for _, entry := range section.entries {
+				table.Append(getTableRow(index, entry.Namespace, entry.ResourceType, entry.Name, fmt.Sprintf("%d/%d", entry.Occurrences, report.Runs), section.status))
+				index++
+			}
+		}
+		if index == 0 {
+			return fmt.Sprintf("No resources found across %d runs\n", report.Runs), nil
+		}
+		table.Render()
+		return fmt.Sprintf("Rolling report across %d runs:\n%s\n", report.Runs, buf.String()), nil
+	case "json", "yaml":
+		response, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		if outputFormat == "yaml" {
+			if response, err = yaml.JSONToYAML(response); err != nil {
+				return "", err
+			}
+		}
+		return string(response), nil
+	default:
+		return "", fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+}
@@ -0,0 +1,60 @@
+package kor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAggregateResults(t *testing.T) {
+	results := [][]byte{
+		[]byte(`{"test-namespace": {"pods": ["stuck-pod", "flaky-pod"]}}`),
+		[]byte(`{"test-namespace": {"pods": [{"name": "stuck-pod", "reason": "Pending deletion waiting for finalizers"}]}}`),
+		[]byte(`{"test-namespace": {"pods": ["stuck-pod", "stuck-pod"]}, "other-namespace": {"secrets": ["new-secret"]}}`),
+	}
+
+	report, err := AggregateResults(results)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expectedPersistent := []PersistenceEntry{
+		{Namespace: "test-namespace", ResourceType: "pods", Name: "stuck-pod", Occurrences: 3},
+	}
+	// Entries with equal occurrences are ordered by namespace
+	expectedTransient := []PersistenceEntry{
+		{Namespace: "other-namespace", ResourceType: "secrets", Name: "new-secret", Occurrences: 1},
+		{Namespace: "test-namespace", ResourceType: "pods", Name: "flaky-pod", Occurrences: 1},
+	}
+
+	if report.Runs != 3 {
+		t.Errorf("Expected 3 runs, got %d", report.Runs)
+	}
+	if !reflect.DeepEqual(report.Persistent, expectedPersistent) {
+		t.Errorf("Expected persistent %v, got %v", expectedPersistent, report.Persistent)
+	}
+	if !reflect.DeepEqual(report.Transient, expectedTransient) {
+		t.Errorf("Expected transient %v, got %v", expectedTransient, report.Transient)
+	}
+}
+
+func TestAggregateResultFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "day1.json")
+	if err := os.WriteFile(path, []byte(`{"test-namespace": {"pods": ["stuck-pod"]}}`), 0o600); err != nil {
+		t.Fatalf("Error writing result file: %v", err)
+	}
+
+	if _, err := AggregateResultFiles([]string{path, filepath.Join(dir, "missing.json")}); err == nil {
+		t.Errorf("Expected an error for a missing result file")
+	}
+
+	report, err := AggregateResultFiles([]string{path})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(report.Persistent) != 1 || len(report.Transient) != 0 {
+		t.Errorf("Expected a single persistent entry, got %+v", report)
+	}
+}
@@ -265,7 +265,11 @@ func getUnusedNetworkPolicies(clientset kubernetes.Interface, namespace string,
 
 func GetUnusedAllNamespaced(filterOpts *filters.Options, clientset kubernetes.Interface, outputFormat string, opts Opts) (string, error) {
 	resources := make(map[string]map[string][]ResourceInfo)
-	for _, namespace := range filterOpts.Namespaces(clientset) {
+	namespaces, err := filterOpts.Namespaces(clientset)
+	if err != nil {
+		return "", err
+	}
+	for _, namespace := range namespaces {
 		switch opts.GroupBy {
 		case "namespace":
 			resources[namespace] = make(map[string][]ResourceInfo)
@@ -368,7 +372,7 @@ func GetUnusedAll(filterOpts *filters.Options, clientset kubernetes.Interface, a
 	}
 
 	// Skip getting non-namespaced resources if --include-namespaces flag is used
-	if len(filterOpts.IncludeNamespaces) > 0 {
+	if filterOpts.HasIncludedNamespaces() {
 		return unusedAllNamespaced, nil
 	}
 
@@ -0,0 +1,111 @@
+package kor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// backupActionDelete records a backup taken before deleting a resource pending deletion
+	backupActionDelete = "delete"
+	// backupActionRemoveFinalizers records a backup taken before removing the finalizers of a resource
+	backupActionRemoveFinalizers = "remove-finalizers"
+)
+
+// unsafeBackupPathChars matches the characters replaced in the backup directory of a cluster, whose name
+// can be a kubeconfig path or an API server URL
+var unsafeBackupPathChars = regexp.MustCompile(`[^\w.-]`)
+
+// BackupProvenance is written next to the manifest of every backed up resource, recording who removed its
+// finalizers, when, on which cluster and why, so restores can be audited
+type BackupProvenance struct {
+	User    string    `json:"user"`
+	Time    time.Time `json:"time"`
+	Cluster string    `json:"cluster,omitempty"`
+	// Action is delete or remove-finalizers
+	Action string `json:"action"`
+	// Finalizers are the finalizers removed, which blocked the deletion of the resource
+	Finalizers []string `json:"finalizers"`
+	// UID, ResourceVersion and DeletionTimestamp are those of the resource when it was backed up, they are
+	// left out of the manifest so it can be applied again
+	UID               string       `json:"uid"`
+	ResourceVersion   string       `json:"resourceVersion"`
+	DeletionTimestamp *metav1.Time `json:"deletionTimestamp,omitempty"`
+}
+
+// backupPath returns where the manifest of a resource is backed up below BackupDir, by namespace, resource
+// type and name. Its provenance is written next to it.
+func backupPath(backupDir string, object *unstructured.Unstructured, gvr schema.GroupVersionResource) string {
+	return filepath.Join(backupDir, reportedNamespace(object.GetNamespace()), gvr.GroupResource().String(), object.GetName()+".yaml")
+}
+
+// clusterBackupDir returns the backup directory of a cluster of a multi-cluster scan, so the resources of
+// the clusters are not overwritten by each other
+func clusterBackupDir(backupDir, cluster string) string {
+	if backupDir == "" {
+		return ""
+	}
+	return filepath.Join(backupDir, unsafeBackupPathChars.ReplaceAllString(cluster, "_"))
+}
+
+// backupResource saves the manifest of a resource to BackupDir before its finalizers are removed, along with
+// its provenance. Dry runs change nothing and are not backed up.
+func backupResource(object *unstructured.Unstructured, gvr schema.GroupVersionResource, action string, removed []string, opts Opts) error {
+	if opts.BackupDir == "" || opts.DryRun {
+		return nil
+	}
+
+	manifest := object.DeepCopy()
+	for _, field := range []string{"resourceVersion", "uid", "managedFields", "deletionTimestamp", "deletionGracePeriodSeconds"} {
+		unstructured.RemoveNestedField(manifest.Object, "metadata", field)
+	}
+	content, err := yaml.Marshal(manifest.Object)
+	if err != nil {
+		return err
+	}
+	provenance, err := json.MarshalIndent(BackupProvenance{
+		User:              backupUser(),
+		Time:              time.Now().UTC(),
+		Cluster:           opts.ClusterName,
+		Action:            action,
+		Finalizers:        removed,
+		UID:               string(object.GetUID()),
+		ResourceVersion:   object.GetResourceVersion(),
+		DeletionTimestamp: object.GetDeletionTimestamp(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := backupPath(opts.BackupDir, object, gvr)
+	// Manifests can hold secrets, they are only readable by the user running kor
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create the backup directory of %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	provenancePath := path[:len(path)-len(filepath.Ext(path))] + ".provenance.json"
+	if err := os.WriteFile(provenancePath, append(provenance, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write the provenance of %s: %w", path, err)
+	}
+	return nil
+}
+
+// backupUser returns the name of the user running kor, $USER when it cannot be looked up
+func backupUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,157 @@
+package kor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+func TestBackupBeforeDelete(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	newClient := func() *fakedynamic.FakeDynamicClient {
+		stuck := CreateTestUnstructered("TestResource", gvr.GroupVersion().String(), testNamespace, "stuck")
+		stuck.SetFinalizers([]string{"example.com/cleanup", "kubernetes.io/pv-protection", metav1.FinalizerDeleteDependents})
+		stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+		stuck.SetUID("1234")
+		stuck.SetResourceVersion("42")
+		return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck)
+	}
+	readBackup := func(t *testing.T, dir string) (*unstructured.Unstructured, BackupProvenance) {
+		t.Helper()
+		path := filepath.Join(dir, testNamespace, "testresources.testgroup", "stuck")
+		content, err := os.ReadFile(path + ".yaml")
+		if err != nil {
+			t.Fatalf("Expected the manifest to be backed up, got %v", err)
+		}
+		manifest := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(content, &manifest.Object); err != nil {
+			t.Fatalf("Expected a yaml manifest, got %q: %v", content, err)
+		}
+		content, err = os.ReadFile(path + ".provenance.json")
+		if err != nil {
+			t.Fatalf("Expected the provenance to be written, got %v", err)
+		}
+		var provenance BackupProvenance
+		if err := json.Unmarshal(content, &provenance); err != nil {
+			t.Fatalf("Expected a json provenance, got %q: %v", content, err)
+		}
+		return manifest, provenance
+	}
+
+	dir := t.TempDir()
+	opts := Opts{NoInteractive: true, BackupDir: dir, ClusterName: "prod"}
+	if _, err := DeleteResourceWithFinalizer(context.TODO(), []ResourceInfo{{Name: "stuck"}}, newClient(), testNamespace, gvr, &filters.Options{}, opts); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	manifest, provenance := readBackup(t, dir)
+	if manifest.GetName() != "stuck" || manifest.GetResourceVersion() != "" || manifest.GetUID() != "" || manifest.GetDeletionTimestamp() != nil {
+		t.Errorf("Expected the manifest without its server state, got %v", manifest.Object)
+	}
+	if !slices.Equal(manifest.GetFinalizers(), []string{"example.com/cleanup", "kubernetes.io/pv-protection", metav1.FinalizerDeleteDependents}) {
+		t.Errorf("Expected the manifest to keep its finalizers, got %v", manifest.GetFinalizers())
+	}
+	if provenance.Action != backupActionDelete || provenance.Cluster != "prod" || provenance.User == "" || provenance.Time.IsZero() {
+		t.Errorf("Expected who deleted the resource, when and on which cluster, got %+v", provenance)
+	}
+	// The protected finalizer and the one of the Background propagation policy are kept
+	if !slices.Equal(provenance.Finalizers, []string{"example.com/cleanup", metav1.FinalizerDeleteDependents}) {
+		t.Errorf("Expected the removed finalizers, got %v", provenance.Finalizers)
+	}
+	if provenance.UID != "1234" || provenance.ResourceVersion != "42" || provenance.DeletionTimestamp == nil {
+		t.Errorf("Expected the server state of the resource, got %+v", provenance)
+	}
+
+	dir = t.TempDir()
+	opts.BackupDir = dir
+	if _, err := RemoveFinalizers(context.TODO(), []ResourceInfo{{Name: "stuck"}}, newClient(), testNamespace, gvr, opts); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, provenance := readBackup(t, dir); provenance.Action != backupActionRemoveFinalizers ||
+		!slices.Equal(provenance.Finalizers, []string{"example.com/cleanup", metav1.FinalizerDeleteDependents}) {
+		t.Errorf("Expected the removed finalizers, got %+v", provenance)
+	}
+
+	// Dry runs change nothing and are not backed up
+	dir = t.TempDir()
+	opts.BackupDir = dir
+	opts.DryRun = true
+	if _, err := DeleteResourceWithFinalizer(context.TODO(), []ResourceInfo{{Name: "stuck"}}, newClient(), testNamespace, gvr, &filters.Options{}, opts); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected nothing to be backed up on a dry run, got %v", entries)
+	}
+}
+
+func TestBackupFailureLeavesResource(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	stuck := CreateTestUnstructered("TestResource", gvr.GroupVersion().String(), testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck)
+	// A file in place of the backup directory cannot be written below
+	backupDir := filepath.Join(t.TempDir(), "backups")
+	if err := os.WriteFile(backupDir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := DeleteResourceWithFinalizer(context.TODO(), []ResourceInfo{{Name: "stuck"}}, dynamicClient, testNamespace, gvr, &filters.Options{}, Opts{NoInteractive: true, BackupDir: backupDir})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// The resource is still reported, with why it was left untouched
+	if len(deleted) != 1 || deleted[0].Name != "stuck" || !strings.HasPrefix(deleted[0].Reason, "not deleted: backup failed: ") {
+		t.Errorf("Expected the resource to be reported as not deleted, got %v", deleted)
+	}
+	if actions := deletionActions(testNamespace, gvr.Resource, []ResourceInfo{{Name: "stuck"}}, deleted); actions[0].Action != deletionActionFailed {
+		t.Errorf("Expected the deletion to be reported as failed, got %+v", actions)
+	}
+	object, err := dynamicClient.Resource(gvr).Namespace(testNamespace).Get(context.TODO(), "stuck", metav1.GetOptions{})
+	if err != nil || len(object.GetFinalizers()) != 1 {
+		t.Errorf("Expected the finalizers to be kept, got %v, %v", object, err)
+	}
+
+	removed, err := RemoveFinalizers(context.TODO(), []ResourceInfo{{Name: "stuck"}}, dynamicClient, testNamespace, gvr, Opts{NoInteractive: true, BackupDir: backupDir})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(removed) != 1 || !strings.HasPrefix(removed[0].Reason, "finalizers not removed: backup failed: ") {
+		t.Errorf("Expected the resource to be reported with its finalizers kept, got %v", removed)
+	}
+
+	// Resources that cannot be fetched are reported as well
+	deleted, err = DeleteResourceWithFinalizer(context.TODO(), []ResourceInfo{{Name: "gone"}}, dynamicClient, testNamespace, gvr, &filters.Options{}, Opts{NoInteractive: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Name != "gone" || !strings.HasPrefix(deleted[0].Reason, "not deleted: get failed: ") {
+		t.Errorf("Expected the resource to be reported as not deleted, got %v", deleted)
+	}
+	if attempts := deletionAttempts([]ResourceInfo{{Name: "gone"}}, deleted); attempts["gone"] {
+		t.Errorf("Expected the deletion not to be requested, got %v", attempts)
+	}
+}
+
+func TestClusterBackupDir(t *testing.T) {
+	if dir := clusterBackupDir("", "prod"); dir != "" {
+		t.Errorf("Expected no backup directory without BackupDir, got %q", dir)
+	}
+	if dir := clusterBackupDir("backups", "https://10.0.0.1:6443"); dir != filepath.Join("backups", "https___10.0.0.1_6443") {
+		t.Errorf("Expected the cluster name to be made safe for a directory, got %q", dir)
+	}
+}
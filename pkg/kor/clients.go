@@ -0,0 +1,103 @@
+package kor
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	// Registers the auth provider plugins of client-go, so kubeconfigs of managed clusters never fail with
+	// "no Auth Provider found". The azure and gcp providers report the exec credential plugin to migrate to,
+	// e.g. kubelogin or gke-gcloud-auth-plugin, which client-go runs without any registration.
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+)
+
+// ClientOptions are the explicit inputs the Kubernetes clients are built from
+type ClientOptions struct {
+	// Kubeconfig is the path of the kubeconfig file, required unless InCluster is set
+	Kubeconfig string
+	// Context is the kubeconfig context to use, the current context when empty
+	Context string
+	// InCluster uses the service account of the pod kor runs in instead of a kubeconfig
+	InCluster bool
+	// QPS and Burst configure the client side rate limiter, the client-go defaults of 5 and 10 when zero.
+	// Scans list every resource type, so large clusters need higher limits, e.g. 50 and 100.
+	QPS   float32
+	Burst int
+}
+
+// Rate limits recommended for scans, used by the command line clients
+const (
+	DefaultQPS   float32 = 50
+	DefaultBurst int     = 100
+)
+
+// ClientQPS and ClientBurst are the rate limits of the clients GetConfig builds from the environment
+var (
+	ClientQPS   = DefaultQPS
+	ClientBurst = DefaultBurst
+)
+
+// Clients are the Kubernetes clients built by BuildClients
+type Clients struct {
+	Config    *rest.Config
+	Clientset kubernetes.Interface
+	Dynamic   dynamic.Interface
+}
+
+// inClusterConfig loads the service account config, replaced in tests
+var inClusterConfig = rest.InClusterConfig
+
+// BuildConfig returns the rest config described by the options. Unlike GetConfig it does not look at
+// the environment or default kubeconfig locations.
+func BuildConfig(opts ClientOptions) (*rest.Config, error) {
+	config, err := loadConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.QPS > 0 {
+		config.QPS = opts.QPS
+	}
+	if opts.Burst > 0 {
+		config.Burst = opts.Burst
+	}
+	return config, nil
+}
+
+func loadConfig(opts ClientOptions) (*rest.Config, error) {
+	if opts.InCluster {
+		if opts.Kubeconfig != "" || opts.Context != "" {
+			return nil, errors.New("in-cluster config cannot be combined with a kubeconfig or context")
+		}
+		return inClusterConfig()
+	}
+	if opts.Kubeconfig == "" {
+		return nil, errors.New("a kubeconfig path is required when not running in-cluster")
+	}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: opts.Kubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: opts.Context},
+	).ClientConfig()
+}
+
+// BuildClients returns the clientset and dynamic client described by the options
+func BuildClients(opts ClientOptions) (*Clients, error) {
+	config, err := BuildConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	return &Clients{Config: config, Clientset: clientset, Dynamic: dynamicClient}, nil
+}
@@ -0,0 +1,127 @@
+package kor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: first
+  cluster:
+    server: https://first.example.com
+- name: second
+  cluster:
+    server: https://second.example.com
+contexts:
+- name: first
+  context:
+    cluster: first
+    user: test
+- name: second
+  context:
+    cluster: second
+    user: test
+current-context: first
+users:
+- name: test
+  user:
+    token: test-token
+`
+
+func TestBuildClients(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := inClusterConfig
+	t.Cleanup(func() { inClusterConfig = previous })
+	inClusterConfig = func() (*rest.Config, error) {
+		return &rest.Config{Host: "https://in-cluster.example.com"}, nil
+	}
+
+	tests := []struct {
+		name          string
+		opts          ClientOptions
+		expectedHost  string
+		expectedError bool
+	}{
+		{"CurrentContext", ClientOptions{Kubeconfig: kubeconfig}, "https://first.example.com", false},
+		{"ExplicitContext", ClientOptions{Kubeconfig: kubeconfig, Context: "second"}, "https://second.example.com", false},
+		{"UnknownContext", ClientOptions{Kubeconfig: kubeconfig, Context: "missing"}, "", true},
+		{"MissingKubeconfig", ClientOptions{Kubeconfig: filepath.Join(t.TempDir(), "missing")}, "", true},
+		{"NoKubeconfig", ClientOptions{}, "", true},
+		{"InCluster", ClientOptions{InCluster: true}, "https://in-cluster.example.com", false},
+		{"InClusterWithKubeconfig", ClientOptions{InCluster: true, Kubeconfig: kubeconfig}, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clients, err := BuildClients(test.opts)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("Expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if clients.Config.Host != test.expectedHost {
+				t.Errorf("Expected host %s, got %s", test.expectedHost, clients.Config.Host)
+			}
+			if clients.Clientset == nil || clients.Dynamic == nil {
+				t.Error("Expected both the clientset and the dynamic client")
+			}
+		})
+	}
+}
+
+func TestBuildClientsInClusterError(t *testing.T) {
+	previous := inClusterConfig
+	t.Cleanup(func() { inClusterConfig = previous })
+	inClusterConfig = func() (*rest.Config, error) {
+		return nil, rest.ErrNotInCluster
+	}
+
+	if _, err := BuildClients(ClientOptions{InCluster: true}); !errors.Is(err, rest.ErrNotInCluster) {
+		t.Errorf("Expected the in-cluster error to be returned, got %v", err)
+	}
+}
+
+func TestBuildConfigRateLimits(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := BuildConfig(ClientOptions{Kubeconfig: kubeconfig, QPS: DefaultQPS, Burst: DefaultBurst})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.QPS != DefaultQPS || config.Burst != DefaultBurst {
+		t.Errorf("Expected QPS %v and burst %d, got %v and %d", DefaultQPS, DefaultBurst, config.QPS, config.Burst)
+	}
+
+	config, err = BuildConfig(ClientOptions{Kubeconfig: kubeconfig})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.QPS != 0 || config.Burst != 0 {
+		t.Errorf("Expected the client-go rate limits to be kept, got QPS %v and burst %d", config.QPS, config.Burst)
+	}
+}
+
+func TestAuthProviderPluginsRegistered(t *testing.T) {
+	for _, name := range []string{"oidc", "gcp", "azure"} {
+		_, err := rest.GetAuthProvider("https://example.com", &clientcmdapi.AuthProviderConfig{Name: name}, nil)
+		if err != nil && strings.Contains(err.Error(), "no Auth Provider found") {
+			t.Errorf("Expected the %s auth provider to be registered, got %v", name, err)
+		}
+	}
+}
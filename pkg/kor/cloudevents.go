@@ -0,0 +1,109 @@
+package kor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	cloudEventsSpecVersion  = "1.0"
+	finalizerStuckEventType = "io.kor.finalizer.stuck"
+)
+
+// CloudEvent is a CloudEvents 1.0 envelope in the structured json format
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            string      `json:"time,omitempty"`
+	DataContentType string      `json:"datacontenttype,omitempty"`
+	Data            interface{} `json:"data,omitempty"`
+}
+
+// FinalizerEventData is the payload of a finalizer stuck event
+type FinalizerEventData struct {
+	Namespace    string `json:"namespace"`
+	ResourceType string `json:"resourceType"`
+	ResourceInfo
+}
+
+// ValidateCloudEvent checks that the required context attributes are set as described by the CloudEvents 1.0 spec
+func ValidateCloudEvent(event CloudEvent) error {
+	if event.SpecVersion != cloudEventsSpecVersion {
+		return fmt.Errorf("unsupported specversion %q", event.SpecVersion)
+	}
+	if event.ID == "" {
+		return errors.New("id must be a non-empty string")
+	}
+	if event.Type == "" {
+		return errors.New("type must be a non-empty string")
+	}
+	if event.Source == "" {
+		return errors.New("source must be a non-empty URI-reference")
+	}
+	if _, err := url.Parse(event.Source); err != nil {
+		return fmt.Errorf("source must be a URI-reference: %w", err)
+	}
+	if event.Time != "" {
+		if _, err := time.Parse(time.RFC3339, event.Time); err != nil {
+			return fmt.Errorf("time must be an RFC 3339 timestamp: %w", err)
+		}
+	}
+	return nil
+}
+
+// cloudEventsSource returns the event source for the scanned cluster
+func cloudEventsSource(clusterName string) string {
+	if clusterName == "" {
+		return "kor"
+	}
+	return clusterName
+}
+
+// formatCloudEvents wraps every finding of the response in its own CloudEvent and
+// renders them as a json batch
+func formatCloudEvents(response map[string]map[string][]ResourceInfo, opts Opts) (string, error) {
+	scanTime := time.Now().UTC().Format(time.RFC3339)
+	events := []CloudEvent{}
+	for namespace, resourceMap := range response {
+		for resourceType, infos := range resourceMap {
+			for _, info := range infos {
+				event := CloudEvent{
+					SpecVersion:     cloudEventsSpecVersion,
+					ID:              string(uuid.NewUUID()),
+					Source:          cloudEventsSource(opts.ClusterName),
+					Type:            finalizerStuckEventType,
+					Subject:         fmt.Sprintf("%s/%s/%s", namespace, resourceType, info.Name),
+					Time:            scanTime,
+					DataContentType: "application/json",
+					Data: FinalizerEventData{
+						Namespace:    namespace,
+						ResourceType: resourceType,
+						ResourceInfo: info,
+					},
+				}
+				if err := ValidateCloudEvent(event); err != nil {
+					return "", fmt.Errorf("invalid cloud event for %s: %w", event.Subject, err)
+				}
+				events = append(events, event)
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Subject < events[j].Subject
+	})
+
+	output, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
@@ -0,0 +1,75 @@
+package kor
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFormatCloudEvents(t *testing.T) {
+	response := map[string]map[string][]ResourceInfo{
+		testNamespace: {
+			"pods":    {{Name: "stuck-pod", Reason: "Pending deletion waiting for finalizers"}},
+			"secrets": {{Name: "stuck-secret"}},
+		},
+	}
+
+	output, err := formatCloudEvents(response, Opts{ClusterName: "https://cluster.example.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var events []map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &events); err != nil {
+		t.Fatalf("Expected a json batch of events, got %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	event := events[0]
+	for attribute, expected := range map[string]string{
+		"specversion":     "1.0",
+		"type":            "io.kor.finalizer.stuck",
+		"source":          "https://cluster.example.com",
+		"subject":         testNamespace + "/pods/stuck-pod",
+		"datacontenttype": "application/json",
+	} {
+		if event[attribute] != expected {
+			t.Errorf("Expected %s %q, got %v", attribute, expected, event[attribute])
+		}
+	}
+	if event["id"] == events[1]["id"] {
+		t.Errorf("Expected unique event ids, got %v twice", event["id"])
+	}
+	data := event["data"].(map[string]interface{})
+	if data["name"] != "stuck-pod" || data["namespace"] != testNamespace || data["resourceType"] != "pods" {
+		t.Errorf("Unexpected event data: %v", data)
+	}
+}
+
+func TestValidateCloudEvent(t *testing.T) {
+	valid := CloudEvent{SpecVersion: "1.0", ID: "1", Source: "kor", Type: "io.kor.finalizer.stuck", Time: "2024-01-01T00:00:00Z"}
+
+	tests := []struct {
+		name          string
+		modify        func(event *CloudEvent)
+		expectedError bool
+	}{
+		{"Valid", func(event *CloudEvent) {}, false},
+		{"WrongSpecVersion", func(event *CloudEvent) { event.SpecVersion = "0.3" }, true},
+		{"MissingID", func(event *CloudEvent) { event.ID = "" }, true},
+		{"MissingSource", func(event *CloudEvent) { event.Source = "" }, true},
+		{"MissingType", func(event *CloudEvent) { event.Type = "" }, true},
+		{"InvalidTime", func(event *CloudEvent) { event.Time = "yesterday" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := valid
+			tt.modify(&event)
+			if err := ValidateCloudEvent(event); (err != nil) != tt.expectedError {
+				t.Errorf("Expected error: %v, got: %v", tt.expectedError, err)
+			}
+		})
+	}
+}
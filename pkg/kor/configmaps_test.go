@@ -156,6 +156,27 @@ func TestProcessNamespaceCM(t *testing.T) {
 	}
 }
 
+// Only finalizer scans honor the used annotation and case-insensitive values, see IsStuckFinalizer
+func TestProcessNamespaceCMUsedAnnotation(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	annotated := CreateTestConfigmap(testNamespace, "annotated", AppLabels)
+	annotated.Annotations = map[string]string{"kor/used": "true"}
+	uppercase := CreateTestConfigmap(testNamespace, "uppercase", map[string]string{"kor/used": "TRUE"})
+	for _, configmap := range []*corev1.ConfigMap{annotated, uppercase} {
+		if _, err := clientset.CoreV1().ConfigMaps(testNamespace).Create(context.TODO(), configmap, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Error creating fake configmap: %v", err)
+		}
+	}
+
+	diff, err := processNamespaceCM(clientset, testNamespace, &filters.Options{})
+	if err != nil {
+		t.Fatalf("Error processing namespace CM: %v", err)
+	}
+	if names := extractNames(diff); !equalSlices(names, []string{"annotated", "uppercase"}) {
+		t.Errorf("Expected the annotated and uppercase marked configmaps to be reported, got %v", diff)
+	}
+}
+
 func TestRetrieveUsedCM(t *testing.T) {
 	clientset := createTestConfigmaps(t)
 
@@ -0,0 +1,55 @@
+package kor
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// servedAPIGroups returns the names of the API groups serving the discovered resources
+func servedAPIGroups(resourceLists []*metav1.APIResourceList) map[string]bool {
+	groups := make(map[string]bool)
+	for _, resourceList := range resourceLists {
+		if gv, err := schema.ParseGroupVersion(resourceList.GroupVersion); err == nil {
+			groups[gv.Group] = true
+		}
+	}
+	return groups
+}
+
+// isBuiltinFinalizerDomain checks if the domain belongs to Kubernetes itself, whose finalizers are handled
+// by the control plane rather than by the controller of an API group, e.g. kubernetes.io/pvc-protection
+func isBuiltinFinalizerDomain(domain string) bool {
+	for _, builtin := range []string{"kubernetes.io", "k8s.io"} {
+		if domain == builtin || strings.HasSuffix(domain, "."+builtin) {
+			return true
+		}
+	}
+	return false
+}
+
+// danglingFinalizers returns the finalizers whose domain prefix names an API group that is not served,
+// usually left behind by an uninstalled operator. Finalizers without a domain, or whose domain is not
+// an API group like name, cannot be cross-referenced and are never reported.
+func danglingFinalizers(finalizers []string, servedGroups map[string]bool) []string {
+	var dangling []string
+	for _, finalizer := range finalizers {
+		domain, _, found := strings.Cut(finalizer, "/")
+		if !found || !strings.Contains(domain, ".") || isBuiltinFinalizerDomain(domain) {
+			continue
+		}
+		served := false
+		for group := range servedGroups {
+			// Controllers often use a subdomain or the parent domain of their API group
+			if group == domain || strings.HasSuffix(domain, "."+group) || strings.HasSuffix(group, "."+domain) {
+				served = true
+				break
+			}
+		}
+		if !served {
+			dangling = append(dangling, finalizer)
+		}
+	}
+	return dangling
+}
@@ -0,0 +1,28 @@
+package kor
+
+import (
+	"testing"
+
+	"k8s.io/utils/strings/slices"
+)
+
+func TestDanglingFinalizers(t *testing.T) {
+	servedGroups := map[string]bool{"": true, "apps": true, "cert-manager.io": true, "monitoring.coreos.com": true}
+	finalizers := []string{
+		"kubernetes.io/pvc-protection",
+		"batch.kubernetes.io/job-tracking",
+		"foregroundDeletion",
+		"external-attacher/csi",
+		"cert-manager.io/cleanup",
+		"acme.cert-manager.io/cleanup",
+		"coreos.com/cleanup",
+		"velero.io/backup",
+		"argocd.argoproj.io/finalizer",
+	}
+
+	dangling := danglingFinalizers(finalizers, servedGroups)
+	expected := []string{"velero.io/backup", "argocd.argoproj.io/finalizer"}
+	if !slices.Equal(dangling, expected) {
+		t.Errorf("Expected dangling finalizers %v, got %v", expected, dangling)
+	}
+}
@@ -1,10 +1,16 @@
 package kor
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"reflect"
+	"slices"
 	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -20,77 +26,81 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/yonahd/kor/pkg/filters"
 )
 
-func DeleteResourceCmd() map[string]func(clientset kubernetes.Interface, namespace, name string) error {
-	var deleteResourceApiMap = map[string]func(clientset kubernetes.Interface, namespace, name string) error{
-		"ConfigMap": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.CoreV1().ConfigMaps(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+func DeleteResourceCmd() map[string]func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+	var deleteResourceApiMap = map[string]func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error{
+		"ConfigMap": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.CoreV1().ConfigMaps(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"Secret": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.CoreV1().Secrets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"Secret": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.CoreV1().Secrets(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"Service": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.CoreV1().Services(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"Service": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.CoreV1().Services(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"Deployment": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.AppsV1().Deployments(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"Deployment": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.AppsV1().Deployments(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"HPA": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.AutoscalingV1().HorizontalPodAutoscalers(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"HPA": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.AutoscalingV1().HorizontalPodAutoscalers(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"Ingress": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.NetworkingV1().Ingresses(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"Ingress": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.NetworkingV1().Ingresses(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"PDB": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.PolicyV1beta1().PodDisruptionBudgets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"PDB": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.PolicyV1beta1().PodDisruptionBudgets(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"Role": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.RbacV1().Roles(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"Role": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.RbacV1().Roles(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"ClusterRole": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.RbacV1().ClusterRoles().Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"ClusterRole": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.RbacV1().ClusterRoles().Delete(ctx, name, deleteOpts)
 		},
-		"PVC": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"PVC": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"StatefulSet": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.AppsV1().StatefulSets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"StatefulSet": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.AppsV1().StatefulSets(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"ServiceAccount": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.CoreV1().ServiceAccounts(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"ServiceAccount": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.CoreV1().ServiceAccounts(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"PV": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.CoreV1().PersistentVolumes().Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"PV": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.CoreV1().PersistentVolumes().Delete(ctx, name, deleteOpts)
 		},
-		"Pod": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.CoreV1().Pods(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"Pod": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.CoreV1().Pods(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"Job": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.BatchV1().Jobs(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"Job": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.BatchV1().Jobs(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"ReplicaSet": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.AppsV1().ReplicaSets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"ReplicaSet": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.AppsV1().ReplicaSets(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"DaemonSet": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.AppsV1().DaemonSets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"DaemonSet": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.AppsV1().DaemonSets(namespace).Delete(ctx, name, deleteOpts)
 		},
-		"StorageClass": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.StorageV1().StorageClasses().Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"StorageClass": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.StorageV1().StorageClasses().Delete(ctx, name, deleteOpts)
 		},
-		"NetworkPolicy": func(clientset kubernetes.Interface, namespace, name string) error {
-			return clientset.NetworkingV1().NetworkPolicies(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		"NetworkPolicy": func(ctx context.Context, clientset kubernetes.Interface, namespace, name string, deleteOpts metav1.DeleteOptions) error {
+			return clientset.NetworkingV1().NetworkPolicies(namespace).Delete(ctx, name, deleteOpts)
 		},
 	}
 
 	return deleteResourceApiMap
 }
 
-func FlagDynamicResource(dynamicClient dynamic.Interface, namespace string, gvr schema.GroupVersionResource, resourceName string) error {
+// FlagDynamicResource marks the resource as used with the used label of filterOpts, set to its first used value,
+// so it is not reported again
+func FlagDynamicResource(ctx context.Context, dynamicClient dynamic.Interface, namespace string, gvr schema.GroupVersionResource, resourceName string, filterOpts *filters.Options) error {
 	resource, err := dynamicClient.
 		Resource(gvr).
 		Namespace(namespace).
-		Get(context.TODO(), resourceName, metav1.GetOptions{})
+		Get(ctx, resourceName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
@@ -99,17 +109,19 @@ func FlagDynamicResource(dynamicClient dynamic.Interface, namespace string, gvr
 	if labels == nil {
 		labels = make(map[string]string)
 	}
-	labels["kor/used"] = "true"
+	key, values := filterOpts.UsedLabel()
+	labels[key] = values[0]
 	resource.SetLabels(labels)
 	_, err = dynamicClient.
 		Resource(gvr).
 		Namespace(namespace).
-		Update(context.TODO(), resource, metav1.UpdateOptions{})
+		Update(ctx, resource, metav1.UpdateOptions{})
 	return err
 }
 
-func FlagResource(clientset kubernetes.Interface, namespace, resourceType, resourceName string) error {
-	resource, err := getResource(clientset, namespace, resourceType, resourceName)
+// FlagResource marks the resource as used like FlagDynamicResource
+func FlagResource(ctx context.Context, clientset kubernetes.Interface, namespace, resourceType, resourceName string, filterOpts *filters.Options) error {
+	resource, err := getResource(ctx, clientset, namespace, resourceType, resourceName)
 	if err != nil {
 		return err
 	}
@@ -120,130 +132,397 @@ func FlagResource(clientset kubernetes.Interface, namespace, resourceType, resou
 		if labels == nil {
 			labels = make(map[string]string)
 		}
-		labels["kor/used"] = "true"
+		key, values := filterOpts.UsedLabel()
+		labels[key] = values[0]
 		labelField.Set(reflect.ValueOf(labels))
 	} else {
 		return fmt.Errorf("unable to set labels for resource type: %s", resourceType)
 	}
 
-	_, err = updateResource(clientset, namespace, resourceType, resource)
+	_, err = updateResource(ctx, clientset, namespace, resourceType, resource)
 	return err
 }
 
-func updateResource(clientset kubernetes.Interface, namespace, resourceType string, resource interface{}) (interface{}, error) {
+func updateResource(ctx context.Context, clientset kubernetes.Interface, namespace, resourceType string, resource interface{}) (interface{}, error) {
 	switch resourceType {
 	case "ConfigMap":
-		return clientset.CoreV1().ConfigMaps(namespace).Update(context.TODO(), resource.(*corev1.ConfigMap), metav1.UpdateOptions{})
+		return clientset.CoreV1().ConfigMaps(namespace).Update(ctx, resource.(*corev1.ConfigMap), metav1.UpdateOptions{})
 	case "Secret":
-		return clientset.CoreV1().Secrets(namespace).Update(context.TODO(), resource.(*corev1.Secret), metav1.UpdateOptions{})
+		return clientset.CoreV1().Secrets(namespace).Update(ctx, resource.(*corev1.Secret), metav1.UpdateOptions{})
 	case "Service":
-		return clientset.CoreV1().Services(namespace).Update(context.TODO(), resource.(*corev1.Service), metav1.UpdateOptions{})
+		return clientset.CoreV1().Services(namespace).Update(ctx, resource.(*corev1.Service), metav1.UpdateOptions{})
 	case "Deployment":
-		return clientset.AppsV1().Deployments(namespace).Update(context.TODO(), resource.(*appsv1.Deployment), metav1.UpdateOptions{})
+		return clientset.AppsV1().Deployments(namespace).Update(ctx, resource.(*appsv1.Deployment), metav1.UpdateOptions{})
 	case "HPA":
-		return clientset.AutoscalingV1().HorizontalPodAutoscalers(namespace).Update(context.TODO(), resource.(*autoscalingv1.HorizontalPodAutoscaler), metav1.UpdateOptions{})
+		return clientset.AutoscalingV1().HorizontalPodAutoscalers(namespace).Update(ctx, resource.(*autoscalingv1.HorizontalPodAutoscaler), metav1.UpdateOptions{})
 	case "Ingress":
-		return clientset.NetworkingV1().Ingresses(namespace).Update(context.TODO(), resource.(*networkingv1.Ingress), metav1.UpdateOptions{})
+		return clientset.NetworkingV1().Ingresses(namespace).Update(ctx, resource.(*networkingv1.Ingress), metav1.UpdateOptions{})
 	case "PDB":
-		return clientset.PolicyV1beta1().PodDisruptionBudgets(namespace).Update(context.TODO(), resource.(*policyv1beta1.PodDisruptionBudget), metav1.UpdateOptions{})
+		return clientset.PolicyV1beta1().PodDisruptionBudgets(namespace).Update(ctx, resource.(*policyv1beta1.PodDisruptionBudget), metav1.UpdateOptions{})
 	case "Role":
-		return clientset.RbacV1().Roles(namespace).Update(context.TODO(), resource.(*rbacv1.Role), metav1.UpdateOptions{})
+		return clientset.RbacV1().Roles(namespace).Update(ctx, resource.(*rbacv1.Role), metav1.UpdateOptions{})
 	case "ClusterRole":
-		return clientset.RbacV1().ClusterRoles().Update(context.TODO(), resource.(*rbacv1.ClusterRole), metav1.UpdateOptions{})
+		return clientset.RbacV1().ClusterRoles().Update(ctx, resource.(*rbacv1.ClusterRole), metav1.UpdateOptions{})
 	case "PVC":
-		return clientset.CoreV1().PersistentVolumeClaims(namespace).Update(context.TODO(), resource.(*corev1.PersistentVolumeClaim), metav1.UpdateOptions{})
+		return clientset.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, resource.(*corev1.PersistentVolumeClaim), metav1.UpdateOptions{})
 	case "StatefulSet":
-		return clientset.AppsV1().StatefulSets(namespace).Update(context.TODO(), resource.(*appsv1.StatefulSet), metav1.UpdateOptions{})
+		return clientset.AppsV1().StatefulSets(namespace).Update(ctx, resource.(*appsv1.StatefulSet), metav1.UpdateOptions{})
 	case "ServiceAccount":
-		return clientset.CoreV1().ServiceAccounts(namespace).Update(context.TODO(), resource.(*corev1.ServiceAccount), metav1.UpdateOptions{})
+		return clientset.CoreV1().ServiceAccounts(namespace).Update(ctx, resource.(*corev1.ServiceAccount), metav1.UpdateOptions{})
 	case "PV":
-		return clientset.CoreV1().PersistentVolumes().Update(context.TODO(), resource.(*corev1.PersistentVolume), metav1.UpdateOptions{})
+		return clientset.CoreV1().PersistentVolumes().Update(ctx, resource.(*corev1.PersistentVolume), metav1.UpdateOptions{})
 	case "Pod":
-		return clientset.CoreV1().Pods(namespace).Update(context.TODO(), resource.(*corev1.Pod), metav1.UpdateOptions{})
+		return clientset.CoreV1().Pods(namespace).Update(ctx, resource.(*corev1.Pod), metav1.UpdateOptions{})
 	case "Job":
-		return clientset.BatchV1().Jobs(namespace).Update(context.TODO(), resource.(*batchv1.Job), metav1.UpdateOptions{})
+		return clientset.BatchV1().Jobs(namespace).Update(ctx, resource.(*batchv1.Job), metav1.UpdateOptions{})
 	case "ReplicaSet":
-		return clientset.AppsV1().ReplicaSets(namespace).Update(context.TODO(), resource.(*appsv1.ReplicaSet), metav1.UpdateOptions{})
+		return clientset.AppsV1().ReplicaSets(namespace).Update(ctx, resource.(*appsv1.ReplicaSet), metav1.UpdateOptions{})
 	case "DaemonSet":
-		return clientset.AppsV1().DaemonSets(namespace).Update(context.TODO(), resource.(*appsv1.DaemonSet), metav1.UpdateOptions{})
+		return clientset.AppsV1().DaemonSets(namespace).Update(ctx, resource.(*appsv1.DaemonSet), metav1.UpdateOptions{})
 	case "StorageClass":
-		return clientset.StorageV1().StorageClasses().Update(context.TODO(), resource.(*storagev1.StorageClass), metav1.UpdateOptions{})
+		return clientset.StorageV1().StorageClasses().Update(ctx, resource.(*storagev1.StorageClass), metav1.UpdateOptions{})
 	case "NetworkPolicy":
-		return clientset.NetworkingV1().NetworkPolicies(namespace).Update(context.TODO(), resource.(*networkingv1.NetworkPolicy), metav1.UpdateOptions{})
+		return clientset.NetworkingV1().NetworkPolicies(namespace).Update(ctx, resource.(*networkingv1.NetworkPolicy), metav1.UpdateOptions{})
 	}
 	return nil, fmt.Errorf("resource type '%s' is not supported", resourceType)
 }
 
-func getResource(clientset kubernetes.Interface, namespace, resourceType, resourceName string) (interface{}, error) {
+func getResource(ctx context.Context, clientset kubernetes.Interface, namespace, resourceType, resourceName string) (interface{}, error) {
 	switch resourceType {
 	case "ConfigMap":
-		return clientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.CoreV1().ConfigMaps(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "Secret":
-		return clientset.CoreV1().Secrets(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.CoreV1().Secrets(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "Service":
-		return clientset.CoreV1().Services(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.CoreV1().Services(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "Deployment":
-		return clientset.AppsV1().Deployments(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.AppsV1().Deployments(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "HPA":
-		return clientset.AutoscalingV1().HorizontalPodAutoscalers(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.AutoscalingV1().HorizontalPodAutoscalers(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "Ingress":
-		return clientset.NetworkingV1().Ingresses(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.NetworkingV1().Ingresses(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "PDB":
-		return clientset.PolicyV1beta1().PodDisruptionBudgets(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.PolicyV1beta1().PodDisruptionBudgets(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "Role":
-		return clientset.RbacV1().Roles(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.RbacV1().Roles(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "ClusterRole":
-		return clientset.RbacV1().ClusterRoles().Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.RbacV1().ClusterRoles().Get(ctx, resourceName, metav1.GetOptions{})
 	case "PVC":
-		return clientset.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "StatefulSet":
-		return clientset.AppsV1().StatefulSets(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.AppsV1().StatefulSets(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "ServiceAccount":
-		return clientset.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "PV":
-		return clientset.CoreV1().PersistentVolumes().Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.CoreV1().PersistentVolumes().Get(ctx, resourceName, metav1.GetOptions{})
 	case "Pod":
-		return clientset.CoreV1().Pods(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.CoreV1().Pods(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "Job":
-		return clientset.BatchV1().Jobs(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.BatchV1().Jobs(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "ReplicaSet":
-		return clientset.AppsV1().ReplicaSets(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.AppsV1().ReplicaSets(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "DaemonSet":
-		return clientset.AppsV1().DaemonSets(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.AppsV1().DaemonSets(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	case "StorageClass":
-		return clientset.StorageV1().StorageClasses().Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.StorageV1().StorageClasses().Get(ctx, resourceName, metav1.GetOptions{})
 	case "NetworkPolicy":
-		return clientset.NetworkingV1().NetworkPolicies(namespace).Get(context.TODO(), resourceName, metav1.GetOptions{})
+		return clientset.NetworkingV1().NetworkPolicies(namespace).Get(ctx, resourceName, metav1.GetOptions{})
 	}
 	return nil, fmt.Errorf("resource type '%s' is not supported", resourceType)
 }
 
-func DeleteResourceWithFinalizer(resources []ResourceInfo, dynamicClient dynamic.Interface, namespace string, gvr schema.GroupVersionResource, noInteractive bool) ([]ResourceInfo, error) {
-	var remainingResources []ResourceInfo
-	for _, resource := range resources {
-		if !noInteractive {
-			fmt.Printf("Do you want to delete %s %s in namespace %s? (Y/N): ", gvr.Resource, resource.Name, namespace)
-			var confirmation string
-			_, err := fmt.Scanf("%s", &confirmation)
-			if err != nil {
+// confirmationInput is where the answers to the interactive delete prompts are read from
+var confirmationInput = bufio.NewReader(os.Stdin)
+
+// askConfirmation prompts until the answer is y, yes, n or no, in any case, and re-prompts at most
+// retries times on any other answer. Running out of retries, read errors and EOF count as no, so
+// nothing is deleted by accident.
+func askConfirmation(prompt string, retries int) bool {
+	for attempt := 0; attempt <= retries; attempt++ {
+		fmt.Print(prompt)
+		answer, err := confirmationInput.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
 				fmt.Fprintf(os.Stderr, "Failed to read input: %v\n", err)
-				continue
 			}
+			fmt.Println()
+			return false
+		}
+		if attempt < retries {
+			fmt.Println("Please answer y(es) or n(o)")
+		}
+	}
+	fmt.Println("No valid answer given, assuming no")
+	return false
+}
+
+// dryRunSuffix marks the output of requests sent as server-side dry runs
+const dryRunSuffix = " (dry-run)"
+
+// dryRun returns the dry run option of mutating requests. With DryRun the API server runs admission
+// and authorization for the requests, without persisting them.
+func dryRun(opts Opts) []string {
+	if opts.DryRun {
+		return []string{metav1.DryRunAll}
+	}
+	return nil
+}
+
+// dryRunMarker returns what is added to the output of mutating requests, to tell dry runs apart
+func dryRunMarker(opts Opts) string {
+	if opts.DryRun {
+		return dryRunSuffix
+	}
+	return ""
+}
+
+// maxBatchConfirmationSample bounds how many resources are listed before confirming a batch deletion
+const maxBatchConfirmationSample = 10
+
+// deletionCandidate is a resource a batch deletion is about to delete
+type deletionCandidate struct {
+	namespace string
+	resource  string
+	name      string
+}
+
+// formatBatchConfirmation summarizes the resources about to be deleted, with their count per resource
+// type and a sample of them, so the whole batch can be confirmed at once
+func formatBatchConfirmation(action string, candidates []deletionCandidate) string {
+	counts := make(map[string]int)
+	for _, candidate := range candidates {
+		counts[candidate.resource]++
+	}
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("About to %s %d resources:\n", action, len(candidates)))
+	for _, resource := range sortedKeys(counts) {
+		summary.WriteString(fmt.Sprintf("  %s: %d\n", resource, counts[resource]))
+	}
+	summary.WriteString("Including:\n")
+	for i, candidate := range candidates {
+		if i == maxBatchConfirmationSample {
+			summary.WriteString(fmt.Sprintf("  and %d more\n", len(candidates)-i))
+			break
+		}
+		summary.WriteString(fmt.Sprintf("  %s/%s/%s\n", candidate.namespace, candidate.resource, candidate.name))
+	}
+	return summary.String()
+}
+
+// confirmBatchDeletion prompts once for every resource about to be deleted. Declining deletes none of them.
+func confirmBatchDeletion(action string, candidates []deletionCandidate, opts Opts) bool {
+	fmt.Print(formatBatchConfirmation(action, candidates))
+	return askConfirmation(fmt.Sprintf("Do you want to %s all %d resources? (Y/N): ", action, len(candidates)), opts.ConfirmationRetries)
+}
+
+// declinedResources marks the resources as not deleted, after their batch deletion was declined
+func declinedResources(resources []ResourceInfo, opts Opts) []ResourceInfo {
+	reason := "not deleted - user declined"
+	if opts.RemoveFinalizers {
+		reason = "finalizers not removed - user declined"
+	}
+	declined := make([]ResourceInfo, 0, len(resources))
+	for _, resource := range resources {
+		resource.Reason = reason
+		declined = append(declined, resource)
+	}
+	return declined
+}
+
+// propagationPolicies lists the accepted deletion propagation policies by their lowercase name
+var propagationPolicies = map[string]metav1.DeletionPropagation{
+	"background": metav1.DeletePropagationBackground,
+	"foreground": metav1.DeletePropagationForeground,
+	"orphan":     metav1.DeletePropagationOrphan,
+}
+
+// needsConfirmation checks if deleting a resource of the given type has to be confirmed interactively.
+// Resource types configured to skip the confirmation are matched case-insensitively.
+func needsConfirmation(resourceType string, opts Opts) bool {
+	if opts.NoInteractive {
+		return false
+	}
+	for _, skipped := range opts.NoConfirmResourceTypes {
+		if strings.EqualFold(skipped, resourceType) {
+			return false
+		}
+	}
+	return true
+}
+
+// RequiredConfirmationToken is the ConfirmationToken deleting resources pending deletion or removing their
+// finalizers without prompting requires
+const RequiredConfirmationToken = "I-UNDERSTAND"
+
+// validateConfirmationToken refuses the deletions and finalizer removals of a finalizer scan that are not
+// prompted for, unless they are confirmed with the RequiredConfirmationToken. Dry runs change nothing and
+// need no confirmation.
+func validateConfirmationToken(opts Opts) error {
+	if !(opts.DeleteFlag || opts.RemoveFinalizers) || !opts.NoInteractive || opts.DryRun || opts.CheckFinalizerFormat {
+		return nil
+	}
+	if opts.ConfirmationToken != RequiredConfirmationToken {
+		return fmt.Errorf("deleting without prompting is irreversible, confirm it with --confirmation-token %s", RequiredConfirmationToken)
+	}
+	return nil
+}
+
+// propagationPolicyFor returns the deletion propagation policy configured for the resource type.
+// Resource types are matched case-insensitively and default to Background.
+func propagationPolicyFor(resourceType string, policies map[string]string) (metav1.DeletionPropagation, error) {
+	for configuredType, policy := range policies {
+		if !strings.EqualFold(configuredType, resourceType) {
+			continue
+		}
+		propagationPolicy, ok := propagationPolicies[strings.ToLower(policy)]
+		if !ok {
+			return "", fmt.Errorf("invalid propagation policy %q for %s, expected Background, Foreground or Orphan", policy, resourceType)
+		}
+		return propagationPolicy, nil
+	}
+	return metav1.DeletePropagationBackground, nil
+}
+
+// DefaultProtectedFinalizers are the finalizers of Kubernetes itself, e.g. kubernetes.io/pv-protection, which
+// guard against data loss and are never removed unless ProtectedFinalizers is set otherwise
+var DefaultProtectedFinalizers = []string{"kubernetes.io/*", "*.kubernetes.io/*"}
+
+// protectedSkippedReason prefixes the reason of resources skipped because only protected finalizers block them
+const protectedSkippedReason = "protected, skipped"
+
+// splitProtectedFinalizers splits the finalizers of a resource into the ones matching the ProtectedFinalizers
+// patterns, DefaultProtectedFinalizers when nil, and the others
+func splitProtectedFinalizers(finalizers []string, opts Opts) (protected, unprotected []string) {
+	patterns := opts.ProtectedFinalizers
+	if patterns == nil {
+		patterns = DefaultProtectedFinalizers
+	}
+	for _, finalizer := range finalizers {
+		if slices.ContainsFunc(patterns, func(pattern string) bool {
+			matched, _ := path.Match(pattern, finalizer)
+			return matched
+		}) {
+			protected = append(protected, finalizer)
+		} else {
+			unprotected = append(unprotected, finalizer)
+		}
+	}
+	return protected, unprotected
+}
+
+// onlyProtectedFinalizers checks if the finalizers blocking a resource are all protected. The garbage collector
+// finalizers are left out, removing them would not complete the deletion while the protected ones remain.
+func onlyProtectedFinalizers(protected, unprotected []string) bool {
+	if len(protected) == 0 {
+		return false
+	}
+	for _, finalizer := range unprotected {
+		if finalizer != metav1.FinalizerOrphanDependents && finalizer != metav1.FinalizerDeleteDependents {
+			return false
+		}
+	}
+	return true
+}
+
+// deleteFailedReason and removeFailedReason prefix the reason of resources left untouched because they could
+// not be fetched, backed up or patched, they are still reported
+const (
+	deleteFailedReason = "not deleted"
+	removeFailedReason = "finalizers not removed"
+)
+
+// failedReason returns the reason of a resource left untouched because step failed with err
+func failedReason(prefix, step string, err error) string {
+	return fmt.Sprintf("%s: %s failed: %v", prefix, step, err)
+}
 
-			if strings.ToLower(confirmation) != "y" && strings.ToLower(confirmation) != "yes" {
+// isFailedReason checks if the reason reports a resource left untouched by a failure rather than declined
+func isFailedReason(reason string) bool {
+	return strings.HasPrefix(reason, deleteFailedReason+":") || strings.HasPrefix(reason, removeFailedReason+":")
+}
+
+// finalizersPatch builds the merge patch setting the finalizers of a resource
+func finalizersPatch(finalizers []string) []byte {
+	if len(finalizers) == 0 {
+		return []byte(`{"metadata":{"finalizers":null}}`)
+	}
+	patch, _ := json.Marshal(map[string]any{"metadata": map[string]any{"finalizers": finalizers}})
+	return patch
+}
+
+// remainingFinalizers returns the finalizers kept when deleting a resource pending deletion. The
+// protected finalizers are kept, and so is the garbage collector finalizer implementing the
+// propagation policy, so dependents are still handled the way the policy describes.
+func remainingFinalizers(finalizers, protected []string, propagationPolicy metav1.DeletionPropagation) []string {
+	var keep string
+	switch propagationPolicy {
+	case metav1.DeletePropagationForeground:
+		keep = metav1.FinalizerDeleteDependents
+	case metav1.DeletePropagationOrphan:
+		keep = metav1.FinalizerOrphanDependents
+	}
+	remaining := slices.Clone(protected)
+	if keep != "" && slices.Contains(finalizers, keep) {
+		remaining = append(remaining, keep)
+	}
+	return remaining
+}
+
+// removedFinalizers returns the finalizers of a resource that are not kept
+func removedFinalizers(finalizers, kept []string) []string {
+	var removed []string
+	for _, finalizer := range finalizers {
+		if !slices.Contains(kept, finalizer) {
+			removed = append(removed, finalizer)
+		}
+	}
+	return removed
+}
+
+func DeleteResourceWithFinalizer(ctx context.Context, resources []ResourceInfo, dynamicClient dynamic.Interface, namespace string, gvr schema.GroupVersionResource, filterOpts *filters.Options, opts Opts) ([]ResourceInfo, error) {
+	propagationPolicy, err := propagationPolicyFor(gvr.Resource, opts.PropagationPolicies)
+	if err != nil {
+		return resources, err
+	}
+
+	throttle := withDeleteThrottle(opts).throttle
+	var remainingResources []ResourceInfo
+	for i, resource := range resources {
+		// Interrupting the run leaves the remaining resources untouched
+		if err := ctx.Err(); err != nil {
+			return append(remainingResources, resources[i:]...), err
+		}
+		// The current finalizers are checked before prompting, resources only blocked by protected finalizers are skipped
+		object, err := dynamicClient.
+			Resource(gvr).
+			Namespace(namespace).
+			Get(ctx, resource.Name, metav1.GetOptions{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get %s %s in namespace %s: %v\n", gvr.Resource, resource.Name, namespace, err)
+			resource.Reason = failedReason(deleteFailedReason, "get", err)
+			remainingResources = append(remainingResources, resource)
+			continue
+		}
+		protected, unprotected := splitProtectedFinalizers(object.GetFinalizers(), opts)
+		if onlyProtectedFinalizers(protected, unprotected) {
+			resource.Reason = fmt.Sprintf("%s: %s", protectedSkippedReason, strings.Join(protected, ", "))
+			remainingResources = append(remainingResources, resource)
+			continue
+		}
+
+		if needsConfirmation(gvr.Resource, opts) {
+			if !askConfirmation(fmt.Sprintf("Do you want to delete %s %s in namespace %s? (Y/N): ", gvr.Resource, resource.Name, namespace), opts.ConfirmationRetries) {
 				resource.Reason = "not deleted - user declined"
 				remainingResources = append(remainingResources, resource)
 
-				fmt.Printf("Do you want to flag the resource %s %s in namespace %s as In Use? (Y/N): ", gvr.Resource, resource.Name, namespace)
-				var inUse string
-				_, err = fmt.Scanf("%s", &inUse)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Failed to read input: %v\n", err)
-					continue
-				}
-
-				if strings.ToLower(inUse) == "y" || strings.ToLower(inUse) == "yes" {
-					if err := FlagDynamicResource(dynamicClient, namespace, gvr, resource.Name); err != nil {
+				if askConfirmation(fmt.Sprintf("Do you want to flag the resource %s %s in namespace %s as In Use? (Y/N): ", gvr.Resource, resource.Name, namespace), opts.ConfirmationRetries) {
+					if err := FlagDynamicResource(ctx, dynamicClient, namespace, gvr, resource.Name, filterOpts); err != nil {
 						fmt.Fprintf(os.Stderr, "Failed to flag resource %s %s in namespace %s as In Use: %v\n", gvr.Resource, resource.Name, namespace, err)
 					} else {
 						resource.Reason = "flagged as in use"
@@ -253,55 +532,132 @@ func DeleteResourceWithFinalizer(resources []ResourceInfo, dynamicClient dynamic
 			}
 		}
 
-		fmt.Printf("Deleting %s %s in namespace %s\n", gvr.Resource, resource.Name, namespace)
-		if _, err := dynamicClient.
+		remaining := remainingFinalizers(object.GetFinalizers(), protected, propagationPolicy)
+		// Resources that could not be backed up are left untouched and reported with the failure
+		if err := backupResource(object, gvr, backupActionDelete, removedFinalizers(object.GetFinalizers(), remaining), opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to back up %s %s in namespace %s, not deleting it: %v\n", gvr.Resource, resource.Name, namespace, err)
+			resource.Reason = failedReason(deleteFailedReason, "backup", err)
+			remainingResources = append(remainingResources, resource)
+			continue
+		}
+		patch := finalizersPatch(remaining)
+		fmt.Printf("Deleting %s %s in namespace %s%s\n", gvr.Resource, resource.Name, namespace, dryRunMarker(opts))
+		if err := throttle.do(func() error {
+			_, err := dynamicClient.
+				Resource(gvr).
+				Namespace(namespace).
+				Patch(ctx, resource.Name, types.MergePatchType,
+					patch,
+					metav1.PatchOptions{DryRun: dryRun(opts)})
+			return err
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to delete %s %s in namespace %s: %v\n", gvr.Resource, resource.Name, namespace, err)
+			resource.Reason = failedReason(deleteFailedReason, "patch", err)
+			remainingResources = append(remainingResources, resource)
+			continue
+		}
+		resource.Name = resource.Name + "-DELETED" + dryRunMarker(opts)
+		remainingResources = append(remainingResources, resource)
+	}
+
+	return remainingResources, nil
+}
+
+// finalizersRemovedReason prefixes the reason of resources whose finalizers were removed
+const finalizersRemovedReason = "finalizers removed"
+
+// RemoveFinalizers strips the finalizers of the resources pending deletion, so the API server completes
+// the deletion already requested for them. Unlike deleting them, the garbage collector finalizers are
+// removed as well. Protected finalizers are kept, and resources only blocked by them are skipped. The
+// reason of each resource reports the finalizers that were removed.
+func RemoveFinalizers(ctx context.Context, resources []ResourceInfo, dynamicClient dynamic.Interface, namespace string, gvr schema.GroupVersionResource, opts Opts) ([]ResourceInfo, error) {
+	throttle := withDeleteThrottle(opts).throttle
+	var remainingResources []ResourceInfo
+	for i, resource := range resources {
+		if err := ctx.Err(); err != nil {
+			return append(remainingResources, resources[i:]...), err
+		}
+		object, err := dynamicClient.
 			Resource(gvr).
 			Namespace(namespace).
-			Patch(context.TODO(), resource.Name, types.MergePatchType,
-				[]byte(`{"metadata":{"finalizers":null}}`),
-				metav1.PatchOptions{}); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to delete %s %s in namespace %s: %v\n", gvr.Resource, resource.Name, namespace, err)
+			Get(ctx, resource.Name, metav1.GetOptions{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get %s %s in namespace %s: %v\n", gvr.Resource, resource.Name, namespace, err)
+			resource.Reason = failedReason(removeFailedReason, "get", err)
+			remainingResources = append(remainingResources, resource)
+			continue
+		}
+		protected, unprotected := splitProtectedFinalizers(object.GetFinalizers(), opts)
+		if onlyProtectedFinalizers(protected, unprotected) {
+			resource.Reason = fmt.Sprintf("%s: %s", protectedSkippedReason, strings.Join(protected, ", "))
+			remainingResources = append(remainingResources, resource)
 			continue
 		}
-		resource.Name = resource.Name + "-DELETED"
+
+		if needsConfirmation(gvr.Resource, opts) {
+			if !askConfirmation(fmt.Sprintf("Do you want to remove the finalizers of %s %s in namespace %s? (Y/N): ", gvr.Resource, resource.Name, namespace), opts.ConfirmationRetries) {
+				resource.Reason = "finalizers not removed - user declined"
+				remainingResources = append(remainingResources, resource)
+				continue
+			}
+		}
+
+		if err := backupResource(object, gvr, backupActionRemoveFinalizers, unprotected, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to back up %s %s in namespace %s, not removing its finalizers: %v\n", gvr.Resource, resource.Name, namespace, err)
+			resource.Reason = failedReason(removeFailedReason, "backup", err)
+			remainingResources = append(remainingResources, resource)
+			continue
+		}
+		fmt.Printf("Removing finalizers of %s %s in namespace %s%s\n", gvr.Resource, resource.Name, namespace, dryRunMarker(opts))
+		if err := throttle.do(func() error {
+			_, err := dynamicClient.
+				Resource(gvr).
+				Namespace(namespace).
+				Patch(ctx, resource.Name, types.MergePatchType,
+					finalizersPatch(protected),
+					metav1.PatchOptions{DryRun: dryRun(opts)})
+			return err
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to remove finalizers of %s %s in namespace %s: %v\n", gvr.Resource, resource.Name, namespace, err)
+			resource.Reason = failedReason(removeFailedReason, "patch", err)
+			remainingResources = append(remainingResources, resource)
+			continue
+		}
+		resource.Reason = fmt.Sprintf("%s%s: %s", finalizersRemovedReason, dryRunMarker(opts), strings.Join(unprotected, ", "))
+		if len(protected) > 0 {
+			resource.Reason += fmt.Sprintf(", kept protected: %s", strings.Join(protected, ", "))
+		}
+		resource.Finalizers = protected
 		remainingResources = append(remainingResources, resource)
 	}
 
 	return remainingResources, nil
 }
 
-func DeleteResource(diff []ResourceInfo, clientset kubernetes.Interface, namespace, resourceType string, noInteractive bool) ([]ResourceInfo, error) {
+func DeleteResource(ctx context.Context, diff []ResourceInfo, clientset kubernetes.Interface, namespace, resourceType string, filterOpts *filters.Options, opts Opts) ([]ResourceInfo, error) {
 	deletedDiff := []ResourceInfo{}
+	propagationPolicy, err := propagationPolicyFor(resourceType, opts.PropagationPolicies)
+	if err != nil {
+		return diff, err
+	}
 
-	for _, resource := range diff {
+	throttle := withDeleteThrottle(opts).throttle
+	for i, resource := range diff {
+		if err := ctx.Err(); err != nil {
+			return append(deletedDiff, diff[i:]...), err
+		}
 		deleteFunc, exists := DeleteResourceCmd()[resourceType]
 		if !exists {
 			fmt.Printf("Resource type '%s' is not supported\n", resource.Name)
 			continue
 		}
 
-		if !noInteractive {
-			fmt.Printf("Do you want to delete %s %s in namespace %s? (Y/N): ", resourceType, resource.Name, namespace)
-			var confirmation string
-			_, err := fmt.Scanf("%s\n", &confirmation)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to read input: %v\n", err)
-				continue
-			}
-
-			if strings.ToLower(confirmation) != "y" && strings.ToLower(confirmation) != "yes" {
+		if needsConfirmation(resourceType, opts) {
+			if !askConfirmation(fmt.Sprintf("Do you want to delete %s %s in namespace %s? (Y/N): ", resourceType, resource.Name, namespace), opts.ConfirmationRetries) {
 				deletedDiff = append(deletedDiff, resource)
 
-				fmt.Printf("Do you want flag the resource %s %s in namespace %s as In Use? (Y/N): ", resourceType, resource.Name, namespace)
-				var inUse string
-				_, err := fmt.Scanf("%s\n", &inUse)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Failed to read input: %v\n", err)
-					continue
-				}
-
-				if strings.ToLower(inUse) == "y" || strings.ToLower(inUse) == "yes" {
-					if err := FlagResource(clientset, namespace, resourceType, resource.Name); err != nil {
+				if askConfirmation(fmt.Sprintf("Do you want flag the resource %s %s in namespace %s as In Use? (Y/N): ", resourceType, resource.Name, namespace), opts.ConfirmationRetries) {
+					if err := FlagResource(ctx, clientset, namespace, resourceType, resource.Name, filterOpts); err != nil {
 						fmt.Fprintf(os.Stderr, "Failed to flag resource %s %s in namespace %s as In Use: %v\n", resourceType, resource.Name, namespace, err)
 					}
 					continue
@@ -310,13 +666,15 @@ func DeleteResource(diff []ResourceInfo, clientset kubernetes.Interface, namespa
 			}
 		}
 
-		fmt.Printf("Deleting %s %s in namespace %s\n", resourceType, resource.Name, namespace)
-		if err := deleteFunc(clientset, namespace, resource.Name); err != nil {
+		fmt.Printf("Deleting %s %s in namespace %s%s\n", resourceType, resource.Name, namespace, dryRunMarker(opts))
+		if err := throttle.do(func() error {
+			return deleteFunc(ctx, clientset, namespace, resource.Name, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy, GracePeriodSeconds: opts.GracePeriodSeconds, DryRun: dryRun(opts)})
+		}); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to delete %s %s in namespace %s: %v\n", resourceType, resource.Name, namespace, err)
 			continue
 		}
 		deletedResource := resource
-		deletedResource.Name += "-DELETED"
+		deletedResource.Name += "-DELETED" + dryRunMarker(opts)
 		deletedDiff = append(deletedDiff, deletedResource)
 	}
 
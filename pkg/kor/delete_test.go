@@ -1,15 +1,25 @@
 package kor
 
 import (
+	"bufio"
 	"context"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	fakedynamic "k8s.io/client-go/dynamic/fake"
 	fake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/yonahd/kor/pkg/filters"
 )
 
 func TestDeleteResource(t *testing.T) {
@@ -50,9 +60,9 @@ func TestDeleteResource(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			deletedDiff, _ := DeleteResource(test.diff, clientset, testNamespace, test.resourceType, true)
+			deletedDiff, _ := DeleteResource(context.TODO(), test.diff, clientset, testNamespace, test.resourceType, &filters.Options{}, Opts{NoInteractive: true})
 			for i, deleted := range deletedDiff {
-				if deleted != test.expectedDiff[i] {
+				if !reflect.DeepEqual(deleted, test.expectedDiff[i]) {
 					t.Errorf("Expected: %s, Got: %s", test.expectedDiff[i], deleted)
 				}
 			}
@@ -102,7 +112,7 @@ func TestDeleteDeleteResourceWithFinalizer(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			deletedDiff, _ := DeleteResourceWithFinalizer(test.diff, dynamicClient, testNamespace, gvr, true)
+			deletedDiff, _ := DeleteResourceWithFinalizer(context.TODO(), test.diff, dynamicClient, testNamespace, gvr, &filters.Options{}, Opts{NoInteractive: true})
 
 			for i, deleted := range deletedDiff {
 				if deleted.Name != test.expectedDiff[i] {
@@ -169,7 +179,7 @@ func TestFlagDynamicResource(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := FlagDynamicResource(dynamicClient, testNamespace, gvr, test.resourceName)
+			err := FlagDynamicResource(context.TODO(), dynamicClient, testNamespace, gvr, test.resourceName, &filters.Options{})
 
 			if (err != nil) != test.expectedError {
 				t.Errorf("Expected error: %v, Got: %v", test.expectedError, err)
@@ -189,3 +199,484 @@ func TestFlagDynamicResource(t *testing.T) {
 		})
 	}
 }
+
+func TestFlagWithCustomUsedLabel(t *testing.T) {
+	filterOpts := &filters.Options{UsedLabelKey: "ops.example.com/retain", UsedLabelValues: []string{"retain", "yes"}}
+
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	stuck := CreateTestUnstructered("TestResource", gvr.GroupVersion().String(), testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now().Add(-time.Hour)})
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck)
+	if stuck, _ := IsStuckFinalizer(stuck, filterOpts); !stuck {
+		t.Fatalf("Expected the resource to be reported before it is flagged")
+	}
+
+	if err := FlagDynamicResource(context.TODO(), dynamicClient, testNamespace, gvr, "stuck", filterOpts); err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+	flagged, err := dynamicClient.Resource(gvr).Namespace(testNamespace).Get(context.TODO(), "stuck", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value := flagged.GetLabels()["ops.example.com/retain"]; value != "retain" {
+		t.Errorf("Expected resource flagged with the first used value, Got: %q", value)
+	}
+	if _, found := flagged.GetLabels()["kor/used"]; found {
+		t.Errorf("Expected the default used label not to be set, Got: %v", flagged.GetLabels())
+	}
+	if stuck, _ := IsStuckFinalizer(flagged, filterOpts); stuck {
+		t.Errorf("Expected the flagged resource not to be reported again")
+	}
+
+	clientset := fake.NewSimpleClientset(CreateTestConfigmap(testNamespace, "unused-cm", AppLabels))
+	if err := FlagResource(context.TODO(), clientset, testNamespace, "ConfigMap", "unused-cm", filterOpts); err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+	unused, err := processNamespaceCM(clientset, testNamespace, filterOpts)
+	if err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+	if len(unused) != 0 {
+		t.Errorf("Expected the flagged configmap not to be reported again, Got: %v", unused)
+	}
+}
+
+func TestPropagationPolicyFor(t *testing.T) {
+	policies := map[string]string{"Deployment": "Foreground", "jobs": "orphan", "Secret": "Never"}
+
+	tests := []struct {
+		name           string
+		resourceType   string
+		expectedPolicy metav1.DeletionPropagation
+		expectedError  bool
+	}{
+		{"ConfiguredType", "Deployment", metav1.DeletePropagationForeground, false},
+		{"CaseInsensitive", "Jobs", metav1.DeletePropagationOrphan, false},
+		{"DefaultBackground", "ConfigMap", metav1.DeletePropagationBackground, false},
+		{"InvalidPolicy", "Secret", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := propagationPolicyFor(test.resourceType, policies)
+			if (err != nil) != test.expectedError {
+				t.Errorf("Expected error: %v, Got: %v", test.expectedError, err)
+			}
+			if policy != test.expectedPolicy {
+				t.Errorf("Expected policy %q, Got: %q", test.expectedPolicy, policy)
+			}
+		})
+	}
+}
+
+func TestDeleteResourcePropagationPolicy(t *testing.T) {
+	clientset := fake.NewSimpleClientset(CreateTestDeployment(testNamespace, "test-deployment", 0, AppLabels))
+
+	opts := Opts{NoInteractive: true, PropagationPolicies: map[string]string{"Deployment": "Foreground"}}
+	if _, err := DeleteResource(context.TODO(), []ResourceInfo{{Name: "test-deployment"}}, clientset, testNamespace, "Deployment", &filters.Options{}, opts); err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+
+	for _, action := range clientset.Actions() {
+		if deleteAction, ok := action.(k8stesting.DeleteAction); ok {
+			policy := deleteAction.GetDeleteOptions().PropagationPolicy
+			if policy == nil || *policy != metav1.DeletePropagationForeground {
+				t.Errorf("Expected Foreground propagation policy, Got: %v", policy)
+			}
+			return
+		}
+	}
+	t.Error("Expected a delete action")
+}
+
+func TestRemainingFinalizersPatch(t *testing.T) {
+	tests := []struct {
+		name              string
+		finalizers        []string
+		propagationPolicy metav1.DeletionPropagation
+		expectedPatch     string
+	}{
+		{"Background", []string{"example.com/cleanup", metav1.FinalizerDeleteDependents}, metav1.DeletePropagationBackground, `{"metadata":{"finalizers":null}}`},
+		{"ForegroundKeepsFinalizer", []string{"example.com/cleanup", metav1.FinalizerDeleteDependents}, metav1.DeletePropagationForeground, `{"metadata":{"finalizers":["foregroundDeletion"]}}`},
+		{"OrphanWithoutFinalizer", []string{"example.com/cleanup"}, metav1.DeletePropagationOrphan, `{"metadata":{"finalizers":null}}`},
+		{"KeepsProtected", []string{"example.com/cleanup", "kubernetes.io/pv-protection", metav1.FinalizerDeleteDependents}, metav1.DeletePropagationForeground, `{"metadata":{"finalizers":["kubernetes.io/pv-protection","foregroundDeletion"]}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			protected, _ := splitProtectedFinalizers(test.finalizers, Opts{})
+			if patch := string(finalizersPatch(remainingFinalizers(test.finalizers, protected, test.propagationPolicy))); patch != test.expectedPatch {
+				t.Errorf("Expected patch %s, Got: %s", test.expectedPatch, patch)
+			}
+		})
+	}
+}
+
+func setConfirmationInput(t *testing.T, input string) {
+	previous := confirmationInput
+	confirmationInput = bufio.NewReader(strings.NewReader(input))
+	t.Cleanup(func() { confirmationInput = previous })
+}
+
+func TestAskConfirmation(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		retries  int
+		expected bool
+	}{
+		{"Yes", "y\n", 0, true},
+		{"YesUpperCase", "YES\n", 0, true},
+		{"No", "n\n", 0, false},
+		{"NoMixedCase", "No\n", 0, false},
+		{"Whitespace", "  yes  \n", 0, true},
+		{"RetryAfterTypo", "yse\ny\n", 1, true},
+		{"RetriesExhausted", "yse\nsure\ny\n", 1, false},
+		{"EmptyAnswer", "\n", 0, false},
+		{"EOF", "", 3, false},
+		{"EOFAfterTypo", "yse\n", 3, false},
+		{"AnswerWithoutNewline", "y", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setConfirmationInput(t, test.input)
+			if confirmed := askConfirmation("Delete? (Y/N): ", test.retries); confirmed != test.expected {
+				t.Errorf("Expected confirmation %v for input %q, Got: %v", test.expected, test.input, confirmed)
+			}
+		})
+	}
+}
+
+func TestDeleteResourceInteractive(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		CreateTestConfigmap(testNamespace, "configmap-1", AppLabels),
+		CreateTestConfigmap(testNamespace, "configmap-2", AppLabels),
+	)
+	// Confirm the first deletion after a typo, the second prompt hits EOF and must not delete
+	setConfirmationInput(t, "yse\nYes\n")
+
+	diff := []ResourceInfo{{Name: "configmap-1"}, {Name: "configmap-2"}}
+	deletedDiff, err := DeleteResource(context.TODO(), diff, clientset, testNamespace, "ConfigMap", &filters.Options{}, Opts{ConfirmationRetries: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+
+	expected := []string{"configmap-1-DELETED", "configmap-2"}
+	if len(deletedDiff) != len(expected) {
+		t.Fatalf("Expected %d resources, Got: %v", len(expected), deletedDiff)
+	}
+	for i, deleted := range deletedDiff {
+		if deleted.Name != expected[i] {
+			t.Errorf("Expected: %s, Got: %s", expected[i], deleted.Name)
+		}
+	}
+	if _, err := clientset.CoreV1().ConfigMaps(testNamespace).Get(context.TODO(), "configmap-2", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected configmap-2 to be kept, Got: %v", err)
+	}
+}
+
+func TestNeedsConfirmation(t *testing.T) {
+	opts := Opts{NoConfirmResourceTypes: []string{"ConfigMap", "jobs"}}
+	for resourceType, expected := range map[string]bool{
+		"ConfigMap": false,
+		"configmap": false,
+		"Jobs":      false,
+		"Secret":    true,
+	} {
+		if got := needsConfirmation(resourceType, opts); got != expected {
+			t.Errorf("Expected confirmation for %s to be %v, got %v", resourceType, expected, got)
+		}
+	}
+	if needsConfirmation("Secret", Opts{NoInteractive: true}) {
+		t.Error("Expected no confirmation when not interactive")
+	}
+}
+
+func TestValidateConfirmationToken(t *testing.T) {
+	for name, test := range map[string]struct {
+		opts  Opts
+		valid bool
+	}{
+		"report only":              {opts: Opts{NoInteractive: true}, valid: true},
+		"interactive delete":       {opts: Opts{DeleteFlag: true}, valid: true},
+		"unconfirmed delete":       {opts: Opts{DeleteFlag: true, NoInteractive: true}},
+		"unconfirmed removal":      {opts: Opts{RemoveFinalizers: true, NoInteractive: true}},
+		"mistyped token":           {opts: Opts{RemoveFinalizers: true, NoInteractive: true, ConfirmationToken: "i-understand"}},
+		"confirmed delete":         {opts: Opts{DeleteFlag: true, NoInteractive: true, ConfirmationToken: RequiredConfirmationToken}, valid: true},
+		"unconfirmed dry run":      {opts: Opts{DeleteFlag: true, NoInteractive: true, DryRun: true}, valid: true},
+		"unconfirmed format check": {opts: Opts{DeleteFlag: true, NoInteractive: true, CheckFinalizerFormat: true}, valid: true},
+	} {
+		if err := validateConfirmationToken(test.opts); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid to be %v, got %v", name, test.valid, err)
+		}
+	}
+
+	// The scan is refused before anything is listed
+	dynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+	opts := Opts{GroupBy: "namespace", RemoveFinalizers: true, NoInteractive: true}
+	if _, err := GetUnusedfinalizers(context.TODO(), &filters.Options{}, fake.NewSimpleClientset(), dynamicClient, "table", opts); err == nil || !strings.Contains(err.Error(), RequiredConfirmationToken) {
+		t.Errorf("Expected the unconfirmed removal to be refused, got %v", err)
+	}
+	if len(dynamicClient.Actions()) > 0 {
+		t.Errorf("Expected nothing to be listed, got %v", dynamicClient.Actions())
+	}
+}
+
+func TestRemoveFinalizers(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	stuck := CreateTestUnstructered("TestResource", gvr.GroupVersion().String(), testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup", metav1.FinalizerDeleteDependents})
+	declined := CreateTestUnstructered("TestResource", gvr.GroupVersion().String(), testNamespace, "declined")
+	declined.SetFinalizers([]string{"example.com/cleanup"})
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck, declined)
+
+	setConfirmationInput(t, "y\nn\n")
+	result, err := RemoveFinalizers(context.TODO(), []ResourceInfo{{Name: "stuck"}, {Name: "declined"}}, dynamicClient, testNamespace, gvr, Opts{})
+	if err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+
+	expected := []ResourceInfo{
+		{Name: "stuck", Reason: "finalizers removed: example.com/cleanup, foregroundDeletion"},
+		{Name: "declined", Reason: "finalizers not removed - user declined"},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %v, Got: %v", expected, result)
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(result[i], expected[i]) {
+			t.Errorf("Expected %v, Got: %v", expected[i], result[i])
+		}
+	}
+
+	for name, finalizers := range map[string]int{"stuck": 0, "declined": 1} {
+		object, err := dynamicClient.Resource(gvr).Namespace(testNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected %s to be kept, Got: %v", name, err)
+		}
+		if len(object.GetFinalizers()) != finalizers {
+			t.Errorf("Expected %d finalizers on %s, Got: %v", finalizers, name, object.GetFinalizers())
+		}
+	}
+}
+
+func TestProtectedFinalizers(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	newClient := func() *fakedynamic.FakeDynamicClient {
+		protected := CreateTestUnstructered("TestResource", gvr.GroupVersion().String(), testNamespace, "protected")
+		protected.SetFinalizers([]string{"kubernetes.io/pv-protection", metav1.FinalizerOrphanDependents})
+		mixed := CreateTestUnstructered("TestResource", gvr.GroupVersion().String(), testNamespace, "mixed")
+		mixed.SetFinalizers([]string{"example.com/cleanup", "kubernetes.io/pv-protection"})
+		return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, protected, mixed)
+	}
+	resources := []ResourceInfo{{Name: "protected"}, {Name: "mixed"}}
+
+	dynamicClient := newClient()
+	removed, err := RemoveFinalizers(context.TODO(), resources, dynamicClient, testNamespace, gvr, Opts{NoInteractive: true})
+	if err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+	if len(removed) != 2 || removed[0].Reason != "protected, skipped: kubernetes.io/pv-protection" ||
+		removed[1].Reason != "finalizers removed: example.com/cleanup, kept protected: kubernetes.io/pv-protection" {
+		t.Errorf("Expected the protected finalizers to be kept, Got: %v", removed)
+	}
+	for name, expected := range map[string][]string{
+		"protected": {"kubernetes.io/pv-protection", metav1.FinalizerOrphanDependents},
+		"mixed":     {"kubernetes.io/pv-protection"},
+	} {
+		object, err := dynamicClient.Resource(gvr).Namespace(testNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected %s to be present, Got: %v", name, err)
+		}
+		if !reflect.DeepEqual(object.GetFinalizers(), expected) {
+			t.Errorf("Expected %s to keep %v, Got: %v", name, expected, object.GetFinalizers())
+		}
+	}
+	actions := deletionActions(testNamespace, gvr.Resource, resources, removed)
+	if actions[0].Action != deletionActionProtected || actions[1].Action != deletionActionRemoved {
+		t.Errorf("Expected the protected resource to be reported as skipped, Got: %v", actions)
+	}
+
+	deleted, err := DeleteResourceWithFinalizer(context.TODO(), resources, newClient(), testNamespace, gvr, &filters.Options{}, Opts{NoInteractive: true})
+	if err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0].Name != "protected" || !strings.HasPrefix(deleted[0].Reason, protectedSkippedReason) || deleted[1].Name != "mixed-DELETED" {
+		t.Errorf("Expected only the unprotected resource to be deleted, Got: %v", deleted)
+	}
+
+	dynamicClient = newClient()
+	if _, err := RemoveFinalizers(context.TODO(), resources, dynamicClient, testNamespace, gvr, Opts{NoInteractive: true, ProtectedFinalizers: []string{}}); err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+	if object, _ := dynamicClient.Resource(gvr).Namespace(testNamespace).Get(context.TODO(), "protected", metav1.GetOptions{}); len(object.GetFinalizers()) != 0 {
+		t.Errorf("Expected every finalizer to be removed without protected finalizers, Got: %v", object.GetFinalizers())
+	}
+}
+
+func TestDeleteResourceGracePeriod(t *testing.T) {
+	clientset := fake.NewSimpleClientset(CreateTestDeployment(testNamespace, "test-deployment", 0, AppLabels))
+
+	gracePeriod := int64(30)
+	opts := Opts{NoInteractive: true, GracePeriodSeconds: &gracePeriod}
+	if _, err := DeleteResource(context.TODO(), []ResourceInfo{{Name: "test-deployment"}}, clientset, testNamespace, "Deployment", &filters.Options{}, opts); err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+
+	for _, action := range clientset.Actions() {
+		if deleteAction, ok := action.(k8stesting.DeleteAction); ok {
+			if seconds := deleteAction.GetDeleteOptions().GracePeriodSeconds; seconds == nil || *seconds != gracePeriod {
+				t.Errorf("Expected a grace period of %d seconds, Got: %v", gracePeriod, seconds)
+			}
+			return
+		}
+	}
+	t.Error("Expected a delete action")
+
+	// Finalizer scans never send delete requests, the grace period would silently be ignored
+	dynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+	opts = Opts{GroupBy: "namespace", DeleteFlag: true, GracePeriodSeconds: &gracePeriod}
+	if _, err := GetUnusedfinalizers(context.TODO(), &filters.Options{}, fake.NewSimpleClientset(), dynamicClient, "table", opts); err == nil || !strings.Contains(err.Error(), "--grace-period") {
+		t.Errorf("Expected the grace period to be refused by the finalizer scan, got %v", err)
+	}
+}
+
+func TestConfirmBatchDeletion(t *testing.T) {
+	var candidates []deletionCandidate
+	for i := 0; i < 12; i++ {
+		candidates = append(candidates, deletionCandidate{namespace: testNamespace, resource: "persistentvolumeclaims", name: fmt.Sprintf("data-%02d", i)})
+	}
+	candidates = append(candidates, deletionCandidate{namespace: "_cluster", resource: "persistentvolumes", name: "pv"})
+
+	summary := formatBatchConfirmation("delete", candidates)
+	for _, expected := range []string{
+		"About to delete 13 resources:\n  persistentvolumeclaims: 12\n  persistentvolumes: 1\n",
+		"  " + testNamespace + "/persistentvolumeclaims/data-09\n  and 3 more\n",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("Expected the summary to contain %q, Got:\n%s", expected, summary)
+		}
+	}
+
+	// A single answer confirms or declines the whole batch
+	setConfirmationInput(t, "y\n")
+	if !confirmBatchDeletion("delete", candidates, Opts{}) {
+		t.Error("Expected the batch to be confirmed")
+	}
+	setConfirmationInput(t, "n\ny\n")
+	if confirmBatchDeletion("delete", candidates, Opts{}) {
+		t.Error("Expected the batch to be declined")
+	}
+
+	declined := declinedResources([]ResourceInfo{{Name: "data-00"}}, Opts{RemoveFinalizers: true})
+	if len(declined) != 1 || declined[0].Reason != "finalizers not removed - user declined" {
+		t.Errorf("Expected the resource to be declined, Got: %v", declined)
+	}
+}
+
+// patchRecorder records the options of the patch requests it receives, which the fake dynamic client drops
+type patchRecorder struct {
+	dynamic.Interface
+	dynamic.NamespaceableResourceInterface
+	patched []metav1.PatchOptions
+}
+
+func (p *patchRecorder) Resource(schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return p
+}
+
+func (p *patchRecorder) Namespace(string) dynamic.ResourceInterface {
+	return p
+}
+
+func (p *patchRecorder) Get(_ context.Context, name string, _ metav1.GetOptions, _ ...string) (*unstructured.Unstructured, error) {
+	obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, name)
+	obj.SetFinalizers([]string{"example.com/cleanup"})
+	return obj, nil
+}
+
+func (p *patchRecorder) Patch(_ context.Context, name string, _ types.PatchType, _ []byte, opts metav1.PatchOptions, _ ...string) (*unstructured.Unstructured, error) {
+	p.patched = append(p.patched, opts)
+	return p.Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func TestDeleteDryRun(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	opts := Opts{NoInteractive: true, DryRun: true}
+
+	dynamicClient := &patchRecorder{}
+	deleted, err := DeleteResourceWithFinalizer(context.TODO(), []ResourceInfo{{Name: "stuck"}}, dynamicClient, testNamespace, gvr, &filters.Options{}, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+	removed, err := RemoveFinalizers(context.TODO(), []ResourceInfo{{Name: "stuck"}}, dynamicClient, testNamespace, gvr, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+	if len(dynamicClient.patched) != 2 {
+		t.Fatalf("Expected 2 patch requests, Got: %v", dynamicClient.patched)
+	}
+	for _, patchOptions := range dynamicClient.patched {
+		if !reflect.DeepEqual(patchOptions.DryRun, []string{metav1.DryRunAll}) {
+			t.Errorf("Expected the patch to be a server-side dry run, Got: %v", patchOptions.DryRun)
+		}
+	}
+	if len(deleted) != 1 || deleted[0].Name != "stuck-DELETED (dry-run)" {
+		t.Errorf("Expected the deletion to be marked as a dry run, Got: %v", deleted)
+	}
+	if len(removed) != 1 || removed[0].Reason != "finalizers removed (dry-run): example.com/cleanup" {
+		t.Errorf("Expected the finalizer removal to be marked as a dry run, Got: %v", removed)
+	}
+	if attempts := deletionAttempts([]ResourceInfo{{Name: "stuck"}}, deleted); !attempts["stuck"] {
+		t.Errorf("Expected the dry run deletion to count as an attempt, Got: %v", attempts)
+	}
+
+	clientset := fake.NewSimpleClientset(CreateTestDeployment(testNamespace, "test-deployment", 0, AppLabels))
+	if _, err := DeleteResource(context.TODO(), []ResourceInfo{{Name: "test-deployment"}}, clientset, testNamespace, "Deployment", &filters.Options{}, opts); err != nil {
+		t.Fatalf("Expected no error, Got: %v", err)
+	}
+	for _, action := range clientset.Actions() {
+		if deleteAction, ok := action.(k8stesting.DeleteAction); ok {
+			if dryRun := deleteAction.GetDeleteOptions().DryRun; !reflect.DeepEqual(dryRun, []string{metav1.DryRunAll}) {
+				t.Errorf("Expected the delete to be a server-side dry run, Got: %v", dryRun)
+			}
+			return
+		}
+	}
+	t.Error("Expected a delete action")
+}
+
+func TestDeleteCancelled(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	stuck := CreateTestUnstructered("TestResource", gvr.GroupVersion().String(), testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck)
+	clientset := fake.NewSimpleClientset(CreateTestDeployment(testNamespace, "test-deployment", 0, AppLabels))
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	resources := []ResourceInfo{{Name: "stuck"}}
+	deleted, err := DeleteResourceWithFinalizer(ctx, resources, dynamicClient, testNamespace, gvr, &filters.Options{}, Opts{NoInteractive: true})
+	if err != context.Canceled || !reflect.DeepEqual(deleted, resources) {
+		t.Errorf("Expected the cancelled deletion to leave the resources untouched, Got: %v, %v", deleted, err)
+	}
+	removed, err := RemoveFinalizers(ctx, resources, dynamicClient, testNamespace, gvr, Opts{NoInteractive: true})
+	if err != context.Canceled || !reflect.DeepEqual(removed, resources) {
+		t.Errorf("Expected the cancelled finalizer removal to leave the resources untouched, Got: %v, %v", removed, err)
+	}
+	if actions := dynamicClient.Actions(); len(actions) != 0 {
+		t.Errorf("Expected no request once cancelled, Got: %v", actions)
+	}
+	if _, err := DeleteResource(ctx, []ResourceInfo{{Name: "test-deployment"}}, clientset, testNamespace, "Deployment", &filters.Options{}, Opts{NoInteractive: true}); err != context.Canceled {
+		t.Errorf("Expected the cancelled deletion to fail, Got: %v", err)
+	}
+	if actions := clientset.Actions(); len(actions) != 0 {
+		t.Errorf("Expected no request once cancelled, Got: %v", actions)
+	}
+}
@@ -42,15 +42,20 @@ func processNamespaceDeployments(clientset kubernetes.Interface, namespace strin
 }
 
 func GetUnusedDeployments(filterOpts *filters.Options, clientset kubernetes.Interface, outputFormat string, opts Opts) (string, error) {
+	opts = withDeleteThrottle(opts)
 	resources := make(map[string]map[string][]ResourceInfo)
-	for _, namespace := range filterOpts.Namespaces(clientset) {
+	namespaces, err := filterOpts.Namespaces(clientset)
+	if err != nil {
+		return "", err
+	}
+	for _, namespace := range namespaces {
 		diff, err := processNamespaceDeployments(clientset, namespace, filterOpts)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to process namespace %s: %v\n", namespace, err)
 			continue
 		}
 		if opts.DeleteFlag {
-			if diff, err = DeleteResource(diff, clientset, namespace, "Deployment", opts.NoInteractive); err != nil {
+			if diff, err = DeleteResource(context.TODO(), diff, clientset, namespace, "Deployment", filterOpts, opts); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to delete Deployment %s in namespace %s: %v\n", diff, namespace, err)
 			}
 		}
@@ -0,0 +1,91 @@
+package kor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/disk"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+)
+
+// defaultDiscoveryCacheTTL is how long cached discovery data is used when no TTL is configured
+const defaultDiscoveryCacheTTL = 10 * time.Minute
+
+// unsafeDiscoveryCacheChars matches the characters replaced in the discovery cache directory of an API
+// server, the same ones kubectl replaces, so both can share a cache directory
+var unsafeDiscoveryCacheChars = regexp.MustCompile(`[^(\w/.)]`)
+
+// discoveryCacheDir returns the directory the discovery data of an API server is cached in, the way
+// kubectl computes it: the host without its scheme, with the unsafe characters replaced
+func discoveryCacheDir(parentDir, host string) string {
+	schemelessHost := strings.Replace(strings.Replace(host, "https://", "", 1), "http://", "", 1)
+	return filepath.Join(parentDir, unsafeDiscoveryCacheChars.ReplaceAllString(schemelessHost, "_"))
+}
+
+// GetCachedDiscoveryClient returns a discovery client caching the API resources on disk below cacheDir,
+// so repeated kor invocations only hit discovery once the TTL expired. The cache is kept per API server.
+func GetCachedDiscoveryClient(kubeconfig, cacheDir string, ttl time.Duration) (discovery.CachedDiscoveryInterface, error) {
+	config, err := GetConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return newCachedDiscoveryClient(config, cacheDir, ttl)
+}
+
+func newCachedDiscoveryClient(config *rest.Config, cacheDir string, ttl time.Duration) (discovery.CachedDiscoveryInterface, error) {
+	if ttl <= 0 {
+		ttl = defaultDiscoveryCacheTTL
+	}
+	return disk.NewCachedDiscoveryClientForConfig(config, discoveryCacheDir(filepath.Join(cacheDir, "discovery"), config.Host), filepath.Join(cacheDir, "http"), ttl)
+}
+
+// ttlDiscoveryClient is an in-memory discovery cache refreshed once its TTL expired, for long-running
+// processes scanning repeatedly
+type ttlDiscoveryClient struct {
+	discovery.CachedDiscoveryInterface
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	refreshed time.Time
+}
+
+// newMemCacheDiscoveryClient caches the discovery data of delegate in memory for the given TTL
+func newMemCacheDiscoveryClient(delegate discovery.DiscoveryInterface, ttl time.Duration) discovery.CachedDiscoveryInterface {
+	if ttl <= 0 {
+		ttl = defaultDiscoveryCacheTTL
+	}
+	return &ttlDiscoveryClient{CachedDiscoveryInterface: memory.NewMemCacheClient(delegate), ttl: ttl, now: time.Now}
+}
+
+func (c *ttlDiscoveryClient) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now := c.now(); now.Sub(c.refreshed) >= c.ttl {
+		if !c.refreshed.IsZero() {
+			c.Invalidate()
+		}
+		c.refreshed = now
+	}
+}
+
+func (c *ttlDiscoveryClient) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	c.expire()
+	return c.CachedDiscoveryInterface.ServerGroupsAndResources()
+}
+
+func (c *ttlDiscoveryClient) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	c.expire()
+	return c.CachedDiscoveryInterface.ServerPreferredResources()
+}
+
+func (c *ttlDiscoveryClient) ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error) {
+	c.expire()
+	return c.CachedDiscoveryInterface.ServerPreferredNamespacedResources()
+}
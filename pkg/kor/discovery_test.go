@@ -0,0 +1,64 @@
+package kor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMemCacheDiscoveryClientTTL(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"list"}}},
+	}}
+
+	now := time.Now()
+	client := newMemCacheDiscoveryClient(clientset.Discovery(), time.Minute).(*ttlDiscoveryClient)
+	client.now = func() time.Time { return now }
+
+	discover := func() int {
+		t.Helper()
+		resources, err := client.ServerPreferredNamespacedResources()
+		if err != nil {
+			t.Fatalf("Unexpected discovery error: %v", err)
+		}
+		if len(resources) != 1 || resources[0].APIResources[0].Name != "configmaps" {
+			t.Fatalf("Unexpected discovered resources: %v", resources)
+		}
+		return len(clientset.Actions())
+	}
+
+	first := discover()
+	if first == 0 {
+		t.Fatal("Expected the first discovery to query the API server")
+	}
+	now = now.Add(30 * time.Second)
+	if cached := discover(); cached != first {
+		t.Errorf("Expected discovery within the TTL to be cached, got %d requests after %d", cached, first)
+	}
+	now = now.Add(time.Minute)
+	if refreshed := discover(); refreshed == first {
+		t.Error("Expected discovery to be refreshed once the TTL expired")
+	}
+}
+
+func TestDiscoveryCacheDir(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"https://10.0.0.1:6443", "cache/10.0.0.1_6443"},
+		{"http://localhost:8080", "cache/localhost_8080"},
+		{"https://api.example.com/k8s/clusters/c-1234", "cache/api.example.com/k8s/clusters/c_1234"},
+		{"api.example.com", "cache/api.example.com"},
+	}
+	for _, test := range tests {
+		if dir := discoveryCacheDir("cache", test.host); dir != filepath.FromSlash(test.expected) {
+			t.Errorf("Expected %s to be cached in %s, got %s", test.host, test.expected, dir)
+		}
+	}
+}
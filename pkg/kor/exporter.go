@@ -1,10 +1,12 @@
 package kor
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -12,6 +14,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
@@ -26,10 +29,27 @@ var (
 		},
 		[]string{"kind", "namespace", "resourceName"},
 	)
+	finalizersPendingGauge = newFinalizersPendingGauge()
+	scanDurationGauge      = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kor_scan_duration_seconds",
+		Help: "Duration of the last kor scan",
+	})
 )
 
 func init() {
-	prometheus.MustRegister(orphanedResourcesCounter)
+	prometheus.MustRegister(orphanedResourcesCounter, finalizersPendingGauge, scanDurationGauge)
+}
+
+// newFinalizersPendingGauge returns the kor_finalizers_pending family, served by the finalizer exporter
+// and written to the metrics textfile
+func newFinalizersPendingGauge() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kor_finalizers_pending",
+			Help: "Resources pending deletion because of their finalizers",
+		},
+		[]string{"namespace", "resource_type"},
+	)
 }
 
 // TODO: add option to change port / url !?
@@ -42,7 +62,19 @@ func Exporter(filterOptions *filters.Options, clientset kubernetes.Interface, ap
 	}
 }
 
-func exportMetrics(filterOptions *filters.Options, clientset kubernetes.Interface, apiExtClient apiextensionsclientset.Interface, dynamicClient dynamic.Interface, outputFormat string, opts Opts, resourceList []string) {
+// FinalizerExporter serves the number of resources pending deletion because of their finalizers per
+// namespace and resource type as metrics, from the same scan as GetUnusedfinalizers
+func FinalizerExporter(filterOptions *filters.Options, clientset kubernetes.Interface, dynamicClient dynamic.Interface, opts Opts) {
+	http.Handle("/metrics", promhttp.Handler())
+	fmt.Println("Server listening on :8080")
+	go exportFinalizerMetrics(filterOptions, clientset, dynamicClient, opts) // Start exporting metrics in the background
+	if err := http.ListenAndServe(":8080", nil); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// exporterInterval returns the time between two scans of the exporter, EXPORTER_INTERVAL minutes
+func exporterInterval() time.Duration {
 	exporterInterval := os.Getenv("EXPORTER_INTERVAL")
 	if exporterInterval == "" {
 		exporterInterval = "10"
@@ -52,13 +84,66 @@ func exportMetrics(filterOptions *filters.Options, clientset kubernetes.Interfac
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	return time.Duration(exporterIntervalValue) * time.Minute
+}
+
+func exportFinalizerMetrics(filterOptions *filters.Options, clientset kubernetes.Interface, dynamicClient dynamic.Interface, opts Opts) {
+	interval := exporterInterval()
+	// The scans share the discovery data until it expires, instead of querying discovery every interval
+	if opts.DiscoveryClient == nil {
+		opts.DiscoveryClient = newMemCacheDiscoveryClient(clientset.Discovery(), opts.DiscoveryCacheTTL)
+	}
+	for {
+		fmt.Println("collecting resources waiting for finalizers")
+		if err := collectFinalizerMetrics(context.Background(), filterOptions, clientset, dynamicClient, opts); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// collectFinalizerMetrics scans the resources pending deletion and replaces the kor_finalizers_pending
+// series with their counts, so resources deleted since the previous scan disappear from the metrics
+func collectFinalizerMetrics(ctx context.Context, filterOptions *filters.Options, clientset kubernetes.Interface, dynamicClient dynamic.Interface, opts Opts) error {
+	if err := validateFinalizerScan(filterOptions, "", opts); err != nil {
+		return err
+	}
+	withFinalizerScanDefaults(filterOptions)
+	start := time.Now()
+	namespaces, err := newNamespaceSelection(ctx, clientset, filterOptions)
+	if err != nil {
+		return err
+	}
+	scanResult, err := scanFinalizers(ctx, filterOptions, clientset, dynamicClient, opts)
+	if err != nil {
+		return err
+	}
+	scanDurationGauge.Set(time.Since(start).Seconds())
+
+	finalizersPendingGauge.Reset()
+	for namespace, resourceTypes := range scanResult.pendingDeletion {
+		if !namespaces.includes(namespace) {
+			continue
+		}
+		for gvr, infos := range resourceTypes {
+			finalizersPendingGauge.WithLabelValues(reportedNamespace(namespace), gvr.GroupResource().String()).Add(float64(len(infos)))
+		}
+	}
+	return nil
+}
+
+func exportMetrics(filterOptions *filters.Options, clientset kubernetes.Interface, apiExtClient apiextensionsclientset.Interface, dynamicClient dynamic.Interface, outputFormat string, opts Opts, resourceList []string) {
+	interval := exporterInterval()
 
 	for {
 		fmt.Println("collecting unused resources")
+		start := time.Now()
 		if korOutput, err := getUnusedResources(filterOptions, clientset, apiExtClient, dynamicClient, outputFormat, opts, resourceList); err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		} else {
+			scanDurationGauge.Set(time.Since(start).Seconds())
 			var data map[string]map[string][]string
 			if err := json.Unmarshal([]byte(korOutput), &data); err != nil {
 				fmt.Println("Error parsing JSON:", err)
@@ -74,7 +159,7 @@ func exportMetrics(filterOptions *filters.Options, clientset kubernetes.Interfac
 					}
 				}
 			}
-			time.Sleep(time.Duration(exporterIntervalValue) * time.Minute)
+			time.Sleep(interval)
 		}
 	}
 }
@@ -86,3 +171,37 @@ func getUnusedResources(filterOptions *filters.Options, clientset kubernetes.Int
 	return GetUnusedMulti(strings.Join(resourceList, ","), filterOptions, clientset, apiExtClient, dynamicClient, outputFormat, opts)
 
 }
+
+// WriteMetricsTextfile writes the number of resources per namespace and resource type as the
+// kor_finalizers_pending metrics of the finalizer exporter, in the format read by the node_exporter
+// textfile collector, together with the time of the scan. The file is written to a temporary file
+// and renamed into place so the collector never reads a partial file.
+func WriteMetricsTextfile(path string, resources map[string]map[string][]ResourceInfo) error {
+	if filepath.Ext(path) != ".prom" {
+		return fmt.Errorf("metrics textfile %q must have the .prom extension to be read by the textfile collector", path)
+	}
+
+	registry := prometheus.NewRegistry()
+	finalizersPending := newFinalizersPendingGauge()
+	scanTimestamp := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kor_last_scan_timestamp_seconds",
+		Help: "Unix time of the kor scan that produced these metrics",
+	})
+	registry.MustRegister(finalizersPending, scanTimestamp)
+
+	for namespace, resourceMap := range resources {
+		for resourceType, infos := range resourceMap {
+			for _, info := range infos {
+				// The resource types are labelled like the exporter does, by group and resource
+				label := resourceType
+				if info.Resource != "" {
+					label = schema.GroupResource{Group: info.Group, Resource: info.Resource}.String()
+				}
+				finalizersPending.WithLabelValues(namespace, label).Inc()
+			}
+		}
+	}
+	scanTimestamp.SetToCurrentTime()
+
+	return prometheus.WriteToTextfile(path, registry)
+}
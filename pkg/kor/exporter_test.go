@@ -0,0 +1,108 @@
+package kor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+func TestWriteMetricsTextfile(t *testing.T) {
+	resources := map[string]map[string][]ResourceInfo{
+		testNamespace: {
+			"pods":             {{Name: "stuck-pod", Version: "v1", Resource: "pods"}, {Name: "other-pod", Version: "v1", Resource: "pods"}},
+			"testresources.v1": {{Name: "stuck", Group: "testgroup", Version: "v1", Resource: "testresources"}},
+		},
+		clusterScopeKey: {
+			"testclusterresources": {{Name: "stuck", Group: "testgroup", Version: "v1", Resource: "testclusterresources"}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "kor.prom")
+	if err := WriteMetricsTextfile(path, resources); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error reading metrics textfile: %v", err)
+	}
+	for _, expected := range []string{
+		`kor_finalizers_pending{namespace="` + testNamespace + `",resource_type="pods"} 2`,
+		`kor_finalizers_pending{namespace="` + testNamespace + `",resource_type="testresources.testgroup"} 1`,
+		`kor_finalizers_pending{namespace="` + clusterScopeKey + `",resource_type="testclusterresources.testgroup"} 1`,
+		"kor_last_scan_timestamp_seconds ",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected metrics textfile to contain %q, got:\n%s", expected, content)
+		}
+	}
+
+	if strings.Contains(string(content), "stuck-pod") {
+		t.Errorf("Expected the metrics to be aggregated without resource names, got:\n%s", content)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the metrics textfile to remain, got %d files", len(entries))
+	}
+}
+
+func TestWriteMetricsTextfileExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kor.txt")
+	if err := WriteMetricsTextfile(path, nil); err == nil {
+		t.Error("Expected an error for a file without the .prom extension")
+	}
+}
+
+func TestCollectFinalizerMetrics(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	var objects []runtime.Object
+	for _, name := range []string{"stuck-a", "stuck-b"} {
+		obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, name)
+		obj.SetFinalizers([]string{"example.com/cleanup"})
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, obj)
+	}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, objects...)
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+	var discoveryCalls int
+	opts := Opts{DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}}}
+
+	// A series of a previous scan is dropped once its resources are gone
+	finalizersPendingGauge.WithLabelValues("gone", "testresources.testgroup").Set(1)
+	if err := collectFinalizerMetrics(context.TODO(), &filters.Options{}, clientset, dynamicClient, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `
+# HELP kor_finalizers_pending Resources pending deletion because of their finalizers
+# TYPE kor_finalizers_pending gauge
+kor_finalizers_pending{namespace="` + testNamespace + `",resource_type="testresources.testgroup"} 2
+`
+	if err := testutil.CollectAndCompare(finalizersPendingGauge, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+	if testutil.CollectAndCount(scanDurationGauge) != 1 {
+		t.Error("Expected the scan duration to be exported")
+	}
+}
@@ -4,119 +4,1278 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	"k8s.io/utils/strings/slices"
 
 	"github.com/yonahd/kor/pkg/filters"
 )
 
-func CheckFinalizers(finalizers []string, deletionTimestamp *metav1.Time) bool {
-	if len(finalizers) > 0 && deletionTimestamp != nil {
+// CheckFinalizers reports whether the deletion of a resource was requested while it still has at least
+// minCount finalizers. A minCount below 1 counts as 1.
+func CheckFinalizers(finalizers []string, deletionTimestamp *metav1.Time, minCount int) bool {
+	if len(finalizers) >= max(minCount, 1) && deletionTimestamp != nil {
 		return true
 	}
 	return false
 }
 
-func retrievePendingDeletionResources(resourceTypes []*metav1.APIResourceList, dynamicClient dynamic.Interface, filterOpts *filters.Options) (map[string]map[schema.GroupVersionResource][]ResourceInfo, error) {
-	pendingDeletionResources := make(map[string]map[schema.GroupVersionResource][]ResourceInfo) //map[namespace]map[gvr][]resourceNames
+const deletionNotRequestedReason = "Deletion has not been requested"
+
+// IsStuckFinalizer applies the finalizer scan checks to a single object without any cluster calls.
+// The reason describes why the object is stuck, or why it is not.
+func IsStuckFinalizer(obj *unstructured.Unstructured, filterOpts *filters.Options) (stuck bool, reason string) {
+	if mark := filters.UsedMark(obj, filterOpts); mark != "" {
+		return false, usedMarkReason(filterOpts, mark)
+	}
+	framework := filter.SetObject(obj)
+	for _, check := range []struct {
+		name   string
+		reason string
+	}{
+		{filters.LabelFilterName, "Matches an excluded label"},
+		{filters.AgeFilterName, "Outside of the included age range"},
+		{filters.ExprFilterName, "Matches the exclude expression"},
+	} {
+		if skip, _ := framework.RunFilter(check.name, filterOpts); skip {
+			return false, check.reason
+		}
+	}
+	if len(obj.GetFinalizers()) == 0 {
+		return false, "Has no finalizers"
+	}
+	if !CheckFinalizers(obj.GetFinalizers(), obj.GetDeletionTimestamp(), 1) {
+		return false, deletionNotRequestedReason
+	}
+	if !CheckFinalizers(obj.GetFinalizers(), obj.GetDeletionTimestamp(), filterOpts.MinFinalizerCount) {
+		return false, fmt.Sprintf("Waiting for fewer than %d finalizers", filterOpts.MinFinalizerCount)
+	}
+	if !filterOpts.MatchesFinalizer(obj.GetFinalizers()) {
+		return false, fmt.Sprintf("No finalizer matches %s", strings.Join(filterOpts.FinalizerMatch, ", "))
+	}
+	if !filterOpts.MatchesStatus(obj.UnstructuredContent()) {
+		return false, statusMismatchReason(filterOpts)
+	}
+	if minStuckDuration, _ := filterOpts.MinStuckDurationValue(); time.Since(obj.GetDeletionTimestamp().Time) < minStuckDuration {
+		return false, fmt.Sprintf("Pending deletion for less than %s", minStuckDuration)
+	}
+	return true, "Pending deletion waiting for finalizers"
+}
+
+// statusMismatchReason describes why an object whose status does not match StatusMatchPath is not stuck
+func statusMismatchReason(filterOpts *filters.Options) string {
+	if filterOpts.StatusMatchValue == "" {
+		return fmt.Sprintf("Has no status at %s", filterOpts.StatusMatchPath)
+	}
+	return fmt.Sprintf("Status at %s is not %q", filterOpts.StatusMatchPath, filterOpts.StatusMatchValue)
+}
+
+// usedMarkReason describes the mark of an object marked as used, a label or an annotation, see filters.UsedMark
+func usedMarkReason(filterOpts *filters.Options, mark string) string {
+	key, _ := filterOpts.UsedLabel()
+	return fmt.Sprintf("Marked as used with the %s %s", key, mark)
+}
+
+// standardFinalizers are the built-in finalizers that are allowed to omit a domain prefix
+var standardFinalizers = []string{
+	metav1.FinalizerOrphanDependents,
+	metav1.FinalizerDeleteDependents,
+	"kubernetes",
+}
+
+// CheckFinalizerFormat reports why a finalizer does not match the expected "domain/name" format.
+// An empty string is returned for well-formed finalizers.
+func CheckFinalizerFormat(finalizer string) string {
+	if strings.IndexFunc(finalizer, unicode.IsSpace) != -1 {
+		return "contains whitespace"
+	}
+	if slices.Contains(standardFinalizers, finalizer) {
+		return ""
+	}
+	if !strings.Contains(finalizer, "/") {
+		return "is missing a domain prefix, expected domain/name"
+	}
+	if errs := validation.IsQualifiedName(finalizer); len(errs) > 0 {
+		return "is not a valid domain/name: " + strings.Join(errs, "; ")
+	}
+	return ""
+}
+
+// finalizerScanResult holds everything collected in a single pass over the listed resources
+type finalizerScanResult struct {
+	pendingDeletion     map[string]map[schema.GroupVersionResource][]ResourceInfo //map[namespace]map[gvr][]resourceNames
+	malformedFinalizers map[string]map[schema.GroupVersionResource][]ResourceInfo
+	namespaceActivity   map[string]time.Time // latest object change seen per namespace
+	stuckItems          []stuckItem
+	skippedTypes        int                                                       // resource types that could not be listed
+	servedGroups        map[string]bool                                           // API groups served, to detect dangling finalizers
+	protectedStuck      map[string]map[schema.GroupVersionResource][]ResourceInfo // stuck objects skipped for being marked as used
+	stats               []resourceTypeStats                                       // listing diagnostics per resource type
+}
+
+func newFinalizerScanResult() *finalizerScanResult {
+	return &finalizerScanResult{
+		pendingDeletion:     make(map[string]map[schema.GroupVersionResource][]ResourceInfo),
+		malformedFinalizers: make(map[string]map[schema.GroupVersionResource][]ResourceInfo),
+		namespaceActivity:   make(map[string]time.Time),
+		protectedStuck:      make(map[string]map[schema.GroupVersionResource][]ResourceInfo),
+	}
+}
+
+// lastActivity returns the most recent change recorded in the object metadata
+func lastActivity(obj metav1.Object) time.Time {
+	latest := obj.GetCreationTimestamp().Time
+	if deletionTimestamp := obj.GetDeletionTimestamp(); deletionTimestamp != nil && deletionTimestamp.After(latest) {
+		latest = deletionTimestamp.Time
+	}
+	for _, managedField := range obj.GetManagedFields() {
+		if managedField.Time != nil && managedField.Time.After(latest) {
+			latest = managedField.Time.Time
+		}
+	}
+	return latest
+}
+
+func addFinalizerResource(resources map[string]map[schema.GroupVersionResource][]ResourceInfo, namespace string, gvr schema.GroupVersionResource, info ResourceInfo) {
+	if resources[namespace] == nil {
+		resources[namespace] = make(map[schema.GroupVersionResource][]ResourceInfo)
+	}
+	resources[namespace][gvr] = append(resources[namespace][gvr], info)
+}
+
+// FindingSeverity classifies how long a resource has been stuck using the configured severity bands.
+// An empty string is returned when no bands are configured.
+func FindingSeverity(stuckFor time.Duration, opts Opts) string {
+	switch {
+	case opts.SeverityWarn == 0 && opts.SeverityCrit == 0:
+		return ""
+	case opts.SeverityCrit > 0 && stuckFor >= opts.SeverityCrit:
+		return "crit"
+	case opts.SeverityWarn > 0 && stuckFor >= opts.SeverityWarn:
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+// explainFinding describes why a stuck object was flagged from the data gathered by the scan
+func explainFinding(obj *unstructured.Unstructured, chain ownerChain, location *time.Location) string {
+	finalizers := obj.GetFinalizers()
+	explanation := fmt.Sprintf("Has finalizer %s", strings.Join(finalizers, ", "))
+	if len(finalizers) > 1 {
+		explanation = fmt.Sprintf("Has finalizers %s", strings.Join(finalizers, ", "))
+	}
+	if deletionTimestamp := obj.GetDeletionTimestamp(); deletionTimestamp != nil {
+		explanation += fmt.Sprintf(", deletion requested %s ago (%s)", duration.HumanDuration(time.Since(deletionTimestamp.Time)), formatTimestamp(deletionTimestamp.Time, location))
+	}
+	if len(chain.owners) > 0 {
+		explanation += ", owned by " + chain.String()
+	}
+	return explanation
+}
+
+// FinalizerFinding is a resource stuck pending deletion because of its finalizers
+type FinalizerFinding struct {
+	Namespace            string                      `json:"namespace"`
+	GroupVersionResource schema.GroupVersionResource `json:"groupVersionResource"`
+	Kind                 string                      `json:"kind"`
+	Name                 string                      `json:"name"`
+	Labels               map[string]string           `json:"labels,omitempty"`
+	Finalizers           []string                    `json:"finalizers"`
+	DeletionTimestamp    *metav1.Time                `json:"deletionTimestamp,omitempty"`
+}
+
+func newFinalizerFinding(obj *unstructured.Unstructured, gvr schema.GroupVersionResource) FinalizerFinding {
+	return FinalizerFinding{
+		Namespace:            obj.GetNamespace(),
+		GroupVersionResource: gvr,
+		Kind:                 obj.GetKind(),
+		Name:                 obj.GetName(),
+		Labels:               obj.GetLabels(),
+		Finalizers:           obj.GetFinalizers(),
+		DeletionTimestamp:    obj.GetDeletionTimestamp(),
+	}
+}
+
+// stuckItem is an object found pending deletion, kept until it is enriched and reported
+type stuckItem struct {
+	object *unstructured.Unstructured
+	gvr    schema.GroupVersionResource
+	reason string
+	chain  ownerChain
+}
+
+// scanObject records the activity and finalizer anomalies of a single object, and returns it when
+// it is stuck pending deletion. The object is copied before it is kept, so it may be shared.
+func (r *finalizerScanResult) scanObject(item *unstructured.Unstructured, gvr schema.GroupVersionResource, filterOpts *filters.Options) (stuckItem, bool) {
+	if activity := lastActivity(item); activity.After(r.namespaceActivity[item.GetNamespace()]) {
+		r.namespaceActivity[item.GetNamespace()] = activity
+	}
+	for _, finalizer := range item.GetFinalizers() {
+		if anomaly := CheckFinalizerFormat(finalizer); anomaly != "" {
+			addFinalizerResource(r.malformedFinalizers, item.GetNamespace(), gvr, ResourceInfo{
+				Name:       item.GetName(),
+				Reason:     fmt.Sprintf("Finalizer %q %s", finalizer, anomaly),
+				Group:      gvr.Group,
+				Version:    gvr.Version,
+				Resource:   gvr.Resource,
+				Kind:       item.GetKind(),
+				Finalizers: item.GetFinalizers(),
+			})
+		}
+	}
+	stuck, reason := IsStuckFinalizer(item, filterOpts)
+	if stuck {
+		return stuckItem{object: item.DeepCopy(), gvr: gvr, reason: reason}, true
+	}
+	// Finalizers of an uninstalled operator block the deletion as soon as it is requested, report them upfront
+	if reason == deletionNotRequestedReason && filterOpts.DanglingFinalizers && filterOpts.MatchesFinalizer(item.GetFinalizers()) && filterOpts.MatchesStatus(item.UnstructuredContent()) {
+		if dangling := danglingFinalizers(item.GetFinalizers(), r.servedGroups); len(dangling) > 0 {
+			reason := fmt.Sprintf("Dangling finalizer %s, its API group is not served", strings.Join(dangling, ", "))
+			if len(dangling) > 1 {
+				reason = fmt.Sprintf("Dangling finalizers %s, their API groups are not served", strings.Join(dangling, ", "))
+			}
+			return stuckItem{object: item.DeepCopy(), gvr: gvr, reason: reason}, true
+		}
+	}
+	// Objects marked as used are never reported as stuck, but are kept aside in case they are wedged anyway
+	if mark := filters.UsedMark(item, filterOpts); mark != "" && CheckFinalizers(item.GetFinalizers(), item.GetDeletionTimestamp(), filterOpts.MinFinalizerCount) && filterOpts.MatchesFinalizer(item.GetFinalizers()) &&
+		filterOpts.MatchesStatus(item.UnstructuredContent()) {
+		addFinalizerResource(r.protectedStuck, item.GetNamespace(), gvr, ResourceInfo{
+			Name:       item.GetName(),
+			Reason:     fmt.Sprintf("%s, waiting for %s", usedMarkReason(filterOpts, mark), strings.Join(item.GetFinalizers(), ", ")),
+			Group:      gvr.Group,
+			Version:    gvr.Version,
+			Resource:   gvr.Resource,
+			Kind:       item.GetKind(),
+			Finalizers: item.GetFinalizers(),
+		})
+	}
+	return stuckItem{}, false
+}
+
+// addStuckItems reports the stuck objects as pending deletion, once they are enriched with their owners
+func (r *finalizerScanResult) addStuckItems(stuckItems []stuckItem, opts Opts) {
+	if opts.FindingFilter != nil {
+		kept := stuckItems[:0]
+		for _, stuck := range stuckItems {
+			if opts.FindingFilter(newFinalizerFinding(stuck.object, stuck.gvr)) {
+				kept = append(kept, stuck)
+			}
+		}
+		stuckItems = kept
+	}
+	r.stuckItems = stuckItems
+	// The timezone is validated before scanning, fall back to UTC rather than failing here
+	location, err := timezoneLocation(opts.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	statusPaths, _ := compileStatusPaths(opts.StatusPaths)
+	for _, stuck := range stuckItems {
+		reason := stuck.reason
+		// Dangling finalizers are reported before any deletion was requested, their reason explains them already
+		if opts.Explain && stuck.object.GetDeletionTimestamp() != nil {
+			reason = explainFinding(stuck.object, stuck.chain, location)
+		} else if (opts.ShowOwners || opts.Explain) && len(stuck.chain.owners) > 0 {
+			reason += ", owned by " + stuck.chain.String()
+		}
+		if opts.ShowFinalizerManagers {
+			if managers := describeFinalizerManagers(finalizerManagers(stuck.object)); managers != "" {
+				reason += ", " + managers
+			}
+		}
+		if status := statusDiagnostic(stuck.object, stuck.gvr, statusPaths); status != "" {
+			reason += ", status: " + status
+		}
+		var severity string
+		var pendingSeconds json.Number
+		if deletionTimestamp := stuck.object.GetDeletionTimestamp(); deletionTimestamp != nil {
+			severity = FindingSeverity(time.Since(deletionTimestamp.Time), opts)
+			pendingSeconds = json.Number(strconv.FormatInt(int64(time.Since(deletionTimestamp.Time).Seconds()), 10))
+		}
+		addFinalizerResource(r.pendingDeletion, stuck.object.GetNamespace(), stuck.gvr, ResourceInfo{
+			Name:           stuck.object.GetName(),
+			Reason:         reason,
+			Severity:       severity,
+			PendingSeconds: pendingSeconds,
+			Group:          stuck.gvr.Group,
+			Version:        stuck.gvr.Version,
+			Resource:       stuck.gvr.Resource,
+			Kind:           stuck.object.GetKind(),
+			Finalizers:     stuck.object.GetFinalizers(),
+		})
+	}
+}
+
+func retrievePendingDeletionResources(ctx context.Context, resourceTypes []*metav1.APIResourceList, dynamicClient dynamic.Interface, filterOpts *filters.Options, opts Opts) (*finalizerScanResult, error) {
+	result := newFinalizerScanResult()
+	result.servedGroups = opts.servedGroups
+	var stuckItems []stuckItem
+
+	var versions *resourceVersionState
+	if opts.ResourceVersionFile != "" {
+		var err error
+		if versions, err = loadResourceVersionState(opts.ResourceVersionFile); err != nil {
+			return result, err
+		}
+	}
+
+	var owners *ownerResolver
+	if opts.ShowOwners || opts.Explain || opts.OwnerTree || opts.SkipOwned {
+		owners = newOwnerResolver(ctx, resourceTypes, dynamicClient, opts.OwnerDepth)
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultListPageSize
+	}
+	retry := newListRetry(opts.ListAttempts)
 
 	for _, apiResourceList := range resourceTypes {
 		gv, err := schema.ParseGroupVersion(apiResourceList.GroupVersion)
 		if err != nil {
-			return pendingDeletionResources, err
+			return result, err
 		}
 
 		for _, resourceType := range apiResourceList.APIResources {
 
 			if slices.Contains(resourceType.Verbs, "list") {
 
+				// Stop right away once the scan is cancelled, instead of going through the remaining resource types
+				if err := ctx.Err(); err != nil {
+					return result, err
+				}
 				gvr := gv.WithResource(resourceType.Name)
-				resourceList, err := dynamicClient.
-					Resource(gvr).
-					Namespace(metav1.NamespaceAll).
-					List(context.TODO(), metav1.ListOptions{LabelSelector: filterOpts.IncludeLabels})
+				resourceState := versions
+				if !slices.Contains(resourceType.Verbs, "watch") {
+					resourceState = nil
+				}
+				listStart := time.Now()
+				items, err := listChangedResources(ctx, dynamicClient, gvr, metav1.ListOptions{LabelSelector: filterOpts.ListLabelSelector(), FieldSelector: filterOpts.FieldSelector, Limit: pageSize}, resourceState, retry)
+				result.stats = append(result.stats, resourceTypeStats{gvr: gvr, listDuration: time.Since(listStart), examined: len(items), err: err})
+				stats := &result.stats[len(result.stats)-1]
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return result, ctxErr
+				}
 				if err != nil {
-					fmt.Printf("Error listing resources for GVR %s: %v\n", apiResourceList.GroupVersion, err)
+					fmt.Fprintf(os.Stderr, "Error listing resources for GVR %s: %v\n", apiResourceList.GroupVersion, err)
+					result.skippedTypes++
+					opts.progress.resourceTypeListed(gvr, resourceType.Namespaced, 0)
 					continue
 				}
-				for _, item := range resourceList.Items {
-					if pass, _ := filter.SetObject(&item).Run(filterOpts); pass {
+				for i := range items {
+					// Every namespace is listed at once, the objects of namespaces that are never reported are dropped
+					// right away, before their owners are looked up
+					if namespace := items[i].GetNamespace(); namespace != "" && !filterOpts.IncludesNamespace(namespace) {
 						continue
 					}
-					if CheckFinalizers(item.GetFinalizers(), item.GetDeletionTimestamp()) {
-						if pendingDeletionResources[item.GetNamespace()] == nil {
-							pendingDeletionResources[item.GetNamespace()] = make(map[schema.GroupVersionResource][]ResourceInfo)
+					stuck, ok := result.scanObject(&items[i], gvr, filterOpts)
+					if !ok {
+						continue
+					}
+					stats.flagged++
+					// Streamed findings are emitted right away instead of being kept until the scan completes
+					if opts.streamFinding != nil {
+						if opts.SkipOwned && owners.controllerExists(stuck.object) {
+							continue
 						}
-						finalizerInfo := ResourceInfo{
-							Name:   item.GetName(),
-							Reason: "Pending deletion waiting for finalizers",
+						if err := opts.streamFinding(newFinalizerFinding(stuck.object, gvr)); err != nil {
+							return result, err
 						}
-						pendingDeletionResources[item.GetNamespace()][gvr] = append(pendingDeletionResources[item.GetNamespace()][gvr], finalizerInfo)
+						continue
 					}
+					stuckItems = append(stuckItems, stuck)
 				}
+				opts.progress.resourceTypeListed(gvr, resourceType.Namespaced, stats.flagged)
 			}
 		}
 	}
-	return pendingDeletionResources, nil
+
+	// Children of a live controller are removed by the garbage collector once their owner is gone,
+	// so they are not stuck on their own
+	if opts.SkipOwned {
+		owned := make([]bool, len(stuckItems))
+		forEachConcurrently(len(stuckItems), opts.Concurrency, func(i int) {
+			owned[i] = owners.controllerExists(stuckItems[i].object)
+		})
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		kept := stuckItems[:0]
+		for i, stuck := range stuckItems {
+			if !owned[i] {
+				kept = append(kept, stuck)
+			}
+		}
+		stuckItems = kept
+	}
+
+	// Owner lookups need extra API calls per object, so they run in a bounded worker pool after listing
+	if opts.ShowOwners || opts.Explain || opts.OwnerTree {
+		forEachConcurrently(len(stuckItems), opts.Concurrency, func(i int) {
+			stuckItems[i].chain = owners.resolve(stuckItems[i].object)
+		})
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+	}
+	result.addStuckItems(stuckItems, opts)
+
+	if versions != nil {
+		if err := versions.save(); err != nil {
+			return result, fmt.Errorf("failed to save resourceVersion state: %w", err)
+		}
+	}
+	return result, nil
 }
 
-func getResourcesWithFinalizersPendingDeletion(clientset kubernetes.Interface, dynamicClient dynamic.Interface, filterOpts *filters.Options) (map[string]map[schema.GroupVersionResource][]ResourceInfo, error) {
+// sortedGroupVersionResources returns the resource types of a namespace ordered by resource, group and version
+func sortedGroupVersionResources(resourceTypes map[schema.GroupVersionResource][]ResourceInfo) []schema.GroupVersionResource {
+	gvrs := make([]schema.GroupVersionResource, 0, len(resourceTypes))
+	for gvr := range resourceTypes {
+		gvrs = append(gvrs, gvr)
+	}
+	sort.Slice(gvrs, func(i, j int) bool {
+		if gvrs[i].Resource != gvrs[j].Resource {
+			return gvrs[i].Resource < gvrs[j].Resource
+		}
+		return gvrs[i].String() < gvrs[j].String()
+	})
+	return gvrs
+}
+
+// resourceTypeKeys names the resource types in the response of a scan. They are named by their plural name, unless
+// resource types of several groups share it, e.g. policies, then they are qualified with their group, and with their
+// version as well when several versions of the group are listed, e.g. policies.v1.kyverno.io.
+type resourceTypeKeys map[schema.GroupVersionResource]string
+
+func newResourceTypeKeys(resources map[string]map[schema.GroupVersionResource][]ResourceInfo) resourceTypeKeys {
+	byResource := make(map[string]map[schema.GroupVersionResource]bool)
+	for _, resourceTypes := range resources {
+		for gvr := range resourceTypes {
+			if byResource[gvr.Resource] == nil {
+				byResource[gvr.Resource] = make(map[schema.GroupVersionResource]bool)
+			}
+			byResource[gvr.Resource][gvr] = true
+		}
+	}
+	keys := make(resourceTypeKeys)
+	for resource, gvrs := range byResource {
+		if len(gvrs) == 1 {
+			continue
+		}
+		groups := make(map[string]int)
+		for gvr := range gvrs {
+			groups[gvr.Group]++
+		}
+		for gvr := range gvrs {
+			switch {
+			case groups[gvr.Group] > 1:
+				keys[gvr] = strings.Join([]string{resource, gvr.Version, gvr.Group}, ".")
+			case gvr.Group != "":
+				keys[gvr] = gvr.GroupResource().String()
+			}
+		}
+	}
+	return keys
+}
+
+// key returns the name of the resource type in the response
+func (k resourceTypeKeys) key(gvr schema.GroupVersionResource) string {
+	if key, ok := k[gvr]; ok {
+		return key
+	}
+	return gvr.Resource
+}
+
+// matchesResourceType checks if a configured resource type names the resource. Resource types are given
+// by their plural name, optionally qualified with their group, e.g. certificates.cert-manager.io
+func matchesResourceType(gvr schema.GroupVersionResource, resourceType string) bool {
+	return strings.EqualFold(resourceType, gvr.Resource) || strings.EqualFold(resourceType, gvr.GroupResource().String())
+}
+
+// selectResourceTypes keeps the included resource types of the discovered resource lists, every
+// resource type when none are included, and drops the excluded ones, so they are never listed. An
+// exclusion wins over an inclusion. Included resource types the server does not serve are warned
+// about and otherwise ignored.
+func selectResourceTypes(resourceLists []*metav1.APIResourceList, resourceTypes, excludeResourceTypes []string) []*metav1.APIResourceList {
+	if len(resourceTypes) == 0 && len(excludeResourceTypes) == 0 {
+		return resourceLists
+	}
+	found := make(map[string]bool)
+	selected := discovery.FilteredBy(discovery.ResourcePredicateFunc(func(groupVersion string, r *metav1.APIResource) bool {
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			return false
+		}
+		gvr := gv.WithResource(r.Name)
+		matched := len(resourceTypes) == 0
+		for _, resourceType := range resourceTypes {
+			if matchesResourceType(gvr, resourceType) {
+				found[resourceType] = true
+				matched = true
+			}
+		}
+		for _, excludeResourceType := range excludeResourceTypes {
+			if matchesResourceType(gvr, excludeResourceType) {
+				return false
+			}
+		}
+		return matched
+	}), resourceLists)
+	for _, resourceType := range resourceTypes {
+		if !found[resourceType] {
+			fmt.Fprintf(os.Stderr, "Resource type %s is not served by the API server, skipping it\n", resourceType)
+		}
+	}
+	return selected
+}
+
+// selectListableResources drops the discovered resources a finalizer scan cannot list: the resources
+// without the list verb, and the subresources, e.g. pods/status, unless includeSubresources is set, so
+// they are not attempted and reported as failing to list
+func selectListableResources(resourceLists []*metav1.APIResourceList, includeSubresources bool) []*metav1.APIResourceList {
+	return discovery.FilteredBy(discovery.ResourcePredicateFunc(func(groupVersion string, r *metav1.APIResource) bool {
+		return slices.Contains(r.Verbs, "list") && (includeSubresources || !strings.Contains(r.Name, "/"))
+	}), resourceLists)
+}
+
+// selectAPIGroups keeps the resource lists of the included API groups, every one when none are included,
+// so the other groups are never listed. A group is included by its name or a domain suffix of it,
+// case-insensitively: cert-manager.io includes acme.cert-manager.io as well. The core group is core.
+func selectAPIGroups(resourceLists []*metav1.APIResourceList, includeGroups []string) []*metav1.APIResourceList {
+	if len(includeGroups) == 0 {
+		return resourceLists
+	}
+	selected := make([]*metav1.APIResourceList, 0, len(resourceLists))
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		group := strings.ToLower(gv.Group)
+		if group == "" {
+			group = "core"
+		}
+		for _, includeGroup := range includeGroups {
+			includeGroup = strings.ToLower(includeGroup)
+			if group == includeGroup || strings.HasSuffix(group, "."+includeGroup) {
+				selected = append(selected, resourceList)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// isAdvisoryResourceType checks if the resource type is configured as advisory only
+func isAdvisoryResourceType(gvr schema.GroupVersionResource, advisoryResourceTypes []string) bool {
+	for _, advisoryResourceType := range advisoryResourceTypes {
+		if matchesResourceType(gvr, advisoryResourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceSelection decides which namespaces the findings of a scan are reported for. The namespaces are
+// selected before the scan lists the resources of every namespace at once, so a namespace missing from
+// the selection, e.g. created while scanning, is checked against the namespace filters when it comes up
+// instead of its resources being dropped. It is not safe for concurrent use.
+type namespaceSelection struct {
+	ctx        context.Context
+	clientset  kubernetes.Interface
+	filterOpts *filters.Options
+	selected   map[string]bool
+}
+
+func newNamespaceSelection(ctx context.Context, clientset kubernetes.Interface, filterOpts *filters.Options) (*namespaceSelection, error) {
+	namespaces, err := filterOpts.Namespaces(clientset)
+	if err != nil {
+		return nil, err
+	}
+	selection := &namespaceSelection{ctx: ctx, clientset: clientset, filterOpts: filterOpts, selected: make(map[string]bool)}
+	for _, namespace := range namespaces {
+		selection.selected[namespace] = true
+	}
+	return selection, nil
+}
+
+// includes reports whether the findings of the namespace are reported, cluster scoped ones always are
+func (s *namespaceSelection) includes(namespace string) bool {
+	if namespace == "" {
+		return true
+	}
+	if selected, ok := s.selected[namespace]; ok {
+		return selected
+	}
+	object, err := s.clientset.CoreV1().Namespaces().Get(s.ctx, namespace, metav1.GetOptions{})
+	s.selected[namespace] = err == nil && s.filterOpts.SelectsNamespace(object)
+	return s.selected[namespace]
+}
+
+// terminatingNamespaces returns the namespaces in the Terminating phase and when their deletion was requested
+func terminatingNamespaces(ctx context.Context, clientset kubernetes.Interface) (map[string]time.Time, error) {
+	namespaceList, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	terminating := make(map[string]time.Time)
+	for _, namespace := range namespaceList.Items {
+		if namespace.Status.Phase != corev1.NamespaceTerminating {
+			continue
+		}
+		var deletionTime time.Time
+		if namespace.DeletionTimestamp != nil {
+			deletionTime = namespace.DeletionTimestamp.Time
+		}
+		terminating[namespace.Name] = deletionTime
+	}
+	return terminating, nil
+}
+
+// blockingNamespaceTermination keeps the stuck resources of terminating namespaces only, since a namespace
+// cannot finish terminating while it still contains them. The reason names the namespace they block.
+func blockingNamespaceTermination(pendingDeletion map[string]map[schema.GroupVersionResource][]ResourceInfo, terminating map[string]time.Time, location *time.Location) map[string]map[schema.GroupVersionResource][]ResourceInfo {
+	blocking := make(map[string]map[schema.GroupVersionResource][]ResourceInfo)
+	for namespace, resources := range pendingDeletion {
+		deletionTime, ok := terminating[namespace]
+		if !ok {
+			continue
+		}
+		blocks := fmt.Sprintf("Blocks termination of namespace %s", namespace)
+		if !deletionTime.IsZero() {
+			blocks += fmt.Sprintf(", terminating for %s since %s", duration.HumanDuration(time.Since(deletionTime)), formatTimestamp(deletionTime, location))
+		}
+		for gvr, infos := range resources {
+			for _, info := range infos {
+				info.Reason = blocks + ": " + info.Reason
+				addFinalizerResource(blocking, namespace, gvr, info)
+			}
+		}
+	}
+	return blocking
+}
+
+// clusterScopeKey is the namespace cluster scoped resources are reported under
+const clusterScopeKey = "_cluster"
+
+// reportedNamespace returns the namespace a resource is reported under, cluster scoped resources have none
+func reportedNamespace(namespace string) string {
+	if namespace == "" {
+		return clusterScopeKey
+	}
+	return namespace
+}
+
+// merge adds the resources collected by another scan
+func (r *finalizerScanResult) merge(other *finalizerScanResult) {
+	for _, resources := range []struct {
+		into, from map[string]map[schema.GroupVersionResource][]ResourceInfo
+	}{
+		{r.pendingDeletion, other.pendingDeletion},
+		{r.malformedFinalizers, other.malformedFinalizers},
+		{r.protectedStuck, other.protectedStuck},
+	} {
+		for namespace, gvrs := range resources.from {
+			for gvr, infos := range gvrs {
+				for _, info := range infos {
+					addFinalizerResource(resources.into, namespace, gvr, info)
+				}
+			}
+		}
+	}
+	for namespace, activity := range other.namespaceActivity {
+		if activity.After(r.namespaceActivity[namespace]) {
+			r.namespaceActivity[namespace] = activity
+		}
+	}
+	r.stuckItems = append(r.stuckItems, other.stuckItems...)
+	r.skippedTypes += other.skippedTypes
+	r.stats = append(r.stats, other.stats...)
+}
+
+// discoverFinalizerResources fetches the preferred API resources once per scan, both the namespaced and
+// the cluster scoped resources are scanned from them. API groups that failed discovery, e.g. an
+// unavailable aggregated API service, are logged and returned, the resources of the other groups are
+// still scanned.
+func discoverFinalizerResources(clientset kubernetes.Interface, opts Opts) ([]*metav1.APIResourceList, []schema.GroupVersion, error) {
 	// Use the discovery client to fetch API resources
-	resourceTypes, err := clientset.Discovery().ServerPreferredNamespacedResources()
+	discoveryClient := opts.DiscoveryClient
+	if discoveryClient == nil {
+		discoveryClient = clientset.Discovery()
+	}
+	resourceLists, err := discoveryClient.ServerPreferredResources()
+	var groupErr *discovery.ErrGroupDiscoveryFailed
+	if errors.As(err, &groupErr) {
+		failedGroups := make([]schema.GroupVersion, 0, len(groupErr.Groups))
+		for gv, cause := range groupErr.Groups {
+			failedGroups = append(failedGroups, gv)
+			fmt.Fprintf(os.Stderr, "Skipping API group %s, discovery failed: %v\n", gv, cause)
+		}
+		sort.Slice(failedGroups, func(i, j int) bool { return failedGroups[i].String() < failedGroups[j].String() })
+		return resourceLists, failedGroups, nil
+	}
 	if err != nil {
-		fmt.Printf("Error fetching server resources: %v\n", err)
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("Error fetching server resources: %w", err)
 	}
+	return resourceLists, nil, nil
+}
+
+func getResourcesWithFinalizersPendingDeletion(ctx context.Context, resourceLists []*metav1.APIResourceList, dynamicClient dynamic.Interface, filterOpts *filters.Options, opts Opts) (*finalizerScanResult, error) {
+	resourceTypes := discovery.FilteredBy(discovery.ResourcePredicateFunc(func(groupVersion string, r *metav1.APIResource) bool {
+		return r.Namespaced
+	}), resourceLists)
 
-	return retrievePendingDeletionResources(resourceTypes, dynamicClient, filterOpts)
+	return retrievePendingDeletionResources(ctx, resourceTypes, dynamicClient, filterOpts, opts)
+}
+
+// getClusterScopedResourcesWithFinalizersPendingDeletion scans the resources that are not namespaced,
+// e.g. PersistentVolumes, ClusterRoles or CustomResourceDefinitions
+func getClusterScopedResourcesWithFinalizersPendingDeletion(ctx context.Context, resourceLists []*metav1.APIResourceList, dynamicClient dynamic.Interface, filterOpts *filters.Options, opts Opts) (*finalizerScanResult, error) {
+	resourceTypes := discovery.FilteredBy(discovery.ResourcePredicateFunc(func(groupVersion string, r *metav1.APIResource) bool {
+		return !r.Namespaced
+	}), resourceLists)
+
+	return retrievePendingDeletionResources(ctx, resourceTypes, dynamicClient, filterOpts, opts)
+}
+
+// finalizerOutputFormats are the output formats of a finalizer scan
+var finalizerOutputFormats = []string{"table", "json", "yaml", "csv", "cloudevents", "hash", "grafana", "histogram", "ndjson", "shell", "summary", "tree"}
+
+// validateFinalizerScan checks the options a finalizer scan is configured with before anything is listed.
+// The output format is not checked when empty, for the scans returning their findings unformatted.
+func validateFinalizerScan(filterOpts *filters.Options, outputFormat string, opts Opts) error {
+	// Rejected before anything is deleted, patched or notified, the output is only formatted at the end
+	if outputFormat != "" && !slices.Contains(finalizerOutputFormats, outputFormat) {
+		return fmt.Errorf("unsupported output format: %s, expected one of %s", outputFormat, strings.Join(finalizerOutputFormats, ", "))
+	}
+	if _, err := compileStatusPaths(opts.StatusPaths); err != nil {
+		return err
+	}
+	if _, err := labels.Parse(filterOpts.ListLabelSelector()); err != nil {
+		return fmt.Errorf("invalid label selector %q: %w", filterOpts.ListLabelSelector(), err)
+	}
+	if _, err := fields.ParseSelector(filterOpts.FieldSelector); err != nil {
+		return fmt.Errorf("invalid field selector %q: %w", filterOpts.FieldSelector, err)
+	}
+	// Their deletion was already requested, only their finalizers are patched and no delete request is sent
+	if opts.GracePeriodSeconds != nil {
+		return errors.New("--grace-period is passed to delete requests, finalizer scans only patch the finalizers of resources already being deleted")
+	}
+	return validateConfirmationToken(opts)
 }
 
-func GetUnusedfinalizers(filterOpts *filters.Options, clientset kubernetes.Interface, dynamicClient *dynamic.DynamicClient, outputFormat string, opts Opts) (string, error) {
+// withFinalizerScanDefaults applies the filter defaults of finalizer scans: the DefaultSystemNamespaces,
+// whose finalizers are never meant to be touched, are excluded unless other SystemNamespaces are set
+func withFinalizerScanDefaults(filterOpts *filters.Options) {
+	if filterOpts.SystemNamespaces == nil {
+		filterOpts.SystemNamespaces = filters.DefaultSystemNamespaces
+	}
+}
+
+// scanFinalizers discovers the API resources once, and scans the namespaced resources and, unless
+// namespaces are included, the cluster scoped resources for finalizers
+func scanFinalizers(ctx context.Context, filterOpts *filters.Options, clientset kubernetes.Interface, dynamicClient dynamic.Interface, opts Opts) (*finalizerScanResult, error) {
+	resourceLists, failedGroups, err := discoverFinalizerResources(clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	if filterOpts.DanglingFinalizers {
+		// A group that failed discovery is still registered, its finalizers are not dangling
+		opts.servedGroups = servedAPIGroups(resourceLists)
+		for _, gv := range failedGroups {
+			opts.servedGroups[gv.Group] = true
+		}
+	}
+	resourceLists = selectListableResources(resourceLists, opts.IncludeSubresources)
+	resourceLists = selectAPIGroups(resourceLists, filterOpts.IncludeGroups)
+	resourceLists = selectResourceTypes(resourceLists, filterOpts.ResourceTypes, filterOpts.ExcludeResourceTypes)
+	if opts.ShowProgress {
+		opts.progress = newScanProgress(resourceLists, !filterOpts.HasIncludedNamespaces(), opts)
+	}
+	scanResult, err := getResourcesWithFinalizersPendingDeletion(ctx, resourceLists, dynamicClient, filterOpts, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process resources waiting for finalizers: %w", err)
+	}
+	// Cluster scoped resources belong to no namespace, so they are only scanned when no namespaces are included
+	if !filterOpts.HasIncludedNamespaces() {
+		clusterScanResult, err := getClusterScopedResourcesWithFinalizersPendingDeletion(ctx, resourceLists, dynamicClient, filterOpts, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process cluster scoped resources waiting for finalizers: %w", err)
+		}
+		scanResult.merge(clusterScanResult)
+	}
+	if opts.Verbose {
+		fmt.Fprint(os.Stderr, formatScanStats(scanResult.stats))
+	}
+	return scanResult, nil
+}
+
+// GetUnusedFinalizersStructured returns the resources stuck pending deletion because of their finalizers,
+// sorted by namespace, resource type and name. Cluster scoped resources have an empty namespace. The
+// resources are only reported, the delete and output options are ignored.
+func GetUnusedFinalizersStructured(ctx context.Context, filterOpts *filters.Options, clientset kubernetes.Interface, dynamicClient dynamic.Interface, opts Opts) ([]FinalizerFinding, error) {
+	if err := validateFinalizerScan(filterOpts, "", opts); err != nil {
+		return nil, err
+	}
+	withFinalizerScanDefaults(filterOpts)
+	activeSince, err := filterOpts.ActiveSinceTime()
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := newNamespaceSelection(ctx, clientset, filterOpts)
+	if err != nil {
+		return nil, err
+	}
+	scanResult, err := scanFinalizers(ctx, filterOpts, clientset, dynamicClient, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return reportedFindings(scanResult, namespaces, activeSince), nil
+}
+
+// reportedFindings returns the findings of the stuck objects in the scanned namespaces, sorted by
+// namespace, resource type and name
+func reportedFindings(scanResult *finalizerScanResult, namespaces *namespaceSelection, activeSince time.Time) []FinalizerFinding {
+	findings := make([]FinalizerFinding, 0, len(scanResult.stuckItems))
+	for _, stuck := range scanResult.stuckItems {
+		namespace := stuck.object.GetNamespace()
+		if !namespaces.includes(namespace) {
+			continue
+		}
+		if !activeSince.IsZero() && scanResult.namespaceActivity[namespace].Before(activeSince) {
+			continue
+		}
+		findings = append(findings, newFinalizerFinding(stuck.object, stuck.gvr))
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Namespace != findings[j].Namespace {
+			return findings[i].Namespace < findings[j].Namespace
+		}
+		if gvrI, gvrJ := findings[i].GroupVersionResource.String(), findings[j].GroupVersionResource.String(); gvrI != gvrJ {
+			return gvrI < gvrJ
+		}
+		return findings[i].Name < findings[j].Name
+	})
+	return findings
+}
+
+// StreamUnusedFinalizers writes the resources stuck pending deletion because of their finalizers to w as
+// they are found, one FinalizerFinding json object per line, instead of keeping every finding in memory
+// until the scan completes. The output is also written to OutputFile when set. Findings are in the order
+// they are listed, and the options needing the complete scan, like ActiveSince, are not supported.
+func StreamUnusedFinalizers(ctx context.Context, filterOpts *filters.Options, clientset kubernetes.Interface, dynamicClient dynamic.Interface, w io.Writer, opts Opts) error {
+	if err := validateFinalizerScan(filterOpts, "ndjson", opts); err != nil {
+		return err
+	}
+	withFinalizerScanDefaults(filterOpts)
+	if filterOpts.ActiveSince != "" {
+		return fmt.Errorf("--active-since needs the complete scan and cannot be used with the ndjson output")
+	}
+	if len(opts.Unredact) > 0 {
+		return fmt.Errorf("--unredact reports a table and cannot be used with the ndjson output")
+	}
+	if err := validateRedaction(opts); err != nil {
+		return err
+	}
+	opts, err := withRedactionKey(opts)
+	if err != nil {
+		return err
+	}
+	if opts.OutputFile != "" {
+		file, err := createOutputFile(opts.OutputFile, time.Now())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = io.MultiWriter(w, file)
+	}
+
+	namespaces, err := newNamespaceSelection(ctx, clientset, filterOpts)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	var count int
+	opts.streamFinding = func(finding FinalizerFinding) error {
+		if !namespaces.includes(finding.Namespace) {
+			return nil
+		}
+		if opts.FindingFilter != nil && !opts.FindingFilter(finding) {
+			return nil
+		}
+		// Hooks are run by whoever runs the scan and keep the real name
+		if err := encoder.Encode(redactFindings([]FinalizerFinding{finding}, opts)[0]); err != nil {
+			return err
+		}
+		count++
+		return runFindingHook(ctx, finding, opts)
+	}
+	if _, err := scanFinalizers(ctx, filterOpts, clientset, dynamicClient, opts); err != nil {
+		return err
+	}
+	if opts.FailOnFindings && count > 0 {
+		return &FindingsError{Count: count}
+	}
+	return nil
+}
+
+// GetUnusedfinalizers reports the resources stuck pending deletion because of their finalizers in the
+// output format, and writes the output to OutputFile as well when set
+func GetUnusedfinalizers(ctx context.Context, filterOpts *filters.Options, clientset kubernetes.Interface, dynamicClient dynamic.Interface, outputFormat string, opts Opts) (string, error) {
+	// The whole output is returned at once here, use StreamUnusedFinalizers to write it as it is found
+	if outputFormat == "ndjson" {
+		var output bytes.Buffer
+		err := StreamUnusedFinalizers(ctx, filterOpts, clientset, dynamicClient, &output, opts)
+		return output.String(), err
+	}
+	// Errors returned along with the output leave it complete, it is written like any other
+	output, err := getUnusedFinalizers(ctx, filterOpts, clientset, dynamicClient, outputFormat, opts)
+	if !HasOutput(err) || opts.OutputFile == "" {
+		return output, err
+	}
+	if err := writeOutputFile(opts.OutputFile, output, time.Now()); err != nil {
+		return "", err
+	}
+	return output, err
+}
+
+func getUnusedFinalizers(ctx context.Context, filterOpts *filters.Options, clientset kubernetes.Interface, dynamicClient dynamic.Interface, outputFormat string, opts Opts) (string, error) {
+	location, err := timezoneLocation(opts.Timezone)
+	if err != nil {
+		return "", err
+	}
+	if err := validateFinalizerScan(filterOpts, outputFormat, opts); err != nil {
+		return "", err
+	}
+	withFinalizerScanDefaults(filterOpts)
+	marking := opts.MarkLabel != "" || opts.MarkAnnotation != ""
+	if marking && (opts.DeleteFlag || opts.RemoveFinalizers) {
+		return "", fmt.Errorf("marking stuck resources is an alternative to deleting them, --mark-label and --mark-annotation cannot be used with --delete or --remove-finalizers")
+	}
+	if opts.DeleteFlag && opts.RemoveFinalizers {
+		return "", fmt.Errorf("--remove-finalizers is an alternative to --delete, they cannot be used together")
+	}
+	if opts.Explain {
+		opts.ShowReason = true
+	}
+	if outputFormat == "tree" {
+		opts.OwnerTree = true
+	}
+	if err := validateRedaction(opts); err != nil {
+		return "", err
+	}
+	if opts, err = withRedactionKey(opts); err != nil {
+		return "", err
+	}
+	opts = withDeleteThrottle(opts)
 	var outputBuffer bytes.Buffer
-	namespaces := filterOpts.Namespaces(clientset)
+	namespaces, err := newNamespaceSelection(ctx, clientset, filterOpts)
+	if err != nil {
+		return "", err
+	}
 	response := make(map[string]map[string][]ResourceInfo)
-	pendingDeletionDiffs, err := getResourcesWithFinalizersPendingDeletion(clientset, dynamicClient, filterOpts)
+	scanResult, err := scanFinalizers(ctx, filterOpts, clientset, dynamicClient, opts)
+	if err != nil {
+		return "", err
+	}
+
+	// The format check is a diagnostic for controller bugs and reports its anomalies instead of the
+	// resources pending deletion. Nothing is ever deleted in this mode.
+	pendingDeletionDiffs := scanResult.pendingDeletion
+	if opts.CheckFinalizerFormat {
+		pendingDeletionDiffs = scanResult.malformedFinalizers
+	} else if opts.TerminatingNamespaces {
+		terminating, err := terminatingNamespaces(ctx, clientset)
+		if err != nil {
+			return "", fmt.Errorf("failed to list terminating namespaces: %w", err)
+		}
+		pendingDeletionDiffs = blockingNamespaceTermination(pendingDeletionDiffs, terminating, location)
+	}
 
+	activeSince, err := filterOpts.ActiveSinceTime()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to process resources waiting for finalizers: %v\n", err)
+		return "", err
+	}
+
+	// Deletions are verified once every deletion was requested, giving the API server time to process them
+	var deletionRuns []deletionRun
+	// With separate delete results the report keeps what was found, and the actions are reported on their own
+	var actions []DeletionAction
+	// Advisory resource types are only reported for information, and never deleted
+	advisory := make(map[string]map[string][]ResourceInfo)
+	// Resource types sharing their name with resource types of other groups are qualified, so none is overwritten
+	keys := newResourceTypeKeys(pendingDeletionDiffs)
+
+	// Mapping redacted names back only reports the resources they redact, nothing else is done
+	if len(opts.Unredact) > 0 {
+		findings := reportedFindings(scanResult, namespaces, activeSince)
+		return formatUnredacted(opts.Unredact, Unredact(opts.RedactionKey, opts.Unredact, findings)), nil
+	}
+
+	// Hooks are invoked before anything is deleted, so a fatal hook failure stops the run before it changes anything
+	if !opts.CheckFinalizerFormat {
+		if err := runFindingHooks(ctx, reportedFindings(scanResult, namespaces, activeSince), opts); err != nil {
+			return "", err
+		}
 	}
 
-	allDiffs := make(map[string][]ResourceInfo)
+	// A batch confirmation covers every resource about to be deleted upfront, instead of prompting for each
+	var batchDeclined bool
+	if (opts.DeleteFlag || opts.RemoveFinalizers) && opts.BatchConfirmation && !opts.NoInteractive && !opts.CheckFinalizerFormat {
+		var candidates []deletionCandidate
+		for _, namespace := range sortedKeys(pendingDeletionDiffs) {
+			if !activeSince.IsZero() && scanResult.namespaceActivity[namespace].Before(activeSince) {
+				continue
+			}
+			if !namespaces.includes(namespace) {
+				continue
+			}
+			for _, gvr := range sortedGroupVersionResources(pendingDeletionDiffs[namespace]) {
+				if isAdvisoryResourceType(gvr, opts.AdvisoryResourceTypes) {
+					continue
+				}
+				for _, info := range sortedByName(pendingDeletionDiffs[namespace][gvr]) {
+					candidates = append(candidates, deletionCandidate{namespace: reportedNamespace(namespace), resource: keys.key(gvr), name: info.Name})
+				}
+			}
+		}
+		action := "delete"
+		if opts.RemoveFinalizers {
+			action = "remove the finalizers of"
+		}
+		if len(candidates) > 0 {
+			if confirmBatchDeletion(action, candidates, opts) {
+				opts.NoInteractive = true
+			} else {
+				batchDeclined = true
+			}
+		}
+	}
 
-	for namespace, resourceType := range pendingDeletionDiffs {
-		if slices.Contains(namespaces, namespace) {
-			for gvr, resourceDiff := range resourceType {
-				if opts.DeleteFlag {
-					if resourceDiff, err = DeleteResourceWithFinalizer(resourceDiff, dynamicClient, namespace, gvr, opts.NoInteractive); err != nil {
-						fmt.Fprintf(os.Stderr, "Failed to delete objects waiting for Finalizers %s in namespace %s: %v\n", resourceDiff, namespace, err)
+	// Namespaces and resource types are processed in order, so prompts and output are the same between runs.
+	// They are not processed concurrently: every namespace was already listed at once, what remains per
+	// namespace are the deletions, which prompt for confirmation and are paced by DeleteRate.
+	for _, namespace := range sortedKeys(pendingDeletionDiffs) {
+		resourceType := pendingDeletionDiffs[namespace]
+		if !activeSince.IsZero() && scanResult.namespaceActivity[namespace].Before(activeSince) {
+			continue
+		}
+		if namespaces.includes(namespace) {
+			reported := reportedNamespace(namespace)
+			allDiffs := make(map[string][]ResourceInfo)
+			for _, gvr := range sortedGroupVersionResources(resourceType) {
+				resourceDiff := sortedByName(resourceType[gvr])
+				if isAdvisoryResourceType(gvr, opts.AdvisoryResourceTypes) {
+					if advisory[reported] == nil {
+						advisory[reported] = make(map[string][]ResourceInfo)
+					}
+					advisory[reported][keys.key(gvr)] = redactResourceInfos(resourceDiff, opts)
+					if (opts.DeleteFlag || opts.RemoveFinalizers) && opts.SeparateDeleteResults && !opts.CheckFinalizerFormat {
+						for _, info := range resourceDiff {
+							actions = append(actions, DeletionAction{Namespace: reported, Resource: keys.key(gvr), Name: info.Name, Action: deletionActionAdvisory})
+						}
 					}
+					continue
+				}
+				if (opts.DeleteFlag || opts.RemoveFinalizers) && !opts.CheckFinalizerFormat {
+					requested := resourceDiff
+					var deleteErr error
+					switch {
+					case batchDeclined:
+						resourceDiff = declinedResources(resourceDiff, opts)
+					case opts.RemoveFinalizers:
+						resourceDiff, deleteErr = RemoveFinalizers(ctx, resourceDiff, dynamicClient, namespace, gvr, opts)
+					default:
+						resourceDiff, deleteErr = DeleteResourceWithFinalizer(ctx, resourceDiff, dynamicClient, namespace, gvr, filterOpts, opts)
+					}
+					if deleteErr != nil {
+						fmt.Fprintf(os.Stderr, "Failed to delete objects waiting for Finalizers %s in namespace %s: %v\n", resourceDiff, namespace, deleteErr)
+					}
+					// Nothing is gone after a dry run, there is no deletion to verify
+					if (opts.VerifyDeletion || opts.WaitForDeletion > 0) && !opts.DryRun {
+						deletionRuns = append(deletionRuns, deletionRun{namespace, gvr, deletionAttempts(requested, resourceDiff)})
+					}
+					if opts.SeparateDeleteResults {
+						actions = append(actions, deletionActions(reported, keys.key(gvr), requested, resourceDiff)...)
+						resourceDiff = requested
+					}
+				}
+				allDiffs[keys.key(gvr)] = redactResourceInfos(resourceDiff, opts)
+			}
+
+			if opts.GroupBy != "resource" {
+				output := formatOutputForNamespace(reported, allDiffs, opts)
+				outputBuffer.WriteString(output)
+			}
+
+			response[reported] = allDiffs
+		}
+	}
+
+	// Resource types span namespaces, so their tables are only complete once every namespace is processed
+	if opts.GroupBy == "resource" {
+		outputBuffer.WriteString(formatSortedOutput(response, opts))
+	}
+
+	if opts.SlackSummaryWebhookURL != "" && !opts.CheckFinalizerFormat {
+		notifySlackSummary(redactFindings(reportedFindings(scanResult, namespaces, activeSince), opts), opts)
+	}
+
+	if marking && !opts.CheckFinalizerFormat {
+		markStuckResources(ctx, stuckItemsFor(scanResult.stuckItems, response, keys), dynamicClient, opts)
+	}
+
+	if outputFormat == "table" && len(advisory) > 0 {
+		outputBuffer.WriteString("Advisory only, these resource types use finalizers as a long-lived protocol:\n")
+		outputBuffer.WriteString(formatSortedOutput(advisory, opts))
+	}
+
+	if outputFormat == "table" && opts.ShowProtectedStuck && !opts.CheckFinalizerFormat {
+		protected := make(map[string]map[string][]ResourceInfo)
+		protectedKeys := newResourceTypeKeys(scanResult.protectedStuck)
+		for namespace, resourceTypes := range scanResult.protectedStuck {
+			if namespaces.includes(namespace) {
+				protected[reportedNamespace(namespace)] = make(map[string][]ResourceInfo)
+				for gvr, infos := range resourceTypes {
+					protected[reportedNamespace(namespace)][protectedKeys.key(gvr)] = redactResourceInfos(infos, opts)
 				}
-				allDiffs[gvr.Resource] = resourceDiff
 			}
+		}
+		if len(protected) > 0 {
+			outputBuffer.WriteString("Protected but stuck, these resources are marked as used and were not reported:\n")
+			outputBuffer.WriteString(formatSortedOutput(protected, opts))
+		}
+	}
+
+	if report := formatDeletionActions(actions); report != "" {
+		if outputFormat == "table" {
+			outputBuffer.WriteString(report)
+		} else {
+			fmt.Fprint(os.Stderr, report)
+		}
+	}
+
+	var remaining []DeletionVerification
+	if opts.WaitForDeletion > 0 {
+		remaining = waitForDeletions(ctx, dynamicClient, deletionRuns, opts.WaitForDeletion, waitForDeletionInterval)
+		if report := formatRemainingDeletions(remaining, opts.WaitForDeletion); report != "" {
+			if outputFormat == "table" {
+				outputBuffer.WriteString(report)
+			} else {
+				fmt.Fprint(os.Stderr, report)
+			}
+		}
+	}
 
-			output := formatOutputForNamespace(namespace, allDiffs, opts)
-			outputBuffer.WriteString(output)
+	var verifications []DeletionVerification
+	if opts.VerifyDeletion {
+		for _, run := range deletionRuns {
+			verifications = append(verifications, verifyDeletions(ctx, dynamicClient, run.namespace, run.gvr, run.attempts)...)
+		}
+	}
+	if report := formatDeletionReport(verifications); report != "" {
+		// Keep machine readable output parseable by reporting to stderr for other formats
+		if outputFormat == "table" {
+			outputBuffer.WriteString(report)
+		} else {
+			fmt.Fprint(os.Stderr, report)
+		}
+	}
 
-			response[namespace] = allDiffs
+	if opts.MetricsTextfile != "" {
+		if err := WriteMetricsTextfile(opts.MetricsTextfile, response); err != nil {
+			return "", err
 		}
 	}
 
-	jsonResponse, err := json.MarshalIndent(response, "", "  ")
+	var unusedFinalizers string
+	switch outputFormat {
+	case "tree":
+		scannedNamespaces := make(map[string]bool, len(response))
+		for namespace := range response {
+			scannedNamespaces[namespace] = true
+		}
+		unusedFinalizers = formatOwnerTrees(scanResult.stuckItems, scannedNamespaces)
+	case "grafana":
+		objectLabels := make(map[string]map[string]string)
+		for _, stuck := range scanResult.stuckItems {
+			objectLabels[reportedNamespace(stuck.object.GetNamespace())+"/"+keys.key(stuck.gvr)+"/"+redactedName(stuck.object.GetName(), opts)] = stuck.object.GetLabels()
+		}
+		unusedFinalizers, err = formatGrafanaTable(response, opts.GrafanaLabelColumns, objectLabels)
+	case "cloudevents":
+		unusedFinalizers, err = formatCloudEvents(response, opts)
+	case "hash":
+		unusedFinalizers = ResultHash(response)
+	case "histogram":
+		unusedFinalizers = formatResourceTypeHistogram(response)
+	case "summary":
+		unusedFinalizers = NewFinalizerReport(redactFindings(reportedFindings(scanResult, namespaces, activeSince), opts)).SummaryTable()
+	case "shell":
+		unusedFinalizers = formatShellSummary(response, advisory, scanResult.skippedTypes)
+	default:
+		if opts.ShowHash && outputFormat == "table" {
+			outputBuffer = *bytes.NewBufferString(fmt.Sprintf("Scan result hash: %s\n%s", ResultHash(response), outputBuffer.String()))
+		}
+
+		// Json and yaml follow the orientation of the table, csv keeps its namespace column first either way
+		jsonResources := response
+		if opts.GroupBy == "resource" && (outputFormat == "json" || outputFormat == "yaml") {
+			jsonResources = transposeResources(response)
+		}
+		jsonResponse, err := json.MarshalIndent(jsonResources, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		unusedFinalizers, err = unusedResourceFormatter(outputFormat, outputBuffer, opts, jsonResponse)
+		if err != nil {
+			return "", err
+		}
+	}
 	if err != nil {
 		return "", err
 	}
 
-	unusedFinalizers, err := unusedResourceFormatter(outputFormat, outputBuffer, opts, jsonResponse)
-	if err != nil {
-		fmt.Printf("err: %v\n", err)
+	// The output is complete, these errors are returned along with it
+	var errs []error
+	if len(remaining) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %d resources still present after waiting %s", ErrDeletionIncomplete, len(remaining), opts.WaitForDeletion))
+	}
+	if count := countResources(response); opts.FailOnFindings && count > 0 {
+		errs = append(errs, &FindingsError{Count: count})
 	}
+	return unusedFinalizers, errors.Join(errs...)
+}
 
-	return unusedFinalizers, nil
+// countResources returns the number of resources in the response of a scan
+func countResources(response map[string]map[string][]ResourceInfo) int {
+	var count int
+	for _, resourceTypes := range response {
+		for _, infos := range resourceTypes {
+			count += len(infos)
+		}
+	}
+	return count
 }
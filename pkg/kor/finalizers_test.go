@@ -1,13 +1,25 @@
 package kor
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
 	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/utils/strings/slices"
 
 	"github.com/yonahd/kor/pkg/filters"
@@ -18,17 +30,21 @@ func TestCheckFinalizers(t *testing.T) {
 		name              string
 		finalizers        []string
 		deletionTimestamp *metav1.Time
+		minCount          int
 		expectedResult    bool
 	}{
-		{"EmptyFinalizersAndNilDeletionTimestamp", []string{}, nil, false},
-		{"NonEmptyFinalizersAndNilDeletionTimestamp", []string{"finalizer1", "finalizer2"}, nil, false},
-		{"EmptyFinalizersAndDeletionTimestamp", []string{}, &metav1.Time{}, false},
-		{"NonEmptyFinalizersAndDeletionTimestamp", []string{"finalizer1", "finalizer2"}, &metav1.Time{}, true},
+		{"EmptyFinalizersAndNilDeletionTimestamp", []string{}, nil, 1, false},
+		{"NonEmptyFinalizersAndNilDeletionTimestamp", []string{"finalizer1", "finalizer2"}, nil, 1, false},
+		{"EmptyFinalizersAndDeletionTimestamp", []string{}, &metav1.Time{}, 1, false},
+		{"NonEmptyFinalizersAndDeletionTimestamp", []string{"finalizer1", "finalizer2"}, &metav1.Time{}, 1, true},
+		{"EmptyFinalizersAndZeroMinCount", []string{}, &metav1.Time{}, 0, false},
+		{"FinalizersAtMinCount", []string{"finalizer1", "finalizer2"}, &metav1.Time{}, 2, true},
+		{"FinalizersBelowMinCount", []string{"finalizer1", "finalizer2"}, &metav1.Time{}, 3, false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := CheckFinalizers(tt.finalizers, tt.deletionTimestamp)
+			result := CheckFinalizers(tt.finalizers, tt.deletionTimestamp, tt.minCount)
 			if result != tt.expectedResult {
 				t.Errorf("Expected result %v, but got %v", tt.expectedResult, result)
 			}
@@ -36,6 +52,132 @@ func TestCheckFinalizers(t *testing.T) {
 	}
 }
 
+func TestIsStuckFinalizer(t *testing.T) {
+	newObject := func(finalizers []string, deleted bool, labels map[string]string) *unstructured.Unstructured {
+		obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "test-resource")
+		obj.SetFinalizers(finalizers)
+		obj.SetLabels(labels)
+		obj.SetCreationTimestamp(metav1.Time{Time: time.Now().Add(-time.Hour)})
+		if deleted {
+			obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		}
+		return obj
+	}
+	longStuck := newObject([]string{"example.com/cleanup"}, true, nil)
+	longStuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now().Add(-2 * time.Hour)})
+	terminating := newObject([]string{"example.com/cleanup"}, true, nil)
+	if err := unstructured.SetNestedField(terminating.Object, "Terminating", "status", "phase"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		object     *unstructured.Unstructured
+		filterOpts *filters.Options
+		expected   bool
+	}{
+		{"Stuck", newObject([]string{"example.com/cleanup"}, true, nil), &filters.Options{}, true},
+		{"NoFinalizers", newObject(nil, true, nil), &filters.Options{}, false},
+		{"NotDeleted", newObject([]string{"example.com/cleanup"}, false, nil), &filters.Options{}, false},
+		{"UsedLabel", newObject([]string{"example.com/cleanup"}, true, UsedLabels), &filters.Options{}, false},
+		{"ExcludedLabel", newObject([]string{"example.com/cleanup"}, true, map[string]string{"app": "test"}), &filters.Options{ExcludeLabels: []string{"app=test"}}, false},
+		{"OutsideAgeRange", newObject([]string{"example.com/cleanup"}, true, nil), &filters.Options{OlderThan: "2h"}, false},
+		{"StuckForLessThanMinimum", newObject([]string{"example.com/cleanup"}, true, nil), &filters.Options{MinStuckDuration: "1h"}, false},
+		{"StuckForMoreThanMinimum", longStuck, &filters.Options{MinStuckDuration: "1h"}, true},
+		{"FewerFinalizersThanMinimum", newObject([]string{"example.com/cleanup"}, true, nil), &filters.Options{MinFinalizerCount: 2}, false},
+		{"MatchingFinalizer", newObject([]string{"example.com/backup", "mycompany.io/cleanup"}, true, nil), &filters.Options{FinalizerMatch: []string{"mycompany.io/cleanup"}}, true},
+		{"MatchingFinalizerGlob", newObject([]string{"mycompany.io/cleanup"}, true, nil), &filters.Options{FinalizerMatch: []string{"other.io/*", "mycompany.io/*"}}, true},
+		{"NoMatchingFinalizer", newObject([]string{"example.com/cleanup"}, true, nil), &filters.Options{FinalizerMatch: []string{"mycompany.io/*"}}, false},
+		{"AsManyFinalizersAsMinimum", newObject([]string{"example.com/cleanup", "example.com/backup"}, true, nil), &filters.Options{MinFinalizerCount: 2}, true},
+		{"MatchingStatus", terminating, &filters.Options{StatusMatchPath: ".status.phase", StatusMatchValue: "Terminating"}, true},
+		{"OtherStatus", terminating, &filters.Options{StatusMatchPath: ".status.phase", StatusMatchValue: "Bound"}, false},
+		{"MissingStatus", newObject([]string{"example.com/cleanup"}, true, nil), &filters.Options{StatusMatchPath: ".status.phase", StatusMatchValue: "Terminating"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stuck, reason := IsStuckFinalizer(tt.object, tt.filterOpts)
+			if stuck != tt.expected {
+				t.Errorf("Expected stuck %v, got %v (%s)", tt.expected, stuck, reason)
+			}
+			if reason == "" {
+				t.Errorf("Expected a reason")
+			}
+		})
+	}
+}
+
+func TestExplainFinding(t *testing.T) {
+	obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "test-resource")
+	obj.SetFinalizers([]string{"example.com/cleanup"})
+	deletionTime := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+	obj.SetDeletionTimestamp(&metav1.Time{Time: deletionTime})
+	chain := ownerChain{owners: []string{"Deployment/foo"}, partial: "Deployment/foo is missing"}
+
+	expected := "Has finalizer example.com/cleanup, deletion requested 3d ago (" + deletionTime.UTC().Format(displayTimestampLayout) + "), owned by Deployment/foo (Deployment/foo is missing)"
+	if explanation := explainFinding(obj, chain, time.UTC); explanation != expected {
+		t.Errorf("Expected explanation %q, got %q", expected, explanation)
+	}
+
+	location := time.FixedZone("TEST", 2*60*60)
+	obj.SetFinalizers([]string{"example.com/cleanup", "example.com/backup"})
+	expected = "Has finalizers example.com/cleanup, example.com/backup, deletion requested 3d ago (" + deletionTime.In(location).Format(displayTimestampLayout) + ")"
+	if explanation := explainFinding(obj, ownerChain{}, location); explanation != expected {
+		t.Errorf("Expected explanation %q, got %q", expected, explanation)
+	}
+}
+
+func TestCheckFinalizerFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		finalizer string
+		malformed bool
+	}{
+		{"DomainQualified", "example.com/cleanup", false},
+		{"StandardForegroundDeletion", "foregroundDeletion", false},
+		{"StandardKubernetes", "kubernetes", false},
+		{"MissingDomain", "cleanup", true},
+		{"TrailingWhitespace", "example.com/cleanup ", true},
+		{"InnerWhitespace", "example.com/clean up", true},
+		{"UppercaseDomain", "Example.com/cleanup", true},
+		{"EmptyName", "example.com/", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anomaly := CheckFinalizerFormat(tt.finalizer)
+			if (anomaly != "") != tt.malformed {
+				t.Errorf("Expected malformed %v for %q, got anomaly %q", tt.malformed, tt.finalizer, anomaly)
+			}
+		})
+	}
+}
+
+func TestFindingSeverity(t *testing.T) {
+	bands := Opts{SeverityWarn: time.Hour, SeverityCrit: 24 * time.Hour}
+	tests := []struct {
+		name     string
+		stuckFor time.Duration
+		opts     Opts
+		expected string
+	}{
+		{"NoBands", 48 * time.Hour, Opts{}, ""},
+		{"BelowWarn", 30 * time.Minute, bands, "info"},
+		{"AtWarn", time.Hour, bands, "warn"},
+		{"AtCrit", 24 * time.Hour, bands, "crit"},
+		{"CritOnly", 2 * time.Hour, Opts{SeverityCrit: time.Hour}, "crit"},
+		{"WarnOnly", 48 * time.Hour, Opts{SeverityWarn: time.Hour}, "warn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if severity := FindingSeverity(tt.stuckFor, tt.opts); severity != tt.expected {
+				t.Errorf("Expected severity %q, got %q", tt.expected, severity)
+			}
+		})
+	}
+}
+
 func TestRetrievePendingDeletionResources(t *testing.T) {
 	scheme := runtime.NewScheme()
 
@@ -87,16 +229,27 @@ func TestRetrievePendingDeletionResources(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			result, err := retrievePendingDeletionResources(test.apiResourceLists, dynamicClient, &filters.Options{})
+			result, err := retrievePendingDeletionResources(context.TODO(), test.apiResourceLists, dynamicClient, &filters.Options{}, Opts{})
 			if (err != nil) != test.expectedError {
 				t.Errorf("Expected error: %v, Got: %v", test.expectedError, err)
 			}
-			if deletedResources, ok := result[testNamespace][gvr.GroupVersion().WithResource("testresources")]; ok {
+			if deletedResources, ok := result.pendingDeletion[testNamespace][gvr.GroupVersion().WithResource("testresources")]; ok {
 				deletedResourceNames := extractNames(deletedResources)
 				if !slices.Equal(deletedResourceNames, test.expectedResult) {
 					t.Errorf("Expected result: %v, Got: %v", test.expectedResult, deletedResources)
 				}
 			}
+			if test.expectedError {
+				return
+			}
+			// "test" and "test2" have no domain prefix and are reported as malformed
+			malformed, ok := result.malformedFinalizers[testNamespace][gvr]
+			if !ok {
+				t.Fatalf("Expected malformed finalizers for %s in namespace %s, Got: %v", gvr.Resource, testNamespace, result.malformedFinalizers)
+			}
+			if len(malformed) != 2 {
+				t.Errorf("Expected 2 malformed finalizers, Got: %v", malformed)
+			}
 		})
 	}
 }
@@ -108,3 +261,899 @@ func extractNames(resources []ResourceInfo) []string {
 	}
 	return names
 }
+
+func TestLastActivity(t *testing.T) {
+	// Unstructured objects store timestamps with second precision
+	now := time.Now().Truncate(time.Second)
+	created := now.Add(-48 * time.Hour)
+	updated := now.Add(-2 * time.Hour)
+	deleted := now.Add(-1 * time.Hour)
+
+	obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "test-resource")
+	obj.SetCreationTimestamp(metav1.Time{Time: created})
+	if activity := lastActivity(obj); !activity.Equal(created) {
+		t.Errorf("Expected creation time %v, got %v", created, activity)
+	}
+
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "test", Time: &metav1.Time{Time: updated}}})
+	if activity := lastActivity(obj); !activity.Equal(updated) {
+		t.Errorf("Expected managed fields time %v, got %v", updated, activity)
+	}
+
+	obj.SetDeletionTimestamp(&metav1.Time{Time: deleted})
+	if activity := lastActivity(obj); !activity.Equal(deleted) {
+		t.Errorf("Expected deletion time %v, got %v", deleted, activity)
+	}
+}
+
+func TestBlockingNamespaceTermination(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "terminating", DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+		},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "active"},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+	)
+	terminating, err := terminatingNamespaces(context.TODO(), clientset)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := terminating["terminating"]; !ok || len(terminating) != 1 {
+		t.Fatalf("Expected only the terminating namespace, got %v", terminating)
+	}
+
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	pendingDeletion := map[string]map[schema.GroupVersionResource][]ResourceInfo{
+		"terminating": {gvr: {{Name: "blocker", Reason: "Pending deletion waiting for finalizers"}}},
+		"active":      {gvr: {{Name: "stuck", Reason: "Pending deletion waiting for finalizers"}}},
+	}
+	blocking := blockingNamespaceTermination(pendingDeletion, terminating, time.UTC)
+	if len(blocking) != 1 {
+		t.Fatalf("Expected blockers of the terminating namespace only, got %v", blocking)
+	}
+	infos := blocking["terminating"][gvr]
+	if len(infos) != 1 || infos[0].Name != "blocker" {
+		t.Fatalf("Expected the blocker, got %v", infos)
+	}
+	if !strings.HasPrefix(infos[0].Reason, "Blocks termination of namespace terminating, terminating for 120m") {
+		t.Errorf("Unexpected reason %q", infos[0].Reason)
+	}
+}
+
+func TestIsAdvisoryResourceType(t *testing.T) {
+	advisoryResourceTypes := []string{"certificates.cert-manager.io", "VolumeSnapshots"}
+
+	tests := []struct {
+		name     string
+		gvr      schema.GroupVersionResource
+		expected bool
+	}{
+		{"GroupQualified", schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}, true},
+		{"OtherGroup", schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "certificates"}, false},
+		{"PluralNameCaseInsensitive", schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}, true},
+		{"NotAdvisory", schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if advisory := isAdvisoryResourceType(test.gvr, advisoryResourceTypes); advisory != test.expected {
+				t.Errorf("Expected advisory %v for %s, got %v", test.expected, test.gvr, advisory)
+			}
+		})
+	}
+}
+
+func TestFindingFilter(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	var stuckItems []stuckItem
+	for _, name := range []string{"keep", "drop"} {
+		obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, name)
+		obj.SetFinalizers([]string{"example.com/cleanup"})
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		stuckItems = append(stuckItems, stuckItem{object: obj, gvr: gvr})
+	}
+
+	var seen []FinalizerFinding
+	result := newFinalizerScanResult()
+	result.addStuckItems(stuckItems, Opts{FindingFilter: func(finding FinalizerFinding) bool {
+		seen = append(seen, finding)
+		return finding.Name != "drop"
+	}})
+
+	if len(seen) != 2 || seen[0].GroupVersionResource != gvr || seen[0].Finalizers[0] != "example.com/cleanup" || seen[0].DeletionTimestamp == nil {
+		t.Errorf("Expected the filter to see every finding with its details, got %+v", seen)
+	}
+	infos := result.pendingDeletion[testNamespace][gvr]
+	if len(infos) != 1 || infos[0].Name != "keep" {
+		t.Errorf("Expected only the kept finding, got %v", infos)
+	}
+	if len(result.stuckItems) != 1 {
+		t.Errorf("Expected the dropped finding to be left out of the stuck items, got %d", len(result.stuckItems))
+	}
+}
+
+func TestScanObjectProtectedStuck(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	protected := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "protected")
+	protected.SetLabels(map[string]string{"kor/used": "true"})
+	protected.SetFinalizers([]string{"example.com/cleanup"})
+	protected.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	used := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "used")
+	used.SetLabels(map[string]string{"kor/used": "true"})
+	used.SetFinalizers([]string{"example.com/cleanup"})
+
+	result := newFinalizerScanResult()
+	for _, obj := range []*unstructured.Unstructured{protected, used} {
+		if _, stuck := result.scanObject(obj, gvr, &filters.Options{}); stuck {
+			t.Errorf("Expected %s marked as used not to be reported as stuck", obj.GetName())
+		}
+	}
+
+	infos := result.protectedStuck[testNamespace][gvr]
+	if len(infos) != 1 || infos[0].Name != "protected" {
+		t.Fatalf("Expected only the protected object pending deletion to be kept, got %v", infos)
+	}
+	if expected := "Marked as used with the kor/used label, waiting for example.com/cleanup"; infos[0].Reason != expected {
+		t.Errorf("Expected reason %q, got %q", expected, infos[0].Reason)
+	}
+
+	// The reason names the key and the form of the mark that matched
+	retained := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "retained")
+	retained.SetAnnotations(map[string]string{"ops.example.com/retain": "keep"})
+	retained.SetFinalizers([]string{"example.com/cleanup"})
+	retained.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	filterOpts := &filters.Options{UsedLabelKey: "ops.example.com/retain", UsedLabelValues: []string{"keep"}}
+	if stuck, reason := IsStuckFinalizer(retained, filterOpts); stuck || reason != "Marked as used with the ops.example.com/retain annotation" {
+		t.Errorf("Expected the annotation to mark the object as used, got %v %q", stuck, reason)
+	}
+	result = newFinalizerScanResult()
+	result.scanObject(retained, gvr, filterOpts)
+	if infos := result.protectedStuck[testNamespace][gvr]; len(infos) != 1 || infos[0].Reason != "Marked as used with the ops.example.com/retain annotation, waiting for example.com/cleanup" {
+		t.Errorf("Unexpected protected stuck objects %v", infos)
+	}
+}
+
+type failingDiscovery struct {
+	discovery.DiscoveryInterface
+}
+
+func (failingDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return nil, errors.New("the server is currently unable to handle the request")
+}
+
+func TestDiscoverFinalizerResourcesError(t *testing.T) {
+	_, _, err := discoverFinalizerResources(fake.NewSimpleClientset(), Opts{DiscoveryClient: failingDiscovery{}})
+	if err == nil {
+		t.Fatal("Expected the discovery error to be returned")
+	}
+	if !strings.Contains(err.Error(), "Error fetching server resources") || !strings.Contains(err.Error(), "unable to handle the request") {
+		t.Errorf("Expected the discovery error with its context, got %v", err)
+	}
+}
+
+type partialDiscovery struct {
+	discovery.DiscoveryInterface
+}
+
+func (partialDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return []*metav1.APIResourceList{{GroupVersion: "testgroup/v1"}}, &discovery.ErrGroupDiscoveryFailed{
+		Groups: map[schema.GroupVersion]error{
+			{Group: "metrics.k8s.io", Version: "v1beta1"}: errors.New("the server is currently unable to handle the request"),
+		},
+	}
+}
+
+func TestDiscoverFinalizerResourcesPartialFailure(t *testing.T) {
+	resourceLists, failedGroups, err := discoverFinalizerResources(fake.NewSimpleClientset(), Opts{DiscoveryClient: partialDiscovery{}})
+	if err != nil {
+		t.Fatalf("Expected partial discovery failures to be tolerated, got %v", err)
+	}
+	if len(resourceLists) != 1 || resourceLists[0].GroupVersion != "testgroup/v1" {
+		t.Errorf("Expected the discovered resources to be kept, got %v", resourceLists)
+	}
+	if len(failedGroups) != 1 || failedGroups[0].Group != "metrics.k8s.io" {
+		t.Errorf("Expected metrics.k8s.io to be reported as failed, got %v", failedGroups)
+	}
+}
+
+func TestRetrievePendingDeletionResourcesCancelled(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	testResource := CreateTestUnstructered("TestResource", gvr.GroupVersion().String(), testNamespace, "test-resource")
+	testResource.SetFinalizers([]string{"example.com/cleanup"})
+	testResource.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	dynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), testResource)
+	apiResourceLists := []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := retrievePendingDeletionResources(ctx, apiResourceLists, dynamicClient, &filters.Options{}, Opts{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the scan to stop with %v, got %v", context.Canceled, err)
+	}
+	if len(result.pendingDeletion) != 0 {
+		t.Errorf("Expected no resources to be scanned once cancelled, got %v", result.pendingDeletion)
+	}
+	if len(dynamicClient.Actions()) != 0 {
+		t.Errorf("Expected no List calls once cancelled, got %v", dynamicClient.Actions())
+	}
+}
+
+func TestRetrievePendingDeletionResourcesSkipOwned(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	parentGVR := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "parents"}
+	parent := CreateTestUnstructered("Parent", "testgroup/v1", testNamespace, "parent")
+	var objects []runtime.Object
+	for _, name := range []string{"child-1", "child-2", "orphan"} {
+		obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, name)
+		obj.SetFinalizers([]string{"example.com/cleanup"})
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		setTestOwner(obj, "Parent", "parent")
+		objects = append(objects, obj)
+	}
+	setTestOwner(objects[2].(*unstructured.Unstructured), "Parent", "missing-parent")
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		gvr:       "TestResourceList",
+		parentGVR: "ParentList",
+	}, append(objects, parent)...)
+	apiResourceLists := []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true},
+			{Name: "parents", Kind: "Parent", Verbs: []string{"get"}, Namespaced: true},
+		},
+	}}
+
+	result, err := retrievePendingDeletionResources(context.TODO(), apiResourceLists, dynamicClient, &filters.Options{}, Opts{SkipOwned: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	infos := result.pendingDeletion[testNamespace][gvr]
+	if len(infos) != 1 || infos[0].Name != "orphan" {
+		t.Errorf("Expected only the resource without a live owner to be reported, got %v", infos)
+	}
+	var gets int
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "get" {
+			gets++
+		}
+	}
+	if gets != 2 {
+		t.Errorf("Expected one owner lookup per distinct owner, got %d", gets)
+	}
+}
+
+func TestRetrievePendingDeletionResourcesSelectors(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	var objects []runtime.Object
+	for name, managedBy := range map[string]string{"argocd-managed": "argocd", "helm-managed": "helm"} {
+		obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, name)
+		obj.SetLabels(map[string]string{"app.kubernetes.io/managed-by": managedBy})
+		obj.SetFinalizers([]string{"example.com/cleanup"})
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, obj)
+	}
+	// The fake client ignores field selectors, like resource types not supporting them, so the healthy
+	// resource is listed and must still be dropped by the finalizer checks
+	healthy := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "healthy")
+	healthy.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "argocd"})
+	healthy.SetFinalizers([]string{"example.com/cleanup"})
+	objects = append(objects, healthy)
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, objects...)
+	apiResourceLists := []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}
+
+	filterOpts := &filters.Options{LabelSelector: "app.kubernetes.io/managed-by=argocd", FieldSelector: "metadata.name!=healthy-but-ignored"}
+	result, err := retrievePendingDeletionResources(context.TODO(), apiResourceLists, dynamicClient, filterOpts, Opts{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	infos := result.pendingDeletion[testNamespace][gvr]
+	if len(infos) != 1 || infos[0].Name != "argocd-managed" {
+		t.Errorf("Expected only the resource matching the label selector and pending deletion, got %v", infos)
+	}
+	for _, action := range dynamicClient.Actions() {
+		list, ok := action.(k8stesting.ListActionImpl)
+		if !ok {
+			continue
+		}
+		if list.ListRestrictions.Labels.String() != filterOpts.LabelSelector {
+			t.Errorf("Expected the label selector to be passed to the list call, got %q", list.ListRestrictions.Labels)
+		}
+		if list.ListRestrictions.Fields.String() != filterOpts.FieldSelector {
+			t.Errorf("Expected the field selector to be passed to the list call, got %q", list.ListRestrictions.Fields)
+		}
+	}
+}
+
+type staticDiscovery struct {
+	discovery.DiscoveryInterface
+	resources []*metav1.APIResourceList
+	calls     *int
+}
+
+func (d staticDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	*d.calls++
+	return d.resources, nil
+}
+
+func TestGetClusterScopedResourcesWithFinalizersPendingDeletion(t *testing.T) {
+	clusterGVR := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "clusterresources"}
+	namespacedGVR := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	clusterResource := CreateTestUnstructered("ClusterResource", "testgroup/v1", "", "stuck-cluster-resource")
+	clusterResource.SetFinalizers([]string{"example.com/cleanup"})
+	clusterResource.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	namespacedResource := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "stuck-resource")
+	namespacedResource.SetFinalizers([]string{"example.com/cleanup"})
+	namespacedResource.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		clusterGVR:    "ClusterResourceList",
+		namespacedGVR: "TestResourceList",
+	}, clusterResource, namespacedResource)
+	var discoveryCalls int
+	opts := Opts{DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "clusterresources", Kind: "ClusterResource", Verbs: []string{"list"}},
+			{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true},
+		},
+	}}}}
+	resourceLists, _, err := discoverFinalizerResources(fake.NewSimpleClientset(), opts)
+	if err != nil {
+		t.Fatalf("Unexpected discovery error: %v", err)
+	}
+
+	namespacedResult, err := getResourcesWithFinalizersPendingDeletion(context.TODO(), resourceLists, dynamicClient, &filters.Options{}, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := namespacedResult.pendingDeletion[""]; ok || len(namespacedResult.pendingDeletion[testNamespace][namespacedGVR]) != 1 {
+		t.Errorf("Expected only the namespaced resource to be pending deletion, got %v", namespacedResult.pendingDeletion)
+	}
+	result, err := getClusterScopedResourcesWithFinalizersPendingDeletion(context.TODO(), resourceLists, dynamicClient, &filters.Options{}, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if discoveryCalls != 1 {
+		t.Errorf("Expected discovery to run once for both scans, got %d calls", discoveryCalls)
+	}
+	if names := extractNames(result.pendingDeletion[""][clusterGVR]); !slices.Equal(names, []string{"stuck-cluster-resource"}) {
+		t.Errorf("Expected the cluster scoped resource to be pending deletion, got %v", result.pendingDeletion)
+	}
+	if _, ok := result.pendingDeletion[testNamespace]; ok {
+		t.Errorf("Expected namespaced resources not to be scanned, got %v", result.pendingDeletion[testNamespace])
+	}
+
+	merged := newFinalizerScanResult()
+	merged.merge(result)
+	if len(merged.pendingDeletion[""][clusterGVR]) != 1 || len(merged.stuckItems) != 1 {
+		t.Errorf("Expected the merged scan to hold the cluster scoped resource, got %v", merged.pendingDeletion)
+	}
+	if namespace := reportedNamespace(""); namespace != clusterScopeKey {
+		t.Errorf("Expected cluster scoped resources to be reported under %q, got %q", clusterScopeKey, namespace)
+	}
+}
+
+func TestScanObjectDanglingFinalizers(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "dangling")
+	obj.SetFinalizers([]string{"velero.io/backup"})
+
+	result := newFinalizerScanResult()
+	result.servedGroups = map[string]bool{"testgroup": true}
+	if _, stuck := result.scanObject(obj, gvr, &filters.Options{}); stuck {
+		t.Error("Expected dangling finalizers not to be reported unless enabled")
+	}
+	stuck, ok := result.scanObject(obj, gvr, &filters.Options{DanglingFinalizers: true})
+	if !ok {
+		t.Fatal("Expected the dangling finalizer to be reported")
+	}
+	if expected := "Dangling finalizer velero.io/backup, its API group is not served"; stuck.reason != expected {
+		t.Errorf("Expected reason %q, got %q", expected, stuck.reason)
+	}
+
+	result.addStuckItems([]stuckItem{stuck}, Opts{Explain: true, SeverityWarn: time.Hour})
+	infos := result.pendingDeletion[testNamespace][gvr]
+	if len(infos) != 1 || infos[0].Reason != stuck.reason || infos[0].Severity != "" {
+		t.Errorf("Expected the dangling finalizer to be reported without severity, got %v", infos)
+	}
+}
+
+func TestGetUnusedFinalizersStructured(t *testing.T) {
+	clusterGVR := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "clusterresources"}
+	namespacedGVR := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	clusterResource := CreateTestUnstructered("ClusterResource", "testgroup/v1", "", "stuck-cluster-resource")
+	clusterResource.SetFinalizers([]string{"example.com/cleanup"})
+	clusterResource.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	var objects []runtime.Object
+	for _, name := range []string{"stuck-b", "stuck-a"} {
+		obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, name)
+		obj.SetFinalizers([]string{"example.com/cleanup"})
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, obj)
+	}
+	otherNamespace := CreateTestUnstructered("TestResource", "testgroup/v1", "other-namespace", "stuck-elsewhere")
+	otherNamespace.SetFinalizers([]string{"example.com/cleanup"})
+	otherNamespace.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	healthy := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "healthy")
+	healthy.SetFinalizers([]string{"example.com/cleanup"})
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		clusterGVR:    "ClusterResourceList",
+		namespacedGVR: "TestResourceList",
+	}, append(objects, clusterResource, otherNamespace, healthy)...)
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+	var discoveryCalls int
+	opts := Opts{DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "clusterresources", Kind: "ClusterResource", Verbs: []string{"list"}},
+			{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true},
+		},
+	}}}}
+
+	findings, err := GetUnusedFinalizersStructured(context.TODO(), &filters.Options{}, clientset, dynamicClient, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, finding := range findings {
+		names = append(names, finding.Name)
+	}
+	if expected := []string{"stuck-cluster-resource", "stuck-a", "stuck-b"}; !slices.Equal(names, expected) {
+		t.Fatalf("Expected findings %v, got %v", expected, names)
+	}
+	finding := findings[1]
+	if finding.Namespace != testNamespace || finding.GroupVersionResource != namespacedGVR || finding.DeletionTimestamp == nil || !slices.Equal(finding.Finalizers, []string{"example.com/cleanup"}) {
+		t.Errorf("Unexpected finding %+v", finding)
+	}
+	if findings[0].Namespace != "" || findings[0].GroupVersionResource != clusterGVR {
+		t.Errorf("Expected the cluster scoped finding without a namespace, got %+v", findings[0])
+	}
+}
+
+func TestSelectResourceTypes(t *testing.T) {
+	resourceLists := []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "persistentvolumeclaims"}, {Name: "configmaps"}}},
+		{GroupVersion: "cert-manager.io/v1", APIResources: []metav1.APIResource{{Name: "certificates"}, {Name: "issuers"}}},
+		{GroupVersion: "acme.cert-manager.io/v1", APIResources: []metav1.APIResource{{Name: "orders"}}},
+	}
+
+	for _, tt := range []struct {
+		name                 string
+		resourceTypes        []string
+		excludeResourceTypes []string
+		expected             []string
+	}{
+		{"NoneConfigured", nil, nil, []string{"v1/persistentvolumeclaims", "v1/configmaps", "cert-manager.io/v1/certificates", "cert-manager.io/v1/issuers", "acme.cert-manager.io/v1/orders"}},
+		{"PlainAndQualified", []string{"persistentvolumeclaims", "certificates.cert-manager.io"}, nil, []string{"v1/persistentvolumeclaims", "cert-manager.io/v1/certificates"}},
+		{"UnknownIgnored", []string{"widgets.example.com", "orders"}, nil, []string{"acme.cert-manager.io/v1/orders"}},
+		{"Excluded", nil, []string{"configmaps", "issuers.cert-manager.io", "orders.example.com"}, []string{"v1/persistentvolumeclaims", "cert-manager.io/v1/certificates", "acme.cert-manager.io/v1/orders"}},
+		{"ExcludeWins", []string{"persistentvolumeclaims", "certificates"}, []string{"certificates"}, []string{"v1/persistentvolumeclaims"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var selected []string
+			for _, resourceList := range selectResourceTypes(resourceLists, tt.resourceTypes, tt.excludeResourceTypes) {
+				for _, resource := range resourceList.APIResources {
+					selected = append(selected, resourceList.GroupVersion+"/"+resource.Name)
+				}
+			}
+			if !slices.Equal(selected, tt.expected) {
+				t.Errorf("Expected resource types %v, got %v", tt.expected, selected)
+			}
+		})
+	}
+}
+
+func TestSelectListableResources(t *testing.T) {
+	resourceLists := []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "pods", Verbs: []string{"list", "get"}, Namespaced: true},
+			{Name: "pods/status", Verbs: []string{"list", "get"}, Namespaced: true},
+			{Name: "bindings", Verbs: []string{"create"}, Namespaced: true},
+		}},
+		{GroupVersion: "authorization.k8s.io/v1", APIResources: []metav1.APIResource{
+			{Name: "selfsubjectaccessreviews", Verbs: []string{"create"}},
+		}},
+	}
+
+	names := func(resourceLists []*metav1.APIResourceList) []string {
+		selected := []string{}
+		for _, resourceList := range resourceLists {
+			for _, resource := range resourceList.APIResources {
+				selected = append(selected, resourceList.GroupVersion+"/"+resource.Name)
+			}
+		}
+		return selected
+	}
+	if selected := names(selectListableResources(resourceLists, false)); !slices.Equal(selected, []string{"v1/pods"}) {
+		t.Errorf("Expected only the listable resources, got %v", selected)
+	}
+	if selected := names(selectListableResources(resourceLists, true)); !slices.Equal(selected, []string{"v1/pods", "v1/pods/status"}) {
+		t.Errorf("Expected the listable subresources as well, got %v", selected)
+	}
+}
+
+func TestSelectAPIGroups(t *testing.T) {
+	resourceLists := []*metav1.APIResourceList{
+		{GroupVersion: "v1"},
+		{GroupVersion: "cert-manager.io/v1"},
+		{GroupVersion: "acme.cert-manager.io/v1"},
+		{GroupVersion: "notcert-manager.io/v1"},
+		{GroupVersion: "apps/v1"},
+	}
+
+	for _, tt := range []struct {
+		name          string
+		includeGroups []string
+		expected      []string
+	}{
+		{"NoneConfigured", nil, []string{"v1", "cert-manager.io/v1", "acme.cert-manager.io/v1", "notcert-manager.io/v1", "apps/v1"}},
+		{"GroupSuffix", []string{"Cert-Manager.io"}, []string{"cert-manager.io/v1", "acme.cert-manager.io/v1"}},
+		{"CoreGroup", []string{"core", "apps"}, []string{"v1", "apps/v1"}},
+		{"UnknownGroup", []string{"example.com"}, []string{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := []string{}
+			for _, resourceList := range selectAPIGroups(resourceLists, tt.includeGroups) {
+				selected = append(selected, resourceList.GroupVersion)
+			}
+			if !slices.Equal(selected, tt.expected) {
+				t.Errorf("Expected groups %v, got %v", tt.expected, selected)
+			}
+		})
+	}
+}
+
+func TestScanFinalizersExcludeResourceTypes(t *testing.T) {
+	eventsGVR := schema.GroupVersionResource{Version: "v1", Resource: "events"}
+	testGVR := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		eventsGVR: "EventList",
+		testGVR:   "TestResourceList",
+	})
+	var discoveryCalls int
+	opts := Opts{DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "events", Kind: "Event", Verbs: []string{"list"}, Namespaced: true}}},
+		{GroupVersion: "testgroup/v1", APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}}},
+	}}}
+
+	filterOpts := &filters.Options{ExcludeResourceTypes: []string{"events"}}
+	if _, err := scanFinalizers(context.TODO(), filterOpts, fake.NewSimpleClientset(), dynamicClient, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var listed []string
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "list" {
+			listed = append(listed, action.GetResource().Resource)
+		}
+	}
+	if !slices.Equal(listed, []string{"testresources"}) {
+		t.Errorf("Expected only testresources to be listed, got %v", listed)
+	}
+}
+
+func TestStreamUnusedFinalizers(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	var objects []runtime.Object
+	for _, namespace := range []string{testNamespace, testNamespace, "other-namespace"} {
+		obj := CreateTestUnstructered("TestResource", "testgroup/v1", namespace, fmt.Sprintf("stuck-%d", len(objects)))
+		obj.SetFinalizers([]string{"example.com/cleanup"})
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, obj)
+	}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, objects...)
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+	resourceLists := []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}
+	var discoveryCalls int
+	opts := Opts{DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: resourceLists}}
+
+	var output bytes.Buffer
+	if err := StreamUnusedFinalizers(context.TODO(), &filters.Options{}, clientset, dynamicClient, &output, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per finding in the scanned namespace, got:\n%s", output.String())
+	}
+	for _, line := range lines {
+		var finding FinalizerFinding
+		if err := json.Unmarshal([]byte(line), &finding); err != nil {
+			t.Fatalf("Expected a FinalizerFinding per line, got %q: %v", line, err)
+		}
+		if finding.Namespace != testNamespace || finding.GroupVersionResource != gvr || !slices.Equal(finding.Finalizers, []string{"example.com/cleanup"}) {
+			t.Errorf("Unexpected finding %+v", finding)
+		}
+	}
+
+	// Streamed findings are not kept in the scan result
+	opts.streamFinding = func(FinalizerFinding) error { return nil }
+	result, err := retrievePendingDeletionResources(context.TODO(), resourceLists, dynamicClient, &filters.Options{}, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.stuckItems) != 0 || len(result.pendingDeletion) != 0 {
+		t.Errorf("Expected no findings to be kept while streaming, got %v", result.pendingDeletion)
+	}
+}
+
+func TestRetrievePendingDeletionResourcesStats(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	stuck := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	healthy := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "healthy")
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList", gvr.GroupVersion().WithResource("brokenresources"): "BrokenResourceList"}, stuck, healthy)
+	dynamicClient.PrependReactor("list", "brokenresources", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("conversion webhook failed")
+	})
+	resourceLists := []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true},
+			{Name: "brokenresources", Kind: "BrokenResource", Verbs: []string{"list"}, Namespaced: true},
+		},
+	}}
+
+	result, err := retrievePendingDeletionResources(context.TODO(), resourceLists, dynamicClient, &filters.Options{}, Opts{ListAttempts: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.stats) != 2 {
+		t.Fatalf("Expected stats for both resource types, got %+v", result.stats)
+	}
+	if stats := result.stats[0]; stats.gvr != gvr || stats.examined != 2 || stats.flagged != 1 || stats.err != nil {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if stats := result.stats[1]; stats.gvr.Resource != "brokenresources" || stats.err == nil {
+		t.Errorf("Expected the list error in the stats, got %+v", stats)
+	}
+
+	report := formatScanStats(result.stats)
+	if !strings.Contains(report, "conversion webhook failed") || !strings.Contains(report, "2 resource types listed in") || !strings.Contains(report, "2 objects examined, 1 flagged") {
+		t.Errorf("Unexpected scan statistics:\n%s", report)
+	}
+}
+
+func TestRetrievePendingDeletionResourcesIncludedNamespaces(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	var objects []runtime.Object
+	for _, namespace := range []string{"team-a", "team-b", "other"} {
+		stuck := CreateTestUnstructered("TestResource", "testgroup/v1", namespace, "stuck")
+		stuck.SetFinalizers([]string{"example.com/cleanup"})
+		stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, stuck)
+	}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, objects...)
+	resourceLists := []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}
+
+	filterOpts := &filters.Options{IncludeNamespaces: []string{"team-a"}, IncludeNamespacesRegex: "^team-b$"}
+	result, err := retrievePendingDeletionResources(context.TODO(), resourceLists, dynamicClient, filterOpts, Opts{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var lists []k8stesting.Action
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() == "list" {
+			lists = append(lists, action)
+		}
+	}
+	if len(lists) != 1 || lists[0].GetNamespace() != metav1.NamespaceAll {
+		t.Errorf("Expected a single list of every namespace, got %v", lists)
+	}
+	if len(result.pendingDeletion) != 2 || result.pendingDeletion["team-a"] == nil || result.pendingDeletion["team-b"] == nil {
+		t.Errorf("Expected only the included namespaces to be kept, got %v", result.pendingDeletion)
+	}
+	if stats := result.stats[0]; stats.examined != 3 || stats.flagged != 2 {
+		t.Errorf("Expected every listed object to be examined, got %+v", stats)
+	}
+}
+
+func TestGetUnusedFinalizersNamespaceCreatedDuringScan(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	var objects []runtime.Object
+	for _, namespace := range []string{testNamespace, "created-during-scan", "excluded"} {
+		obj := CreateTestUnstructered("TestResource", "testgroup/v1", namespace, "stuck-in-"+namespace)
+		obj.SetFinalizers([]string{"example.com/cleanup"})
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, obj)
+	}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, objects...)
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+	// The namespaces are created after the namespaces to scan were selected, while the resources are listed
+	dynamicClient.PrependReactor("list", "testresources", func(k8stesting.Action) (bool, runtime.Object, error) {
+		for _, namespace := range []string{"created-during-scan", "excluded"} {
+			if _, err := clientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+		return false, nil, nil
+	})
+	var discoveryCalls int
+	opts := Opts{GroupBy: "namespace", DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}}}
+
+	output, err := GetUnusedfinalizers(context.TODO(), &filters.Options{ExcludeNamespaces: []string{"excluded"}}, clientset, dynamicClient, "json", opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var response map[string]map[string][]ResourceInfo
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Unexpected output %q: %v", output, err)
+	}
+	if len(response) != 2 {
+		t.Fatalf("Expected the scanned and the newly created namespace, got %v", response)
+	}
+	for _, namespace := range []string{testNamespace, "created-during-scan"} {
+		if infos := response[namespace]["testresources"]; len(infos) != 1 || infos[0].Name != "stuck-in-"+namespace {
+			t.Errorf("Expected the resource stuck in %s only, got %v", namespace, infos)
+		}
+	}
+}
+
+func TestGetUnusedFinalizersFailOnFindings(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	stuck := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+	var discoveryCalls int
+	opts := Opts{GroupBy: "namespace", FailOnFindings: true, DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}}}
+
+	for _, outputFormat := range []string{"table", "json", "hash", "ndjson"} {
+		t.Run(outputFormat, func(t *testing.T) {
+			dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck)
+			output, err := GetUnusedfinalizers(context.TODO(), &filters.Options{}, clientset, dynamicClient, outputFormat, opts)
+			var findingsErr *FindingsError
+			if !errors.As(err, &findingsErr) || findingsErr.Count != 1 {
+				t.Fatalf("Expected a FindingsError for the stuck resource, got %v", err)
+			}
+			if !HasOutput(err) || output == "" {
+				t.Errorf("Expected the output along with the error, got %q", output)
+			}
+
+			dynamicClient = fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{gvr: "TestResourceList"})
+			if _, err := GetUnusedfinalizers(context.TODO(), &filters.Options{}, clientset, dynamicClient, outputFormat, opts); err != nil {
+				t.Errorf("Expected no error without findings, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetUnusedFinalizersCollidingResourceNames(t *testing.T) {
+	kyverno := schema.GroupVersionResource{Group: "kyverno.io", Version: "v1", Resource: "policies"}
+	gatekeeper := schema.GroupVersionResource{Group: "gatekeeper.sh", Version: "v1", Resource: "policies"}
+	var objects []runtime.Object
+	for _, apiVersion := range []string{"kyverno.io/v1", "gatekeeper.sh/v1"} {
+		obj := CreateTestUnstructered("Policy", apiVersion, testNamespace, "stuck")
+		obj.SetFinalizers([]string{"example.com/cleanup"})
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, obj)
+	}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{kyverno: "PolicyList", gatekeeper: "PolicyList"}, objects...)
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+	var discoveryCalls int
+	opts := Opts{GroupBy: "namespace", DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{
+		{GroupVersion: "kyverno.io/v1", APIResources: []metav1.APIResource{{Name: "policies", Kind: "Policy", Verbs: []string{"list"}, Namespaced: true}}},
+		{GroupVersion: "gatekeeper.sh/v1", APIResources: []metav1.APIResource{{Name: "policies", Kind: "Policy", Verbs: []string{"list"}, Namespaced: true}}},
+	}}}
+
+	output, err := GetUnusedfinalizers(context.TODO(), &filters.Options{}, clientset, dynamicClient, "json", opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var report map[string]map[string][]ResourceInfo
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Expected a json report, got %q: %v", output, err)
+	}
+	for _, gvr := range []schema.GroupVersionResource{kyverno, gatekeeper} {
+		infos := report[testNamespace][gvr.GroupResource().String()]
+		if len(infos) != 1 || infos[0].Group != gvr.Group || infos[0].Version != gvr.Version || infos[0].Kind != "Policy" {
+			t.Errorf("Expected the %s finding keyed by its group, got %v", gvr.GroupResource(), report)
+		}
+	}
+
+	kyvernoV2 := schema.GroupVersionResource{Group: "kyverno.io", Version: "v2", Resource: "policies"}
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	keys := newResourceTypeKeys(map[string]map[schema.GroupVersionResource][]ResourceInfo{testNamespace: {kyverno: nil, kyvernoV2: nil, gatekeeper: nil, pods: nil}})
+	expected := map[schema.GroupVersionResource]string{kyverno: "policies.v1.kyverno.io", kyvernoV2: "policies.v2.kyverno.io", gatekeeper: "policies.gatekeeper.sh", pods: "pods"}
+	for gvr, key := range expected {
+		if keys.key(gvr) != key {
+			t.Errorf("Expected %s to be keyed %q, got %q", gvr, key, keys.key(gvr))
+		}
+	}
+}
+
+func TestGetUnusedFinalizersSystemNamespaces(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	var objects []runtime.Object
+	var namespaces []runtime.Object
+	for _, namespace := range []string{testNamespace, "kube-system"} {
+		stuck := CreateTestUnstructered("TestResource", "testgroup/v1", namespace, "stuck")
+		stuck.SetFinalizers([]string{"example.com/cleanup"})
+		stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, stuck)
+		namespaces = append(namespaces, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, objects...)
+	var discoveryCalls int
+	opts := Opts{GroupBy: "namespace", DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}}}
+
+	for _, test := range []struct {
+		name       string
+		filterOpts *filters.Options
+		expected   []string
+	}{
+		{"excluded by default", &filters.Options{}, []string{testNamespace}},
+		{"none configured", &filters.Options{SystemNamespaces: []string{}}, []string{"kube-system", testNamespace}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := GetUnusedfinalizers(context.TODO(), test.filterOpts, fake.NewSimpleClientset(namespaces...), dynamicClient, "json", opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var report map[string]map[string][]ResourceInfo
+			if err := json.Unmarshal([]byte(output), &report); err != nil {
+				t.Fatalf("Expected a json report, got %q: %v", output, err)
+			}
+			if reported := sortedKeys(report); !slices.Equal(reported, test.expected) {
+				t.Errorf("Expected the namespaces %v to be reported, got %v", test.expected, reported)
+			}
+		})
+	}
+}
+
+func TestGetUnusedFinalizersUnsupportedOutputFormat(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	stuck := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck)
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+	var discoveryCalls int
+	opts := Opts{GroupBy: "namespace", DeleteFlag: true, NoInteractive: true, ConfirmationToken: RequiredConfirmationToken, DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}}}
+
+	if _, err := GetUnusedfinalizers(context.TODO(), &filters.Options{}, clientset, dynamicClient, "yml", opts); err == nil || !strings.Contains(err.Error(), "unsupported output format: yml") {
+		t.Fatalf("Expected the output format to be rejected, got %v", err)
+	}
+	if actions := dynamicClient.Actions(); len(actions) != 0 {
+		t.Errorf("Expected nothing to be listed or deleted, got %v", actions)
+	}
+	if discoveryCalls != 0 {
+		t.Errorf("Expected the scan to be rejected before discovery, got %d calls", discoveryCalls)
+	}
+}
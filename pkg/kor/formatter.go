@@ -2,17 +2,158 @@ package kor
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"sigs.k8s.io/yaml"
 )
 
 type ResourceInfo struct {
-	Name   string `json:"name"`
-	Reason string `json:"reason,omitempty"`
+	Name     string `json:"name"`
+	Reason   string `json:"reason,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	// Group, Version, Resource and Kind identify the API resource of dynamically discovered findings,
+	// since resource names alone collide between API groups
+	Group    string `json:"group,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	// Finalizers are the finalizers of resources found pending deletion
+	Finalizers []string `json:"finalizers,omitempty"`
+	// PendingSeconds is how long the deletion of the resource has been pending, in whole seconds. It is
+	// a json.Number so ResourceInfo keeps printing with %s, and is empty when no deletion was requested.
+	PendingSeconds json.Number `json:"pendingSeconds,omitempty"`
+}
+
+// getName returns the name column, followed by the finalizers of the resource when it has any
+func (info ResourceInfo) getName() string {
+	if len(info.Finalizers) == 0 {
+		return info.Name
+	}
+	return fmt.Sprintf("%s (%s)", info.Name, strings.Join(info.Finalizers, ", "))
+}
+
+// getPendingFor returns the pending for column, empty for resources whose deletion was not requested
+func (info ResourceInfo) getPendingFor() string {
+	seconds, err := info.PendingSeconds.Int64()
+	if err != nil {
+		return ""
+	}
+	return duration.HumanDuration(time.Duration(seconds) * time.Second)
+}
+
+// hasPendingDuration checks if any of the resources is pending deletion, to add the pending for column
+func hasPendingDuration(resources map[string][]ResourceInfo) bool {
+	for _, infos := range resources {
+		for _, info := range infos {
+			if info.PendingSeconds != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withPendingForColumn adds the pending for column after the resource name column of a table header
+func withPendingForColumn(header []string) []string {
+	return append(header[:3:3], append([]string{"PENDING FOR"}, header[3:]...)...)
+}
+
+// getReason returns the reason column, prefixed with the severity when one is set
+func (info ResourceInfo) getReason() string {
+	if info.Severity == "" {
+		return info.Reason
+	}
+	return fmt.Sprintf("[%s] %s", info.Severity, info.Reason)
+}
+
+// ResultHash returns a stable sha256 of the reported resources. Only the namespace, resource type and
+// name of each resource are hashed, so identical findings always produce the same hash.
+func ResultHash(resources map[string]map[string][]ResourceInfo) string {
+	var entries []string
+	for namespace, resourceMap := range resources {
+		for resourceType, infos := range resourceMap {
+			for _, info := range infos {
+				entries = append(entries, namespace+"\x00"+resourceType+"\x00"+info.Name)
+			}
+		}
+	}
+	sort.Strings(entries)
+	sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// formatResourceTypeHistogram counts the reported resources per resource type across all namespaces,
+// most frequent resource type first
+func formatResourceTypeHistogram(resources map[string]map[string][]ResourceInfo) string {
+	counts := make(map[string]int)
+	for _, resourceMap := range resources {
+		for resourceType, infos := range resourceMap {
+			if len(infos) > 0 {
+				counts[resourceType] += len(infos)
+			}
+		}
+	}
+	resourceTypes := make([]string, 0, len(counts))
+	for resourceType := range counts {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Slice(resourceTypes, func(i, j int) bool {
+		if counts[resourceTypes[i]] != counts[resourceTypes[j]] {
+			return counts[resourceTypes[i]] > counts[resourceTypes[j]]
+		}
+		return resourceTypes[i] < resourceTypes[j]
+	})
+
+	var buf bytes.Buffer
+	table := tablewriter.NewWriter(&buf)
+	table.SetHeader([]string{"#", "RESOURCE TYPE", "COUNT"})
+	for i, resourceType := range resourceTypes {
+		table.Append(getTableRow(i, resourceType, fmt.Sprintf("%d", counts[resourceType])))
+	}
+	table.Render()
+	return buf.String()
+}
+
+// formatShellSummary renders a single line of key=value pairs for shell scripts, e.g. to eval it.
+// The keys are stable:
+//
+//	stuck           resources pending deletion waiting for finalizers
+//	namespaces      namespaces with at least one stuck resource
+//	resource_types  resource types with at least one stuck resource
+//	advisory        stuck resources of advisory only resource types, not counted as stuck
+//	skipped         resource types that could not be listed
+func formatShellSummary(resources, advisory map[string]map[string][]ResourceInfo, skipped int) string {
+	var stuck, advisoryCount int
+	namespaces := make(map[string]bool)
+	resourceTypes := make(map[string]bool)
+	for namespace, resourceMap := range resources {
+		for resourceType, infos := range resourceMap {
+			if len(infos) == 0 {
+				continue
+			}
+			stuck += len(infos)
+			namespaces[namespace] = true
+			resourceTypes[resourceType] = true
+		}
+	}
+	for _, resourceMap := range advisory {
+		for _, infos := range resourceMap {
+			advisoryCount += len(infos)
+		}
+	}
+	return fmt.Sprintf("stuck=%d namespaces=%d resource_types=%d advisory=%d skipped=%d\n", stuck, len(namespaces), len(resourceTypes), advisoryCount, skipped)
 }
 
 func getTableRow(index int, columns ...string) []string {
@@ -31,23 +172,45 @@ func unusedResourceFormatter(outputFormat string, outputBuffer bytes.Buffer, opt
 		if err := SendToSlack(SlackMessage{}, opts, outputBuffer.String()); err != nil {
 			return "", fmt.Errorf("failed to send message to slack: %w", err)
 		}
+		return "", nil
 	case "json", "yaml":
 		var resources map[string]map[string][]ResourceInfo
 		if err := json.Unmarshal(jsonResponse, &resources); err != nil {
 			return "", err
 		}
+		// Map keys are marshaled in order already, sort the resources as well for byte-identical output
+		for _, resourceMap := range resources {
+			for resourceType, infos := range resourceMap {
+				resourceMap[resourceType] = sortedByName(infos)
+			}
+		}
 
 		if !opts.ShowReason {
-			// Create a map of namespaces with their corresponding maps of resource types and lists of resource names
-			namespaces := make(map[string]map[string][]string)
+			// Create a map of namespaces with their corresponding maps of resource types and lists of resource names.
+			// Findings carrying their API resource keep it, without the reason, so they stay unambiguous.
+			namespaces := make(map[string]map[string]interface{})
 			for namespace, resourceMap := range resources {
 				for resourceType, infoSlice := range resourceMap {
-					for _, info := range infoSlice {
-						if _, ok := namespaces[namespace]; !ok {
-							namespaces[namespace] = make(map[string][]string)
+					if len(infoSlice) == 0 {
+						continue
+					}
+					if _, ok := namespaces[namespace]; !ok {
+						namespaces[namespace] = make(map[string]interface{})
+					}
+					if infoSlice[0].Resource != "" {
+						identities := make([]ResourceInfo, 0, len(infoSlice))
+						for _, info := range infoSlice {
+							info.Reason, info.Severity = "", ""
+							identities = append(identities, info)
 						}
-						namespaces[namespace][resourceType] = append(namespaces[namespace][resourceType], info.Name)
+						namespaces[namespace][resourceType] = identities
+						continue
+					}
+					names := make([]string, 0, len(infoSlice))
+					for _, info := range infoSlice {
+						names = append(names, info.Name)
 					}
+					namespaces[namespace][resourceType] = names
 				}
 			}
 			// Marshal the map to JSON format
@@ -75,22 +238,100 @@ func unusedResourceFormatter(outputFormat string, outputBuffer bytes.Buffer, opt
 			}
 		}
 		return string(modifiedJSONResponse), nil
+	case "csv":
+		var resources map[string]map[string][]ResourceInfo
+		if err := json.Unmarshal(jsonResponse, &resources); err != nil {
+			return "", err
+		}
+		return formatCSV(resources, opts.ShowReason)
 	default:
 		return "", fmt.Errorf("unsupported output format: %s", outputFormat)
 	}
-	return "", fmt.Errorf("unsupported output format: %s", outputFormat)
+}
+
+// outputFileTimestampLayout replaces {timestamp} in the output file path, sorting lexically by scan time
+const outputFileTimestampLayout = "20060102T150405Z"
+
+// writeOutputFile writes the formatted output to path, replacing {timestamp} in it with the time of the
+// scan in UTC, so successive scans can be archived side by side. Missing parent directories are
+// created and an existing file is truncated.
+func writeOutputFile(path, output string, scanTime time.Time) error {
+	file, err := createOutputFile(path, scanTime)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(output); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write output file %s: %w", file.Name(), err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file %s: %w", file.Name(), err)
+	}
+	return nil
+}
+
+// createOutputFile creates the output file for a scan at the given time, see writeOutputFile
+func createOutputFile(path string, scanTime time.Time) (*os.File, error) {
+	path = strings.ReplaceAll(path, "{timestamp}", scanTime.UTC().Format(outputFileTimestampLayout))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of output file %s: %w", path, err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write output file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// formatCSV renders one row per resource sorted by namespace, resource type and name, so the output of
+// successive runs can be diffed. Cluster scoped resources are listed under the _cluster namespace.
+func formatCSV(resources map[string]map[string][]ResourceInfo, showReason bool) (string, error) {
+	header := []string{"namespace", "resourceType", "resourceName"}
+	if showReason {
+		header = append(header, "reason")
+	}
+	var rows [][]string
+	for namespace, resourceMap := range resources {
+		for resourceType, infos := range resourceMap {
+			for _, info := range infos {
+				row := []string{reportedNamespace(namespace), resourceType, info.Name}
+				if showReason {
+					row = append(row, info.Reason)
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		for column := 0; column < 3; column++ {
+			if rows[i][column] != rows[j][column] {
+				return rows[i][column] < rows[j][column]
+			}
+		}
+		return false
+	})
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(header); err != nil {
+		return "", err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
 
 func FormatOutput(resources map[string]map[string][]ResourceInfo, opts Opts) bytes.Buffer {
 	var output bytes.Buffer
 	switch opts.GroupBy {
 	case "namespace":
-		for namespace, diffs := range resources {
-			output.WriteString(formatOutputForNamespace(namespace, diffs, opts))
+		for _, namespace := range sortedKeys(resources) {
+			output.WriteString(formatOutputForNamespace(namespace, resources[namespace], opts))
 		}
 	case "resource":
-		for resource, diffs := range resources {
-			output.WriteString(formatOutputForResource(resource, diffs, opts))
+		for _, resource := range sortedKeys(resources) {
+			output.WriteString(formatOutputForResource(resource, resources[resource], opts))
 		}
 	}
 	return output
@@ -100,14 +341,22 @@ func formatOutputForNamespace(namespace string, resources map[string][]ResourceI
 	var buf strings.Builder
 	table := tablewriter.NewWriter(&buf)
 	table.SetColWidth(60)
-	table.SetHeader(getTableHeader(opts.GroupBy, opts.ShowReason))
+	header := getTableHeader(opts.GroupBy, opts.ShowReason)
+	showPendingFor := hasPendingDuration(resources)
+	if showPendingFor {
+		header = withPendingForColumn(header)
+	}
+	table.SetHeader(header)
 	allEmpty := true
 	var index int
-	for resourceType, diff := range resources {
-		for _, info := range diff {
-			row := getTableRow(index, resourceType, info.Name)
+	for _, resourceType := range sortedKeys(resources) {
+		for _, info := range sortedByName(resources[resourceType]) {
+			row := getTableRow(index, resourceType, info.getName())
+			if showPendingFor {
+				row = append(row, info.getPendingFor())
+			}
 			if opts.ShowReason && info.Reason != "" {
-				row = append(row, info.Reason)
+				row = append(row, info.getReason())
 			}
 			table.Append(row)
 			allEmpty = false
@@ -135,13 +384,21 @@ func formatOutputForResource(resource string, resources map[string][]ResourceInf
 	var buf bytes.Buffer
 	table := tablewriter.NewWriter(&buf)
 	table.SetColWidth(60)
-	table.SetHeader(getTableHeader(opts.GroupBy, opts.ShowReason))
+	header := getTableHeader(opts.GroupBy, opts.ShowReason)
+	showPendingFor := hasPendingDuration(resources)
+	if showPendingFor {
+		header = withPendingForColumn(header)
+	}
+	table.SetHeader(header)
 	var index int
-	for ns, infos := range resources {
-		for _, info := range infos {
-			row := getTableRow(index, ns, info.Name)
+	for _, ns := range sortedKeys(resources) {
+		for _, info := range sortedByName(resources[ns]) {
+			row := getTableRow(index, ns, info.getName())
+			if showPendingFor {
+				row = append(row, info.getPendingFor())
+			}
 			if opts.ShowReason && info.Reason != "" {
-				row = append(row, info.Reason)
+				row = append(row, info.getReason())
 			}
 			table.Append(row)
 			index++
@@ -160,6 +417,50 @@ func appendResources(resources map[string]map[string][]ResourceInfo, resourceTyp
 	}
 }
 
+// transposeResources pivots resources keyed by namespace and then resource type to the orientation
+// of --group-by resource, keyed by resource type and then namespace
+func transposeResources(resources map[string]map[string][]ResourceInfo) map[string]map[string][]ResourceInfo {
+	transposed := make(map[string]map[string][]ResourceInfo)
+	for namespace, resourceMap := range resources {
+		for resourceType, infos := range resourceMap {
+			appendResources(transposed, resourceType, namespace, infos)
+		}
+	}
+	return transposed
+}
+
+// formatSortedOutput renders resources keyed by namespace as one table per namespace, or with
+// --group-by resource as one table per resource type, sorted by name
+func formatSortedOutput(resources map[string]map[string][]ResourceInfo, opts Opts) string {
+	format := formatOutputForNamespace
+	if opts.GroupBy == "resource" {
+		resources = transposeResources(resources)
+		format = formatOutputForResource
+	}
+	var buf strings.Builder
+	for _, key := range sortedKeys(resources) {
+		buf.WriteString(format(key, resources[key], opts))
+	}
+	return buf.String()
+}
+
+// sortedKeys returns the keys of a map in order, so output built from maps is identical between runs
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedByName returns a copy of the resources sorted by name
+func sortedByName(infos []ResourceInfo) []ResourceInfo {
+	sorted := slices.Clone(infos)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
 func getTableHeader(groupBy string, showReason bool) []string {
 	switch groupBy {
 	case "namespace":
@@ -199,10 +500,10 @@ func getTableRowResourceInfo(index int, resourceType string, resource ResourceIn
 	row := []string{
 		fmt.Sprintf("%d", index+1),
 		resourceType,
-		resource.Name,
+		resource.getName(),
 	}
 	if ShowReason && resource.Reason != "" {
-		row = append(row, resource.Reason)
+		row = append(row, resource.getReason())
 	}
 	return row
 }
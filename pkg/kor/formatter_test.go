@@ -0,0 +1,324 @@
+package kor
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestResultHash(t *testing.T) {
+	resources := map[string]map[string][]ResourceInfo{
+		"ns1": {"pods": {{Name: "pod-a"}, {Name: "pod-b"}}},
+		"ns2": {"secrets": {{Name: "secret-a", Reason: "Pending deletion waiting for finalizers"}}},
+	}
+	reordered := map[string]map[string][]ResourceInfo{
+		"ns2": {"secrets": {{Name: "secret-a", Severity: "crit"}}},
+		"ns1": {"pods": {{Name: "pod-b"}, {Name: "pod-a"}}},
+	}
+	changed := map[string]map[string][]ResourceInfo{
+		"ns1": {"pods": {{Name: "pod-a"}}},
+		"ns2": {"secrets": {{Name: "secret-a"}}},
+	}
+
+	hash := ResultHash(resources)
+	if !strings.HasPrefix(hash, "sha256:") {
+		t.Errorf("Expected a sha256 hash, got %s", hash)
+	}
+	if hash != ResultHash(reordered) {
+		t.Errorf("Expected identical findings to produce the same hash")
+	}
+	if hash == ResultHash(changed) {
+		t.Errorf("Expected different findings to produce a different hash")
+	}
+}
+
+func TestFormatResourceTypeHistogram(t *testing.T) {
+	resources := map[string]map[string][]ResourceInfo{
+		"ns1": {"pods": {{Name: "pod-a"}, {Name: "pod-b"}}, "secrets": {{Name: "secret-a"}}},
+		"ns2": {"pods": {{Name: "pod-c"}}, "configmaps": {{Name: "cm-a"}}, "jobs": {}},
+	}
+
+	histogram := formatResourceTypeHistogram(resources)
+	var rows []string
+	for _, line := range strings.Split(histogram, "\n") {
+		if fields := strings.Fields(strings.ReplaceAll(line, "|", " ")); len(fields) == 3 && fields[0] != "#" {
+			rows = append(rows, fields[1]+"="+fields[2])
+		}
+	}
+
+	expected := []string{"pods=3", "configmaps=1", "secrets=1"}
+	if strings.Join(rows, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected histogram rows %v, got %v\n%s", expected, rows, histogram)
+	}
+}
+
+func TestUnusedResourceFormatterResourceIdentity(t *testing.T) {
+	resources := map[string]map[string][]ResourceInfo{
+		"ns1": {
+			"policies":   {{Name: "policy-a", Reason: "Pending deletion waiting for finalizers", Group: "example.com", Version: "v1", Resource: "policies", Kind: "Policy"}},
+			"configmaps": {{Name: "cm-a", Reason: "ConfigMap is not used"}},
+		},
+	}
+	jsonResponse, err := json.Marshal(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := unusedResourceFormatter("json", bytes.Buffer{}, Opts{}, jsonResponse)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var parsed map[string]map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Expected json output, got %v", err)
+	}
+	if names := string(parsed["ns1"]["configmaps"]); strings.Join(strings.Fields(names), "") != `["cm-a"]` {
+		t.Errorf("Expected resource names only for findings without an API resource, got %s", names)
+	}
+	var policies []ResourceInfo
+	if err := json.Unmarshal(parsed["ns1"]["policies"], &policies); err != nil {
+		t.Fatalf("Expected findings with their API resource, got %v", err)
+	}
+	expected := ResourceInfo{Name: "policy-a", Group: "example.com", Version: "v1", Resource: "policies", Kind: "Policy"}
+	if len(policies) != 1 || !reflect.DeepEqual(policies[0], expected) {
+		t.Errorf("Expected %+v without the reason, got %+v", expected, policies)
+	}
+}
+
+func TestFormatShellSummary(t *testing.T) {
+	resources := map[string]map[string][]ResourceInfo{
+		"ns1": {"pods": {{Name: "a"}, {Name: "b"}}, "configmaps": {{Name: "c"}}},
+		"ns2": {"pods": {{Name: "d"}}},
+		"ns3": {"pods": {}},
+	}
+	advisory := map[string]map[string][]ResourceInfo{"ns1": {"certificates": {{Name: "e"}}}}
+
+	expected := "stuck=4 namespaces=2 resource_types=2 advisory=1 skipped=3\n"
+	if output := formatShellSummary(resources, advisory, 3); output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
+
+func TestUnusedResourceFormatterYAML(t *testing.T) {
+	resources := map[string]map[string][]ResourceInfo{
+		"ns1": {"configmaps": {{Name: "cm-a", Reason: "ConfigMap is not used"}, {Name: "cm-b", Reason: "ConfigMap is not used"}}},
+		"ns2": {"secrets": {{Name: "secret-a", Reason: "Secret is not used"}}},
+	}
+	jsonResponse, err := json.Marshal(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, showReason := range []bool{false, true} {
+		opts := Opts{ShowReason: showReason}
+		jsonOutput, err := unusedResourceFormatter("json", bytes.Buffer{}, opts, jsonResponse)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		yamlOutput, err := unusedResourceFormatter("yaml", bytes.Buffer{}, opts, jsonResponse)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		convertedJSON, err := yaml.YAMLToJSON([]byte(yamlOutput))
+		if err != nil {
+			t.Fatalf("Expected yaml output, got %v", err)
+		}
+		var fromJSON, fromYAML interface{}
+		if err := json.Unmarshal([]byte(jsonOutput), &fromJSON); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(convertedJSON, &fromYAML); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(fromJSON, fromYAML) {
+			t.Errorf("Expected the yaml output to hold the json data %v, got %v", fromJSON, fromYAML)
+		}
+	}
+
+	if _, err := unusedResourceFormatter("xml", bytes.Buffer{}, Opts{}, jsonResponse); err == nil || !strings.Contains(err.Error(), "unsupported output format: xml") {
+		t.Errorf("Expected an unsupported output format error, got %v", err)
+	}
+}
+
+func TestFormatCSV(t *testing.T) {
+	resources := map[string]map[string][]ResourceInfo{
+		"ns2": {"configmaps": {{Name: "cm-b"}, {Name: "cm-a"}}},
+		"ns1": {"secrets": {{Name: "name, with comma", Reason: `Has "quotes"`}}, "configmaps": {{Name: "cm-c"}}},
+		"":    {"persistentvolumes": {{Name: "pv-a"}}},
+	}
+
+	output, err := formatCSV(resources, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := `namespace,resourceType,resourceName
+_cluster,persistentvolumes,pv-a
+ns1,configmaps,cm-c
+ns1,secrets,"name, with comma"
+ns2,configmaps,cm-a
+ns2,configmaps,cm-b
+`
+	if output != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, output)
+	}
+
+	output, err = formatCSV(map[string]map[string][]ResourceInfo{"ns1": resources["ns1"]}, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(output, `ns1,secrets,"name, with comma","Has ""quotes"""`) {
+		t.Errorf("Expected the reason column to be quoted, got:\n%s", output)
+	}
+}
+
+func TestFormatOutputFinalizers(t *testing.T) {
+	resources := map[string][]ResourceInfo{
+		"persistentvolumeclaims": {{Name: "data", Finalizers: []string{"kubernetes.io/pvc-protection", "example.com/backup"}}},
+	}
+
+	output := formatOutputForNamespace(testNamespace, resources, Opts{GroupBy: "namespace"})
+	if !strings.Contains(output, "data (kubernetes.io/pvc-protection, example.com/backup)") {
+		t.Errorf("Expected the finalizers after the resource name, got:\n%s", output)
+	}
+
+	jsonResponse, err := json.Marshal(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(jsonResponse), `"finalizers":["kubernetes.io/pvc-protection","example.com/backup"]`) {
+		t.Errorf("Expected the finalizers in the json output, got %s", jsonResponse)
+	}
+}
+
+func TestFormatOutputPendingFor(t *testing.T) {
+	resources := map[string][]ResourceInfo{
+		"testresources": {{Name: "stuck", Reason: "Pending deletion waiting for finalizers", PendingSeconds: "7200"}},
+	}
+
+	output := formatOutputForNamespace(testNamespace, resources, Opts{GroupBy: "namespace", ShowReason: true})
+	if !strings.Contains(output, "PENDING FOR") || !strings.Contains(output, "| 120m ") {
+		t.Errorf("Expected a pending for column with a human readable duration, got:\n%s", output)
+	}
+	if strings.Index(output, "PENDING FOR") > strings.Index(output, "REASON") {
+		t.Errorf("Expected the pending for column before the reason, got:\n%s", output)
+	}
+
+	jsonResponse, err := json.Marshal(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(jsonResponse), `"pendingSeconds":7200`) {
+		t.Errorf("Expected the pending seconds as an integer in the json output, got %s", jsonResponse)
+	}
+
+	output = formatOutputForNamespace(testNamespace, map[string][]ResourceInfo{"configmaps": {{Name: "unused"}}}, Opts{GroupBy: "namespace"})
+	if strings.Contains(output, "PENDING FOR") {
+		t.Errorf("Expected no pending for column without resources pending deletion, got:\n%s", output)
+	}
+}
+
+func TestFormatSortedOutputGroupByResource(t *testing.T) {
+	resources := map[string]map[string][]ResourceInfo{
+		"team-b":   {"persistentvolumeclaims": {{Name: "data-b"}}},
+		"team-a":   {"persistentvolumeclaims": {{Name: "data-a"}}, "configmaps": {{Name: "settings"}}},
+		"_cluster": {"persistentvolumes": {}},
+	}
+
+	transposed := transposeResources(resources)
+	expected := map[string]map[string][]ResourceInfo{
+		"persistentvolumeclaims": {"team-a": {{Name: "data-a"}}, "team-b": {{Name: "data-b"}}},
+		"configmaps":             {"team-a": {{Name: "settings"}}},
+	}
+	if !reflect.DeepEqual(transposed, expected) {
+		t.Errorf("Expected resources keyed by resource type %v, got %v", expected, transposed)
+	}
+
+	output := formatSortedOutput(resources, Opts{GroupBy: "resource"})
+	configMaps, claims := strings.Index(output, "Unused configmapss:"), strings.Index(output, "Unused persistentvolumeclaimss:")
+	if configMaps < 0 || claims < configMaps {
+		t.Errorf("Expected one table per resource type sorted by resource type, got:\n%s", output)
+	}
+	if !strings.Contains(output, "NAMESPACE") || strings.Contains(output, "Unused resources in namespace") {
+		t.Errorf("Expected the namespace as a column instead of a table per namespace, got:\n%s", output)
+	}
+
+	output = formatSortedOutput(resources, Opts{GroupBy: "namespace"})
+	if teamA, teamB := strings.Index(output, `namespace: "team-a"`), strings.Index(output, `namespace: "team-b"`); teamA < 0 || teamB < teamA {
+		t.Errorf("Expected one table per namespace sorted by namespace, got:\n%s", output)
+	}
+}
+
+func TestFormatOutputSorted(t *testing.T) {
+	resources := map[string]map[string][]ResourceInfo{
+		"team-b": {"secrets": {{Name: "token"}}},
+		"team-a": {
+			"secrets":    {{Name: "tls"}, {Name: "api-key"}},
+			"configmaps": {{Name: "settings"}, {Name: "env"}},
+		},
+	}
+
+	first := FormatOutput(resources, Opts{GroupBy: "namespace"})
+	output := first.String()
+	var positions []int
+	for _, expected := range []string{`namespace: "team-a"`, "| env ", "| settings ", "| api-key ", "| tls ", `namespace: "team-b"`} {
+		positions = append(positions, strings.Index(output, expected))
+	}
+	if !slices.IsSorted(positions) || slices.Contains(positions, -1) {
+		t.Errorf("Expected namespaces, resource types and names in order, got:\n%s", output)
+	}
+	for i := 0; i < 10; i++ {
+		if again := FormatOutput(resources, Opts{GroupBy: "namespace"}); again.String() != output {
+			t.Fatalf("Expected identical output between runs, got:\n%s\nand:\n%s", output, again.String())
+		}
+	}
+
+	jsonResponse, err := json.Marshal(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonOutput, err := unusedResourceFormatter("json", bytes.Buffer{}, Opts{}, jsonResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(jsonOutput, `"secrets": [
+      "api-key",
+      "tls"
+    ]`) {
+		t.Errorf("Expected the names sorted in the json output, got %s", jsonOutput)
+	}
+}
+
+func TestWriteOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	scanTime := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	path := filepath.Join(dir, "reports", "finalizers-{timestamp}.json")
+	if err := writeOutputFile(path, `{"stale": "content that is longer"}`, scanTime); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := writeOutputFile(path, `{}`, scanTime); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "reports", "finalizers-20240501T123000Z.json"))
+	if err != nil {
+		t.Fatalf("Expected the timestamped output file, got %v", err)
+	}
+	if string(content) != `{}` {
+		t.Errorf("Expected the output file to be truncated, got %s", content)
+	}
+
+	// A regular file cannot be the parent directory of the output file
+	err = writeOutputFile(filepath.Join(dir, "reports", "finalizers-20240501T123000Z.json", "nested.json"), `{}`, scanTime)
+	if err == nil || !strings.Contains(err.Error(), "nested.json") {
+		t.Errorf("Expected an error naming the output file, got %v", err)
+	}
+}
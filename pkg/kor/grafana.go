@@ -0,0 +1,61 @@
+package kor
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// GrafanaColumn is a column of a Grafana table, as served by the JSON datasource
+type GrafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// GrafanaTable is a Grafana table panel response with one row per finding
+type GrafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []GrafanaColumn `json:"columns"`
+	Rows    [][]string      `json:"rows"`
+}
+
+var grafanaFindingColumns = []string{"Namespace", "Resource", "Name", "Reason", "Severity"}
+
+// formatGrafanaTable renders the findings as a Grafana table, with a column for each of the
+// requested labels. objectLabels holds the labels of each finding keyed by namespace/resource/name.
+func formatGrafanaTable(response map[string]map[string][]ResourceInfo, labelColumns []string, objectLabels map[string]map[string]string) (string, error) {
+	table := GrafanaTable{Type: "table", Rows: [][]string{}}
+	for _, column := range grafanaFindingColumns {
+		table.Columns = append(table.Columns, GrafanaColumn{Text: column, Type: "string"})
+	}
+	for _, label := range labelColumns {
+		table.Columns = append(table.Columns, GrafanaColumn{Text: label, Type: "string"})
+	}
+
+	for namespace, resourceMap := range response {
+		for resourceType, infos := range resourceMap {
+			for _, info := range infos {
+				row := []string{namespace, resourceType, info.Name, info.Reason, info.Severity}
+				labels := objectLabels[namespace+"/"+resourceType+"/"+info.Name]
+				for _, label := range labelColumns {
+					row = append(row, labels[label])
+				}
+				table.Rows = append(table.Rows, row)
+			}
+		}
+	}
+	sort.Slice(table.Rows, func(i, j int) bool {
+		for column := 0; column < 3; column++ {
+			if table.Rows[i][column] != table.Rows[j][column] {
+				return table.Rows[i][column] < table.Rows[j][column]
+			}
+		}
+		return false
+	})
+
+	// The JSON datasource expects a list of tables
+	output, err := json.MarshalIndent([]GrafanaTable{table}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
@@ -0,0 +1,48 @@
+package kor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFormatGrafanaTable(t *testing.T) {
+	response := map[string]map[string][]ResourceInfo{
+		testNamespace: {
+			"pods":    {{Name: "stuck-pod", Reason: "Pending deletion waiting for finalizers", Severity: "warn"}},
+			"secrets": {{Name: "stuck-secret"}},
+		},
+	}
+	objectLabels := map[string]map[string]string{
+		testNamespace + "/pods/stuck-pod": {"app": "web"},
+	}
+
+	output, err := formatGrafanaTable(response, []string{"app"}, objectLabels)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var tables []GrafanaTable
+	if err := json.Unmarshal([]byte(output), &tables); err != nil {
+		t.Fatalf("Expected a json list of tables, got %v", err)
+	}
+	if len(tables) != 1 || tables[0].Type != "table" {
+		t.Fatalf("Expected a single table, got %v", tables)
+	}
+
+	var columns []string
+	for _, column := range tables[0].Columns {
+		columns = append(columns, column.Text)
+	}
+	if expected := []string{"Namespace", "Resource", "Name", "Reason", "Severity", "app"}; !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Expected columns %v, got %v", expected, columns)
+	}
+
+	expectedRows := [][]string{
+		{testNamespace, "pods", "stuck-pod", "Pending deletion waiting for finalizers", "warn", "web"},
+		{testNamespace, "secrets", "stuck-secret", "", "", ""},
+	}
+	if !reflect.DeepEqual(tables[0].Rows, expectedRows) {
+		t.Errorf("Expected rows %v, got %v", expectedRows, tables[0].Rows)
+	}
+}
@@ -0,0 +1,113 @@
+package kor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// findingHookTimeout bounds how long a single invocation of the finding hook may take
+const findingHookTimeout = 30 * time.Second
+
+// findingHookClient posts the findings to FindingHookURL
+var findingHookClient = &http.Client{Timeout: findingHookTimeout}
+
+// runFindingHooks invokes the finding hooks once per finding. Failures are reported and the remaining
+// findings are passed on, unless FindingHookFatal is set, then the first failure is returned.
+func runFindingHooks(ctx context.Context, findings []FinalizerFinding, opts Opts) error {
+	if opts.FindingHookURL == "" && opts.FindingHookCommand == "" {
+		return nil
+	}
+	for _, finding := range findings {
+		if err := runFindingHook(ctx, finding, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runFindingHook posts the finding as json to FindingHookURL and runs FindingHookCommand with it, see
+// runFindingHooks. With NotifyDryRun the hooks are printed instead of invoked.
+func runFindingHook(ctx context.Context, finding FinalizerFinding, opts Opts) error {
+	if opts.FindingHookURL == "" && opts.FindingHookCommand == "" {
+		return nil
+	}
+	if opts.NotifyDryRun {
+		fmt.Fprintf(os.Stderr, "Dry run, not invoking the finding hook for %s %s/%s\n", finding.GroupVersionResource.Resource, reportedNamespace(finding.Namespace), finding.Name)
+		return nil
+	}
+
+	payload, err := json.Marshal(finding)
+	if err == nil && opts.FindingHookURL != "" {
+		err = postFinding(ctx, opts.FindingHookURL, payload)
+	}
+	if err == nil && opts.FindingHookCommand != "" {
+		err = runFindingCommand(ctx, opts.FindingHookCommand, finding, payload)
+	}
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("finding hook failed for %s %s/%s: %w", finding.GroupVersionResource.Resource, reportedNamespace(finding.Namespace), finding.Name, err)
+	if opts.FindingHookFatal {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, err)
+	return nil
+}
+
+func postFinding(ctx context.Context, url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := findingHookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// runFindingCommand runs the command with sh, passing the finding as json on stdin and its fields as
+// KOR_* environment variables. They are not substituted into the command itself, so names and
+// finalizers are never interpreted by the shell: use e.g. "$KOR_NAMESPACE/$KOR_NAME" in the command.
+func runFindingCommand(ctx context.Context, command string, finding FinalizerFinding, payload []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, findingHookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), findingHookEnv(finding)...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = os.Stderr
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
+
+// findingHookEnv returns the substitution variables of the finding hook command
+func findingHookEnv(finding FinalizerFinding) []string {
+	return []string{
+		"KOR_NAMESPACE=" + finding.Namespace,
+		"KOR_GROUP=" + finding.GroupVersionResource.Group,
+		"KOR_VERSION=" + finding.GroupVersionResource.Version,
+		"KOR_RESOURCE=" + finding.GroupVersionResource.Resource,
+		"KOR_KIND=" + finding.Kind,
+		"KOR_NAME=" + finding.Name,
+		"KOR_FINALIZERS=" + strings.Join(finding.Finalizers, ","),
+	}
+}
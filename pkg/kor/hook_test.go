@@ -0,0 +1,62 @@
+package kor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRunFindingHooks(t *testing.T) {
+	findings := []FinalizerFinding{
+		{Namespace: testNamespace, GroupVersionResource: schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}, Kind: "TestResource", Name: "first", Finalizers: []string{"mycompany.io/cleanup"}},
+		{Namespace: testNamespace, GroupVersionResource: schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}, Kind: "TestResource", Name: "second; touch injected", Finalizers: []string{"a/b", "c/d"}},
+	}
+
+	var received []FinalizerFinding
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var finding FinalizerFinding
+		if err := json.NewDecoder(r.Body).Decode(&finding); err != nil {
+			t.Errorf("Expected a json finding, got %v", err)
+		}
+		received = append(received, finding)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	command := `echo "$KOR_NAMESPACE $KOR_RESOURCE.$KOR_GROUP $KOR_NAME $KOR_FINALIZERS" >> ` + filepath.Join(dir, "invocations")
+	if err := runFindingHooks(context.TODO(), findings, Opts{FindingHookURL: server.URL, FindingHookCommand: command}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(received) != 2 || received[0].Name != "first" || received[1].Finalizers[1] != "c/d" {
+		t.Errorf("Expected every finding to be posted, got %+v", received)
+	}
+	invocations, err := os.ReadFile(filepath.Join(dir, "invocations"))
+	if err != nil {
+		t.Fatalf("Expected the command to run: %v", err)
+	}
+	expected := "test-namespace testresources.testgroup first mycompany.io/cleanup\n" +
+		"test-namespace testresources.testgroup second; touch injected a/b,c/d\n"
+	if string(invocations) != expected {
+		t.Errorf("Expected invocations:\n%s\ngot:\n%s", expected, invocations)
+	}
+	if _, err := os.Stat("injected"); err == nil {
+		os.Remove("injected")
+		t.Error("Expected the finding fields not to be interpreted by the shell")
+	}
+
+	failing := Opts{FindingHookCommand: "echo unavailable >&2; exit 1"}
+	if err := runFindingHooks(context.TODO(), findings, failing); err != nil {
+		t.Errorf("Expected hook failures to only be reported, got %v", err)
+	}
+	failing.FindingHookFatal = true
+	if err := runFindingHooks(context.TODO(), findings, failing); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("Expected the hook failure to be returned, got %v", err)
+	}
+}
@@ -81,15 +81,20 @@ func processNamespaceHpas(clientset kubernetes.Interface, namespace string, filt
 }
 
 func GetUnusedHpas(filterOpts *filters.Options, clientset kubernetes.Interface, outputFormat string, opts Opts) (string, error) {
+	opts = withDeleteThrottle(opts)
 	resources := make(map[string]map[string][]ResourceInfo)
-	for _, namespace := range filterOpts.Namespaces(clientset) {
+	namespaces, err := filterOpts.Namespaces(clientset)
+	if err != nil {
+		return "", err
+	}
+	for _, namespace := range namespaces {
 		diff, err := processNamespaceHpas(clientset, namespace, filterOpts)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to process namespace %s: %v\n", namespace, err)
 			continue
 		}
 		if opts.DeleteFlag {
-			if diff, err = DeleteResource(diff, clientset, namespace, "HPA", opts.NoInteractive); err != nil {
+			if diff, err = DeleteResource(context.TODO(), diff, clientset, namespace, "HPA", filterOpts, opts); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to delete HPA %s in namespace %s: %v\n", diff, namespace, err)
 			}
 		}
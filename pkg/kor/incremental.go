@@ -0,0 +1,233 @@
+package kor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// incrementalWatchBudget bounds how long the changes since the stored resourceVersions are watched
+// for, across all resource types of a scan. Once it is spent the remaining resource types are listed in
+// full, which is slower but just as accurate.
+var incrementalWatchBudget = 10 * time.Second
+
+// incrementalWatchIdle ends the watch of a resource type once no change arrived for that long. The API
+// server sends the buffered changes right away, so a short pause means they have all been received.
+var incrementalWatchIdle = 200 * time.Millisecond
+
+// resourceVersionState holds, per resource type, the last resourceVersion seen and the objects pending
+// deletion at that version, so repeated scans only receive the objects changed since the previous scan
+// and merge them into the objects found by it
+type resourceVersionState struct {
+	path     string
+	types    map[string]*resourceTypeState
+	deadline time.Time
+}
+
+// resourceTypeState is the state of a resource type, its pending objects are keyed by namespace and name
+type resourceTypeState struct {
+	ResourceVersion string                                `json:"resourceVersion"`
+	Pending         map[string]*unstructured.Unstructured `json:"pending,omitempty"`
+}
+
+// loadResourceVersionState reads the state file, a missing file starts with an empty state. The watch
+// budget of the scan starts when the state is loaded.
+func loadResourceVersionState(path string) (*resourceVersionState, error) {
+	state := newResourceVersionState(path)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &state.types); err != nil {
+		return nil, fmt.Errorf("invalid resourceVersion state file %s: %w", path, err)
+	}
+	return state, nil
+}
+
+func newResourceVersionState(path string) *resourceVersionState {
+	return &resourceVersionState{path: path, types: make(map[string]*resourceTypeState), deadline: time.Now().Add(incrementalWatchBudget)}
+}
+
+// resourceVersion returns the resourceVersion stored for a resource type, empty when none is stored
+func (s *resourceVersionState) resourceVersion(gvr schema.GroupVersionResource) string {
+	if typeState := s.types[gvr.String()]; typeState != nil {
+		return typeState.ResourceVersion
+	}
+	return ""
+}
+
+// save writes the state to a temporary file renamed into place, so an interrupted scan never
+// leaves a truncated state behind
+func (s *resourceVersionState) save() error {
+	content, err := json.MarshalIndent(s.types, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// pendingDeletion reports whether an object waits on finalizers to be deleted, only those can be reported
+// by a finalizer scan, so only those are kept in the state
+func pendingDeletion(obj *unstructured.Unstructured) bool {
+	return obj.GetDeletionTimestamp() != nil && len(obj.GetFinalizers()) > 0
+}
+
+func objectKey(obj *unstructured.Unstructured) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// listChangedResources returns the objects of a resource type. Without a stored resourceVersion, when
+// the API server cannot serve the changes since it, e.g. because it expired, or once the watch budget
+// of the scan is spent, all objects are listed, in pages of listOptions.Limit objects. Otherwise the
+// changes since the stored resourceVersion are merged into the objects pending deletion at it, and the
+// objects pending deletion now are returned. Pages failing with a transient error are retried.
+func listChangedResources(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, listOptions metav1.ListOptions, state *resourceVersionState, retry *listRetry) ([]unstructured.Unstructured, error) {
+	if state != nil && state.resourceVersion(gvr) != "" && time.Now().Before(state.deadline) {
+		typeState := state.types[gvr.String()]
+		if err := watchChangedResources(ctx, dynamicClient, gvr, listOptions, typeState, state.deadline); err == nil {
+			items := make([]unstructured.Unstructured, 0, len(typeState.Pending))
+			for _, key := range sortedKeys(typeState.Pending) {
+				items = append(items, *typeState.Pending[key].DeepCopy())
+			}
+			return items, nil
+		}
+	}
+
+	var items []unstructured.Unstructured
+	var resourceVersion string
+	for {
+		var resourceList *unstructured.UnstructuredList
+		err := retry.do(ctx, func() (err error) {
+			resourceList, err = dynamicClient.
+				Resource(gvr).
+				Namespace(metav1.NamespaceAll).
+				List(ctx, listOptions)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Every page is served from the snapshot of the first one, so its resourceVersion covers them all
+		if resourceVersion == "" {
+			resourceVersion = resourceList.GetResourceVersion()
+		}
+		items = append(items, resourceList.Items...)
+		if resourceList.GetContinue() == "" {
+			break
+		}
+		listOptions.Continue = resourceList.GetContinue()
+	}
+	// Without a resourceVersion the changes cannot be watched on the next scan, and the stored state is stale
+	if state != nil && resourceVersion == "" {
+		delete(state.types, gvr.String())
+	}
+	if state != nil && resourceVersion != "" {
+		typeState := &resourceTypeState{ResourceVersion: resourceVersion, Pending: make(map[string]*unstructured.Unstructured)}
+		for i := range items {
+			if pendingDeletion(&items[i]) {
+				typeState.Pending[objectKey(&items[i])] = items[i].DeepCopy()
+			}
+		}
+		state.types[gvr.String()] = typeState
+	}
+	return items, nil
+}
+
+// watchChangedResources applies the changes since the stored resourceVersion of a resource type to its
+// pending objects, and advances the resourceVersion to continue from on the next scan. The watch ends once
+// no change arrived for incrementalWatchIdle, or at the deadline of the scan. The changes are applied in
+// order as they arrive, so the state is consistent with the last resourceVersion seen whenever it ends.
+// On error the state of the resource type is left untouched.
+func watchChangedResources(ctx context.Context, dynamicClient dynamic.Interface, gvr schema.GroupVersionResource, listOptions metav1.ListOptions, typeState *resourceTypeState, deadline time.Time) error {
+	// The server side timeout only ends a watch the client stopped reading from
+	timeout := int64(time.Until(deadline).Seconds()) + 1
+	listOptions.ResourceVersion = typeState.ResourceVersion
+	listOptions.Limit = 0
+	listOptions.AllowWatchBookmarks = true
+	listOptions.TimeoutSeconds = &timeout
+	watcher, err := dynamicClient.Resource(gvr).Namespace(metav1.NamespaceAll).Watch(ctx, listOptions)
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	resourceVersion := typeState.ResourceVersion
+	pending := make(map[string]*unstructured.Unstructured, len(typeState.Pending))
+	for key, obj := range typeState.Pending {
+		pending[key] = obj
+	}
+	idle := time.NewTimer(incrementalWatchIdle)
+	defer idle.Stop()
+	done := time.NewTimer(time.Until(deadline))
+	defer done.Stop()
+watch:
+	for {
+		var event watch.Event
+		var open bool
+		select {
+		case event, open = <-watcher.ResultChan():
+			if !open {
+				break watch
+			}
+		case <-idle.C:
+			break watch
+		case <-done.C:
+			break watch
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if event.Type == watch.Error {
+			return fmt.Errorf("watching %s since resourceVersion %s failed: %v", gvr.Resource, typeState.ResourceVersion, event.Object)
+		}
+		if !idle.Stop() {
+			<-idle.C
+		}
+		idle.Reset(incrementalWatchIdle)
+		item, ok := event.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if item.GetResourceVersion() != "" {
+			resourceVersion = item.GetResourceVersion()
+		}
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			// An object whose finalizers were removed, or whose deletion was never requested, is not pending any more
+			if pendingDeletion(item) {
+				pending[objectKey(item)] = item
+			} else {
+				delete(pending, objectKey(item))
+			}
+		case watch.Deleted:
+			delete(pending, objectKey(item))
+		}
+	}
+	typeState.ResourceVersion = resourceVersion
+	typeState.Pending = pending
+	return nil
+}
@@ -0,0 +1,257 @@
+package kor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestResourceVersionState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versions.json")
+	state, err := loadResourceVersionState(path)
+	if err != nil {
+		t.Fatalf("Expected a missing state file to be empty, got %v", err)
+	}
+	stuck := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	state.types["testresources.v1.testgroup"] = &resourceTypeState{ResourceVersion: "42", Pending: map[string]*unstructured.Unstructured{objectKey(stuck): stuck}}
+	if err := state.save(); err != nil {
+		t.Fatalf("Expected no error saving the state, got %v", err)
+	}
+
+	loaded, err := loadResourceVersionState(path)
+	if err != nil {
+		t.Fatalf("Expected no error loading the state, got %v", err)
+	}
+	typeState := loaded.types["testresources.v1.testgroup"]
+	if typeState == nil || typeState.ResourceVersion != "42" {
+		t.Fatalf("Expected the stored resourceVersion 42, got %v", loaded.types)
+	}
+	if pending := typeState.Pending[objectKey(stuck)]; pending == nil || !pendingDeletion(pending) {
+		t.Errorf("Expected the stored pending object, got %v", typeState.Pending)
+	}
+}
+
+func TestListChangedResources(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	unchanged := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "unchanged")
+	unchanged.SetFinalizers([]string{"example.com/cleanup"})
+	unchanged.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	removed := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "removed")
+	removed.SetFinalizers([]string{"example.com/cleanup"})
+	removed.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	changed := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "changed")
+	newClient := func() *fakedynamic.FakeDynamicClient {
+		return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, unchanged, changed)
+	}
+	newState := func() *resourceVersionState {
+		state := newResourceVersionState("")
+		state.types[gvr.String()] = &resourceTypeState{ResourceVersion: "10", Pending: map[string]*unstructured.Unstructured{
+			objectKey(unchanged): unchanged.DeepCopy(),
+			objectKey(removed):   removed.DeepCopy(),
+		}}
+		return state
+	}
+
+	t.Run("MergeChangesSinceStoredVersion", func(t *testing.T) {
+		dynamicClient := newClient()
+		// The watch is left open like on a live API server, it ends once no change arrives
+		watcher := watch.NewFakeWithChanSize(3, false)
+		first := changed.DeepCopy()
+		first.SetResourceVersion("11")
+		latest := changed.DeepCopy()
+		latest.SetResourceVersion("12")
+		latest.SetFinalizers([]string{"example.com/cleanup"})
+		latest.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		deleted := removed.DeepCopy()
+		deleted.SetResourceVersion("13")
+		watcher.Add(first)
+		watcher.Modify(latest)
+		watcher.Delete(deleted)
+		var watchedVersion string
+		dynamicClient.PrependWatchReactor("testresources", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			watchedVersion = action.(k8stesting.WatchActionImpl).WatchRestrictions.ResourceVersion
+			return true, watcher, nil
+		})
+
+		state := newState()
+		items, err := listChangedResources(context.TODO(), dynamicClient, gvr, metav1.ListOptions{}, state, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if watchedVersion != "10" {
+			t.Errorf("Expected to watch since resourceVersion 10, got %q", watchedVersion)
+		}
+		var names []string
+		for _, item := range items {
+			names = append(names, item.GetName())
+		}
+		if !slices.Equal(names, []string{"changed", "unchanged"}) {
+			t.Errorf("Expected the unchanged pending resource merged with the changed one, got %v", names)
+		}
+		if state.resourceVersion(gvr) != "13" {
+			t.Errorf("Expected the stored resourceVersion to advance to 13, got %q", state.resourceVersion(gvr))
+		}
+	})
+
+	t.Run("FallBackToFullList", func(t *testing.T) {
+		dynamicClient := newClient()
+		dynamicClient.PrependWatchReactor("testresources", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			return true, nil, errors.New("resourceVersion too old")
+		})
+
+		state := newState()
+		items, err := listChangedResources(context.TODO(), dynamicClient, gvr, metav1.ListOptions{}, state, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(items) != 2 {
+			t.Errorf("Expected every resource to be listed, got %d", len(items))
+		}
+		if typeState := state.types[gvr.String()]; typeState != nil && typeState.Pending[objectKey(removed)] != nil {
+			t.Errorf("Expected the stale pending resources to be dropped, got %v", typeState.Pending)
+		}
+	})
+
+	t.Run("FullListOnceBudgetSpent", func(t *testing.T) {
+		dynamicClient := newClient()
+		var watched bool
+		dynamicClient.PrependWatchReactor("testresources", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			watched = true
+			return true, watch.NewFake(), nil
+		})
+
+		state := newState()
+		state.deadline = time.Now()
+		items, err := listChangedResources(context.TODO(), dynamicClient, gvr, metav1.ListOptions{}, state, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if watched || len(items) != 2 {
+			t.Errorf("Expected every resource to be listed without watching, watched %v, got %d", watched, len(items))
+		}
+	})
+}
+
+func TestWatchChangedResourcesDeadline(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"})
+	// A watch receiving changes continuously never goes idle, the deadline of the scan ends it
+	watcher := watch.NewFake()
+	dynamicClient.PrependWatchReactor("testresources", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		return true, watcher, nil
+	})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for i := 0; ; i++ {
+			item := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "busy")
+			item.SetResourceVersion(strconv.Itoa(11 + i))
+			select {
+			case <-stop:
+				return
+			case <-time.After(incrementalWatchIdle / 4):
+			}
+			watcher.Modify(item)
+		}
+	}()
+
+	typeState := &resourceTypeState{ResourceVersion: "10"}
+	start := time.Now()
+	if err := watchChangedResources(context.TODO(), dynamicClient, gvr, metav1.ListOptions{}, typeState, start.Add(3*incrementalWatchIdle)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 6*incrementalWatchIdle {
+		t.Errorf("Expected the watch to end at the deadline, took %s", elapsed)
+	}
+	if typeState.ResourceVersion == "10" {
+		t.Error("Expected the resourceVersion to advance to the last change received")
+	}
+}
+
+// pagedResources serves the objects of a resource type in pages of limit objects, like the API server
+type pagedResources struct {
+	dynamic.Interface
+	dynamic.NamespaceableResourceInterface
+	items  []unstructured.Unstructured
+	listed []metav1.ListOptions
+}
+
+func (p *pagedResources) Resource(schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return p
+}
+
+func (p *pagedResources) Namespace(string) dynamic.ResourceInterface {
+	return p
+}
+
+func (p *pagedResources) List(_ context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	p.listed = append(p.listed, opts)
+	start := 0
+	if opts.Continue != "" {
+		start, _ = strconv.Atoi(opts.Continue)
+	}
+	end := len(p.items)
+	if opts.Limit > 0 && start+int(opts.Limit) < end {
+		end = start + int(opts.Limit)
+	}
+	list := &unstructured.UnstructuredList{Items: p.items[start:end]}
+	list.SetResourceVersion(fmt.Sprintf("%d", 20+len(p.listed)))
+	if end < len(p.items) {
+		list.SetContinue(strconv.Itoa(end))
+	}
+	return list, nil
+}
+
+func TestListChangedResourcesPaginated(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	resources := &pagedResources{}
+	for i := 0; i < 5; i++ {
+		resources.items = append(resources.items, *CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, fmt.Sprintf("resource-%d", i)))
+	}
+	resources.items[3].SetFinalizers([]string{"example.com/cleanup"})
+	resources.items[3].SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+
+	state := newResourceVersionState("")
+	items, err := listChangedResources(context.TODO(), resources, gvr, metav1.ListOptions{Limit: 2}, state, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(items) != 5 || items[0].GetName() != "resource-0" || items[4].GetName() != "resource-4" {
+		t.Errorf("Expected the resources of every page, got %v", items)
+	}
+	if len(resources.listed) != 3 {
+		t.Fatalf("Expected 3 pages to be listed, got %d", len(resources.listed))
+	}
+	for i, opts := range resources.listed {
+		if opts.Limit != 2 {
+			t.Errorf("Expected page %d to be limited to 2 resources, got %d", i, opts.Limit)
+		}
+	}
+	if resources.listed[2].Continue != "4" {
+		t.Errorf("Expected the last page to continue from the token of the previous one, got %q", resources.listed[2].Continue)
+	}
+	if state.resourceVersion(gvr) != "21" {
+		t.Errorf("Expected the resourceVersion of the first page to be stored, got %q", state.resourceVersion(gvr))
+	}
+	if pending := state.types[gvr.String()].Pending; len(pending) != 1 || pending[objectKey(&resources.items[3])] == nil {
+		t.Errorf("Expected only the resource pending deletion to be stored, got %v", pending)
+	}
+}
@@ -0,0 +1,65 @@
+package kor
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+// toUnstructured returns a cached object as unstructured, converting typed objects from typed informers
+func toUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	switch o := obj.(type) {
+	case *unstructured.Unstructured:
+		return o, nil
+	case runtime.Object:
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+		if err != nil {
+			return nil, err
+		}
+		return &unstructured.Unstructured{Object: content}, nil
+	default:
+		return nil, fmt.Errorf("unsupported object type %T", obj)
+	}
+}
+
+// GetPendingDeletionFromStores runs the finalizer detection against the objects of informer caches,
+// keyed by the resource they hold, instead of listing them from the API server. It is meant for
+// controllers already running informers. Namespaces are matched against the include and exclude
+// lists only, and owners are not resolved since that requires API calls.
+func GetPendingDeletionFromStores(stores map[schema.GroupVersionResource]cache.Store, filterOpts *filters.Options, opts Opts) (map[string]map[string][]ResourceInfo, error) {
+	if _, err := timezoneLocation(opts.Timezone); err != nil {
+		return nil, err
+	}
+	result := newFinalizerScanResult()
+	var stuckItems []stuckItem
+	for gvr, store := range stores {
+		for _, obj := range store.List() {
+			item, err := toUnstructured(obj)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read cached %s: %w", gvr.Resource, err)
+			}
+			if stuck, ok := result.scanObject(item, gvr, filterOpts); ok {
+				stuckItems = append(stuckItems, stuck)
+			}
+		}
+	}
+	result.addStuckItems(stuckItems, opts)
+
+	response := make(map[string]map[string][]ResourceInfo)
+	keys := newResourceTypeKeys(result.pendingDeletion)
+	for namespace, resources := range result.pendingDeletion {
+		if !filterOpts.IncludesNamespace(namespace) {
+			continue
+		}
+		response[namespace] = make(map[string][]ResourceInfo)
+		for gvr, infos := range resources {
+			response[namespace][keys.key(gvr)] = infos
+		}
+	}
+	return response, nil
+}
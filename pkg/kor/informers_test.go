@@ -0,0 +1,64 @@
+package kor
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+func TestGetPendingDeletionFromStores(t *testing.T) {
+	deletionTimestamp := &metav1.Time{Time: time.Now()}
+
+	customGVR := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	customStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	stuck := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.SetDeletionTimestamp(deletionTimestamp)
+	excluded := CreateTestUnstructered("TestResource", "testgroup/v1", "excluded-namespace", "stuck")
+	excluded.SetFinalizers([]string{"example.com/cleanup"})
+	excluded.SetDeletionTimestamp(deletionTimestamp)
+	for _, obj := range []interface{}{stuck, excluded, CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "healthy")} {
+		if err := customStore.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	podGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	podStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	if err := podStore.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              "stuck-pod",
+		Namespace:         testNamespace,
+		Finalizers:        []string{"example.com/cleanup"},
+		DeletionTimestamp: deletionTimestamp,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := GetPendingDeletionFromStores(
+		map[schema.GroupVersionResource]cache.Store{customGVR: customStore, podGVR: podStore},
+		&filters.Options{ExcludeNamespaces: []string{"excluded-namespace"}},
+		Opts{},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(response) != 1 {
+		t.Fatalf("Expected findings in %s only, got %v", testNamespace, response)
+	}
+	if infos := response[testNamespace]["testresources"]; len(infos) != 1 || infos[0].Name != "stuck" {
+		t.Errorf("Expected the stuck custom resource, got %v", infos)
+	}
+	if infos := response[testNamespace]["pods"]; len(infos) != 1 || infos[0].Name != "stuck-pod" {
+		t.Errorf("Expected the stuck typed pod, got %v", infos)
+	}
+	if stuck.GetDeletionTimestamp() == nil || len(stuck.GetFinalizers()) != 1 {
+		t.Error("Expected the cached object to be left untouched")
+	}
+}
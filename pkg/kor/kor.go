@@ -7,12 +7,14 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"sync"
+	"time"
 
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
@@ -50,6 +52,180 @@ type Opts struct {
 	Token         string
 	GroupBy       string
 	ShowReason    bool
+	// CheckFinalizerFormat reports the finalizers not in the domain/name format instead of the resources pending deletion
+	CheckFinalizerFormat bool
+	// ShowOwners resolves the owner chain of each stuck resource, up to OwnerDepth owners, into its reason
+	ShowOwners bool
+	OwnerDepth int
+	// SeverityWarn and SeverityCrit are how long a resource is stuck pending deletion before it gets the warn or
+	// crit severity, no severity when zero
+	SeverityWarn time.Duration
+	SeverityCrit time.Duration
+	// ClusterName identifies the scanned cluster in the cloudevents source, the Slack summary and the backups
+	ClusterName string
+	// PropagationPolicies maps resource types to the Background, Foreground or Orphan propagation policy of their
+	// deletion, matched case-insensitively. Background when a resource type is not set.
+	PropagationPolicies map[string]string
+	// ShowHash prints the hash of the scan result above the table output
+	ShowHash bool
+	// Explain replaces the reason of each stuck resource with its finalizers, deletion age and owners
+	Explain bool
+	// Concurrency is the maximum number of concurrent API calls enriching the results, 10 when zero
+	Concurrency int
+	// MetricsTextfile is written with the kor_finalizers_pending metrics of the scan, for the node_exporter
+	MetricsTextfile string
+	// OwnerTree resolves the owners of the stuck resources to render them as a tree, set by the tree output
+	OwnerTree bool
+	// ConfirmationRetries is how many times a confirmation is asked again after an answer other than y(es) or
+	// n(o), the resource is not deleted once they are exhausted
+	ConfirmationRetries int
+	// VerifyDeletion re-lists the resources a finalizer scan deleted and reports which ones are gone
+	VerifyDeletion bool
+	// GrafanaLabelColumns are the labels added as columns of the grafana output
+	GrafanaLabelColumns []string
+	// TerminatingNamespaces only reports the resources blocking namespaces stuck in the Terminating phase
+	TerminatingNamespaces bool
+	// ResourceVersionFile stores the last seen resourceVersion per resource type, so repeated finalizer scans
+	// only watch the changes since the previous one
+	ResourceVersionFile string
+	// ShowFinalizerManagers adds the field managers that set the finalizers, and when, to the reason
+	ShowFinalizerManagers bool
+	// DeleteRate is the maximum number of deletions per second, lowered while the API server throttles. No limit
+	// when zero.
+	DeleteRate float64
+	// AdvisoryResourceTypes use finalizers as a long-lived protocol, they are reported separately and never deleted
+	AdvisoryResourceTypes []string
+	// Timezone is the IANA timezone human readable timestamps are displayed in, UTC when empty
+	Timezone string
+	// FindingFilter drops the finalizer findings it returns false for. It is called for every resource
+	// stuck pending deletion after the built-in filters (used mark, excluded labels, age and
+	// exclude expression) and before namespace selection, reporting and deletion, so dropped findings
+	// are never deleted.
+	FindingFilter func(FinalizerFinding) bool
+	// DiscoveryClient is used instead of the clientset discovery when set, e.g. to share a cached
+	// discovery client between scans
+	DiscoveryClient discovery.DiscoveryInterface
+	// DiscoveryCacheDir caches the API discovery on disk for DiscoveryCacheTTL, when set
+	DiscoveryCacheDir string
+	DiscoveryCacheTTL time.Duration
+	// NoConfirmResourceTypes are deleted without an interactive confirmation, while other resource types still prompt
+	NoConfirmResourceTypes []string
+	// ShowProtectedStuck reports the resources marked as used that are stuck pending deletion in a separate section
+	ShowProtectedStuck bool
+	// StatusPaths maps resource types to a JSONPath into their status explaining why they are stuck
+	StatusPaths map[string]string
+	// SeparateDeleteResults reports the resources found unchanged, and what a delete run did with them separately
+	SeparateDeleteResults bool
+	// MarkLabel and MarkAnnotation are set on every stuck resource to when its deletion was requested, instead
+	// of deleting it
+	MarkLabel      string
+	MarkAnnotation string
+	// RemoveFinalizers strips the finalizers of the resources pending deletion instead of deleting them, an
+	// alternative to DeleteFlag
+	RemoveFinalizers bool
+	// SkipOwned drops the resources whose controlling owner still exists, their deletion cascades from it
+	SkipOwned bool
+	// PageSize is the number of objects listed per request, 500 when zero
+	PageSize int64
+	// ListAttempts is how many times a List request failing with a transient error is made, 3 when zero
+	ListAttempts int
+	// SlackSummaryWebhookURL receives a summary of the resources stuck on finalizers after a scan finding any
+	SlackSummaryWebhookURL string
+	// BatchConfirmation prompts once for all the resources a finalizer scan is about to delete, instead of for each of them
+	BatchConfirmation bool
+	// GracePeriodSeconds is passed to delete requests, the default of the resource type when nil
+	GracePeriodSeconds *int64
+	// WaitForDeletion is how long a finalizer scan waits for the resources it deleted to be gone, when set.
+	// The ones remaining are reported, and ErrDeletionIncomplete is returned along with the output.
+	WaitForDeletion time.Duration
+	// FindingHookURL receives a POST of every finding of a finalizer scan as json
+	FindingHookURL string
+	// FindingHookCommand is run with sh for every finding of a finalizer scan, with the finding as json on
+	// stdin and its fields in the KOR_NAMESPACE, KOR_GROUP, KOR_VERSION, KOR_RESOURCE, KOR_KIND, KOR_NAME
+	// and KOR_FINALIZERS environment variables
+	FindingHookCommand string
+	// FindingHookFatal fails the scan on the first failed finding hook, failures are only reported otherwise
+	FindingHookFatal bool
+	// ConfirmationToken must be RequiredConfirmationToken for a finalizer scan to delete or remove finalizers with NoInteractive
+	ConfirmationToken string
+	// ProtectedFinalizers are path.Match patterns of the finalizers a finalizer scan never removes, resources only
+	// blocked by them are skipped. DefaultProtectedFinalizers when nil.
+	ProtectedFinalizers []string
+	// FailOnFindings returns a FindingsError along with the output of a finalizer scan reporting any resource
+	FailOnFindings bool
+	// OutputFile is written with the output of a finalizer scan, {timestamp} in it is replaced with the scan time
+	OutputFile string
+	// DryRun sends delete and patch requests as server-side dry runs
+	DryRun bool
+	// BackupDir is where a finalizer scan saves the manifests of the resources before deleting them or removing
+	// their finalizers, each with its BackupProvenance. Resources that cannot be backed up are left untouched.
+	BackupDir string
+	// NotifyDryRun prints the Slack summary and the finding hooks of a finalizer scan instead of sending them
+	NotifyDryRun bool
+	// IncludeSubresources also lists the subresources a finalizer scan discovers with the list verb, e.g. pods/status,
+	// which are skipped otherwise as they can rarely be listed
+	IncludeSubresources bool
+	// ShowProgress reports on stderr the resource types a finalizer scan listed out of the total, and the resources flagged so far
+	ShowProgress bool
+	// RedactNames reports the resources of a finalizer scan under their RedactName, their namespace and resource type are kept
+	RedactNames bool
+	// RedactionKey keys the redacted names, it is generated and printed on stderr when empty
+	RedactionKey string
+	// Unredact maps the redacted names of an earlier report back to the resources they redact, with its RedactionKey
+	Unredact []string
+
+	servedGroups  map[string]bool              // API groups served during a finalizer scan
+	streamFinding func(FinalizerFinding) error // emits every finding as it is found instead of keeping it
+	progress      *scanProgress                // reports the progress of a finalizer scan with ShowProgress
+	throttle      *deleteThrottle              // paces every deletion and patch of a run at DeleteRate
+}
+
+const defaultConcurrency = 10
+
+// defaultListPageSize is the number of objects listed per request, so huge resource types are fetched in pages
+const defaultListPageSize int64 = 500
+
+// displayTimestampLayout is used for timestamps in human readable output, json keeps RFC 3339 in UTC
+const displayTimestampLayout = "2006-01-02 15:04:05 MST"
+
+// timezoneLocation returns the IANA timezone human readable timestamps are displayed in, UTC by default
+func timezoneLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return location, nil
+}
+
+// formatTimestamp renders a timestamp for human readable output in the given location
+func formatTimestamp(t time.Time, location *time.Location) string {
+	return t.In(location).Format(displayTimestampLayout)
+}
+
+// forEachConcurrently calls fn for every index below n using at most concurrency goroutines
+func forEachConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency && worker < n; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
 }
 
 func RemoveDuplicatesAndSort(slice []string) []string {
@@ -70,20 +246,25 @@ func GetKubeConfigPath() string {
 	return filepath.Join(home, ".kube", "config")
 }
 
+// GetConfig resolves the client options from the environment: in-cluster when a service account token
+// is mounted, otherwise the given kubeconfig, $KUBECONFIG or ~/.kube/config
 func GetConfig(kubeconfig string) (*rest.Config, error) {
 	if _, err := os.Stat("/var/run/secrets/kubernetes.io/serviceaccount/token"); err == nil {
-		return rest.InClusterConfig()
+		return BuildConfig(ClientOptions{InCluster: true, QPS: ClientQPS, Burst: ClientBurst})
 	}
 
-	if kubeconfig == "" {
-		if configEnv := os.Getenv("KUBECONFIG"); configEnv != "" {
-			kubeconfig = configEnv
-		} else {
-			kubeconfig = GetKubeConfigPath()
-		}
-	}
+	return BuildConfig(ClientOptions{Kubeconfig: ResolveKubeconfig(kubeconfig), QPS: ClientQPS, Burst: ClientBurst})
+}
 
-	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+// ResolveKubeconfig returns the kubeconfig path to use: the given one, $KUBECONFIG or ~/.kube/config
+func ResolveKubeconfig(kubeconfig string) string {
+	if kubeconfig != "" {
+		return kubeconfig
+	}
+	if configEnv := os.Getenv("KUBECONFIG"); configEnv != "" {
+		return configEnv
+	}
+	return GetKubeConfigPath()
 }
 
 func GetKubeClient(kubeconfig string) *kubernetes.Clientset {
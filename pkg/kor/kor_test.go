@@ -4,6 +4,7 @@ import (
 	"os"
 	"sort"
 	"testing"
+	"time"
 )
 
 func stringSlicesEqual(a, b []string) bool {
@@ -198,3 +199,16 @@ func TestResourceExceptionWithRegexPrefixInNamespace(t *testing.T) {
 		t.Error("Expected to find exception")
 	}
 }
+
+func TestTimezoneLocation(t *testing.T) {
+	if location, err := timezoneLocation(""); err != nil || location != time.UTC {
+		t.Errorf("Expected UTC by default, got %v, %v", location, err)
+	}
+	if _, err := timezoneLocation("Not/AZone"); err == nil {
+		t.Error("Expected an error for an invalid timezone")
+	}
+	deletionTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if formatted := formatTimestamp(deletionTime, time.FixedZone("TEST", 2*60*60)); formatted != "2024-01-01 14:00:00 TEST" {
+		t.Errorf("Expected the timestamp in the configured timezone, got %s", formatted)
+	}
+}
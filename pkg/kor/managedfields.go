@@ -0,0 +1,70 @@
+package kor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// finalizerManager is a field manager owning some of the finalizers of an object
+type finalizerManager struct {
+	manager    string
+	operation  metav1.ManagedFieldsOperationType
+	time       *metav1.Time
+	finalizers []string
+}
+
+// finalizerManagers reads which field managers set the finalizers of an object, and when, from its
+// managedFields. Objects without a managedFields entry for the finalizers return no managers.
+func finalizerManagers(obj metav1.Object) []finalizerManager {
+	var managers []finalizerManager
+	for _, entry := range obj.GetManagedFields() {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		finalizerFields, ok := fields["f:metadata"]["f:finalizers"]
+		if !ok {
+			continue
+		}
+
+		manager := finalizerManager{manager: entry.Manager, operation: entry.Operation, time: entry.Time}
+		for key := range finalizerFields {
+			var finalizer string
+			if value, isValue := strings.CutPrefix(key, "v:"); isValue && json.Unmarshal([]byte(value), &finalizer) == nil {
+				manager.finalizers = append(manager.finalizers, finalizer)
+			}
+		}
+		sort.Strings(manager.finalizers)
+		managers = append(managers, manager)
+	}
+	return managers
+}
+
+// describeFinalizerManagers summarizes who set the finalizers of an object and how long ago
+func describeFinalizerManagers(managers []finalizerManager) string {
+	if len(managers) == 0 {
+		return ""
+	}
+	descriptions := make([]string, 0, len(managers))
+	for _, manager := range managers {
+		details := append([]string{}, manager.finalizers...)
+		if manager.time != nil {
+			details = append(details, fmt.Sprintf("%s %s ago", strings.ToLower(string(manager.operation)), duration.HumanDuration(time.Since(manager.time.Time))))
+		}
+		description := manager.manager
+		if len(details) > 0 {
+			description += " (" + strings.Join(details, ", ") + ")"
+		}
+		descriptions = append(descriptions, description)
+	}
+	return "finalizers set by " + strings.Join(descriptions, ", ")
+}
@@ -0,0 +1,44 @@
+package kor
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFinalizerManagers(t *testing.T) {
+	setAt := metav1.NewTime(time.Now().Add(-3 * time.Hour))
+	obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "stuck")
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{
+			Manager:    "cleanup-controller",
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			Time:       &setAt,
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:finalizers":{".":{},"v:\"example.com/cleanup\"":{}}}}`)},
+		},
+		{
+			Manager:    "kubectl",
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{"f:app":{}}}}`)},
+		},
+		{
+			Manager:    "policy-controller",
+			Operation:  metav1.ManagedFieldsOperationApply,
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:finalizers":{"v:\"example.com/policy\"":{}}}}`)},
+		},
+	})
+
+	expected := "finalizers set by cleanup-controller (example.com/cleanup, update 3h ago), policy-controller (example.com/policy)"
+	if description := describeFinalizerManagers(finalizerManagers(obj)); description != expected {
+		t.Errorf("Expected %q, got %q", expected, description)
+	}
+
+	withoutManagedFields := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "unmanaged")
+	if description := describeFinalizerManagers(finalizerManagers(withoutManagedFields)); description != "" {
+		t.Errorf("Expected no managers for an object without managedFields, got %q", description)
+	}
+}
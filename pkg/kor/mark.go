@@ -0,0 +1,79 @@
+package kor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// markLabelLayout renders the deletion time as a valid label value, which cannot contain colons
+const markLabelLayout = "20060102T150405Z"
+
+// markPatch builds the merge patch setting the configured label and annotation to when the deletion of
+// the object was requested
+func markPatch(deletionTimestamp time.Time, opts Opts) ([]byte, error) {
+	metadata := make(map[string]interface{})
+	if opts.MarkLabel != "" {
+		metadata["labels"] = map[string]string{opts.MarkLabel: deletionTimestamp.UTC().Format(markLabelLayout)}
+	}
+	if opts.MarkAnnotation != "" {
+		metadata["annotations"] = map[string]string{opts.MarkAnnotation: deletionTimestamp.UTC().Format(time.RFC3339)}
+	}
+	return json.Marshal(map[string]interface{}{"metadata": metadata})
+}
+
+// markStuckResources labels and annotates the stuck objects with the time their deletion was requested,
+// without touching their finalizers, so label driven tooling can pick them up. Objects marked as used are
+// never reported as stuck, so they are not marked either.
+func markStuckResources(ctx context.Context, items []stuckItem, dynamicClient dynamic.Interface, opts Opts) {
+	throttle := withDeleteThrottle(opts).throttle
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return
+		}
+		// Objects with dangling finalizers are reported before their deletion was requested
+		if item.object.GetDeletionTimestamp() == nil {
+			continue
+		}
+		namespace, name := item.object.GetNamespace(), item.object.GetName()
+		patch, err := markPatch(item.object.GetDeletionTimestamp().Time, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to mark %s %s in namespace %s: %v\n", item.gvr.Resource, name, namespace, err)
+			continue
+		}
+		if err := throttle.do(func() error {
+			_, err := dynamicClient.
+				Resource(item.gvr).
+				Namespace(namespace).
+				Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{DryRun: dryRun(opts)})
+			return err
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to mark %s %s in namespace %s: %v\n", item.gvr.Resource, name, namespace, err)
+		}
+	}
+}
+
+// stuckItemsFor returns the stuck objects that are still reported in the response, whose resource types are named by keys
+func stuckItemsFor(items []stuckItem, response map[string]map[string][]ResourceInfo, keys resourceTypeKeys) []stuckItem {
+	reported := make(map[string]bool)
+	for namespace, resources := range response {
+		for resource, infos := range resources {
+			for _, info := range infos {
+				reported[namespace+"/"+resource+"/"+info.Name] = true
+			}
+		}
+	}
+	var kept []stuckItem
+	for _, item := range items {
+		if reported[reportedNamespace(item.object.GetNamespace())+"/"+keys.key(item.gvr)+"/"+item.object.GetName()] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,53 @@
+package kor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func TestMarkStuckResources(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	deletionTime := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	stuck := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.SetDeletionTimestamp(&metav1.Time{Time: deletionTime})
+	other := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "other")
+	other.SetFinalizers([]string{"example.com/cleanup"})
+	other.SetDeletionTimestamp(&metav1.Time{Time: deletionTime})
+
+	scheme := runtime.NewScheme()
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(scheme,
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck, other)
+
+	items := []stuckItem{{object: stuck, gvr: gvr}, {object: other, gvr: gvr}}
+	response := map[string]map[string][]ResourceInfo{testNamespace: {gvr.Resource: {{Name: "stuck"}}}}
+	markStuckResources(context.TODO(), stuckItemsFor(items, response, nil), dynamicClient, Opts{MarkLabel: "kor/stuck-since", MarkAnnotation: "kor/stuck-since"})
+
+	marked, err := dynamicClient.Resource(gvr).Namespace(testNamespace).Get(context.TODO(), "stuck", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting the marked object: %v", err)
+	}
+	if label := marked.GetLabels()["kor/stuck-since"]; label != "20240301T123000Z" {
+		t.Errorf("Expected the stuck-since label to be set, got %q", label)
+	}
+	if annotation := marked.GetAnnotations()["kor/stuck-since"]; annotation != "2024-03-01T12:30:00Z" {
+		t.Errorf("Expected the stuck-since annotation to be set, got %q", annotation)
+	}
+	if len(marked.GetFinalizers()) != 1 {
+		t.Errorf("Expected the finalizers to be kept, got %v", marked.GetFinalizers())
+	}
+
+	unreported, err := dynamicClient.Resource(gvr).Namespace(testNamespace).Get(context.TODO(), "other", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting the unreported object: %v", err)
+	}
+	if _, ok := unreported.GetLabels()["kor/stuck-since"]; ok {
+		t.Error("Expected objects missing from the response not to be marked")
+	}
+}
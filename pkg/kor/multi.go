@@ -2,6 +2,7 @@ package kor
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -97,17 +98,20 @@ func retrieveNamespaceDiffs(clientset kubernetes.Interface, namespace string, re
 }
 
 func GetUnusedMulti(resourceNames string, filterOpts *filters.Options, clientset kubernetes.Interface, apiExtClient apiextensionsclientset.Interface, dynamicClient dynamic.Interface, outputFormat string, opts Opts) (string, error) {
+	opts = withDeleteThrottle(opts)
 	resourceList := strings.Split(resourceNames, ",")
-	namespaces := filterOpts.Namespaces(clientset)
+	namespaces, err := filterOpts.Namespaces(clientset)
+	if err != nil {
+		return "", err
+	}
 	resources := make(map[string]map[string][]ResourceInfo)
-	var err error
 
 	noNamespaceDiff, resourceList := retrieveNoNamespaceDiff(clientset, apiExtClient, dynamicClient, resourceList, filterOpts)
 	if len(noNamespaceDiff) != 0 {
 		for _, diff := range noNamespaceDiff {
 			if len(diff.diff) != 0 {
 				if opts.DeleteFlag {
-					if diff.diff, err = DeleteResource(diff.diff, clientset, "", diff.resourceType, opts.NoInteractive); err != nil {
+					if diff.diff, err = DeleteResource(context.TODO(), diff.diff, clientset, "", diff.resourceType, filterOpts, opts); err != nil {
 						fmt.Fprintf(os.Stderr, "Failed to delete %s %s: %v\n", diff.resourceType, diff.diff, err)
 					}
 				}
@@ -126,7 +130,7 @@ func GetUnusedMulti(resourceNames string, filterOpts *filters.Options, clientset
 		allDiffs := retrieveNamespaceDiffs(clientset, namespace, resourceList, filterOpts)
 		for _, diff := range allDiffs {
 			if opts.DeleteFlag {
-				if diff.diff, err = DeleteResource(diff.diff, clientset, namespace, diff.resourceType, opts.NoInteractive); err != nil {
+				if diff.diff, err = DeleteResource(context.TODO(), diff.diff, clientset, namespace, diff.resourceType, filterOpts, opts); err != nil {
 					fmt.Fprintf(os.Stderr, "Failed to delete %s %s in namespace %s: %v\n", diff.resourceType, diff.diff, namespace, err)
 				}
 			}
@@ -0,0 +1,236 @@
+package kor
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+// ClusterTarget is a cluster scanned by ScanClusters
+type ClusterTarget struct {
+	// Name identifies the cluster in the results and the output
+	Name string
+	ClientOptions
+}
+
+// ClusterResult is the output of the finalizer scan of a cluster, or why it failed
+type ClusterResult struct {
+	Output string
+	Err    error
+}
+
+// buildClusterClients builds the clients a cluster is scanned with, replaced in tests
+var buildClusterClients = BuildClients
+
+// ContextTargets returns a cluster target per context of the kubeconfig, named after the context
+func ContextTargets(kubeconfig string, contexts []string) []ClusterTarget {
+	kubeconfig = ResolveKubeconfig(kubeconfig)
+	targets := make([]ClusterTarget, 0, len(contexts))
+	for _, kubeContext := range contexts {
+		targets = append(targets, ClusterTarget{
+			Name:          kubeContext,
+			ClientOptions: ClientOptions{Kubeconfig: kubeconfig, Context: kubeContext, QPS: ClientQPS, Burst: ClientBurst},
+		})
+	}
+	return targets
+}
+
+// KubeconfigTargets returns a cluster target per kubeconfig, using its current context and named after its path
+func KubeconfigTargets(kubeconfigs []string) []ClusterTarget {
+	targets := make([]ClusterTarget, 0, len(kubeconfigs))
+	for _, kubeconfig := range kubeconfigs {
+		targets = append(targets, ClusterTarget{
+			Name:          kubeconfig,
+			ClientOptions: ClientOptions{Kubeconfig: kubeconfig, QPS: ClientQPS, Burst: ClientBurst},
+		})
+	}
+	return targets
+}
+
+// validateClusterScan rejects the options that cannot be shared by scans of several clusters
+func validateClusterScan(clusters []ClusterTarget, opts Opts) error {
+	names := make(map[string]bool, len(clusters))
+	for _, cluster := range clusters {
+		if names[cluster.Name] {
+			return fmt.Errorf("cluster %q is given more than once", cluster.Name)
+		}
+		names[cluster.Name] = true
+	}
+	if opts.ResourceVersionFile != "" || opts.MetricsTextfile != "" {
+		return errors.New("--resource-version-file and --metrics-textfile are kept per cluster, they cannot be used when scanning several clusters")
+	}
+	if (opts.DeleteFlag || opts.RemoveFinalizers) && !opts.NoInteractive {
+		return errors.New("clusters are scanned concurrently and cannot prompt for confirmation, use --no-interactive to delete across clusters")
+	}
+	if err := validateRedaction(opts); err != nil {
+		return err
+	}
+	return validateConfirmationToken(opts)
+}
+
+// ScanClusters runs the finalizer scan of every cluster concurrently and returns the results keyed by
+// cluster name. Each scan gets its own copy of the filter options and is identified by the cluster name,
+// e.g. as the cloudevents source. Use FormatClusterResults to combine them into a single report.
+func ScanClusters(ctx context.Context, filterOpts *filters.Options, clusters []ClusterTarget, outputFormat string, opts Opts) (map[string]ClusterResult, error) {
+	if err := validateClusterScan(clusters, opts); err != nil {
+		return nil, err
+	}
+	// The clusters share the redaction key, so a single key maps the names of the combined report back
+	opts, err := withRedactionKey(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]ClusterResult, len(clusters))
+	for _, cluster := range clusters {
+		wg.Add(1)
+		go func(cluster ClusterTarget) {
+			defer wg.Done()
+			output, err := scanCluster(ctx, filterOpts.Clone(), cluster, outputFormat, opts)
+			mu.Lock()
+			defer mu.Unlock()
+			results[cluster.Name] = ClusterResult{Output: output, Err: err}
+		}(cluster)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// GetUnusedFinalizersMultiCluster scans every cluster and returns the combined report, see FormatClusterResults,
+// and writes it to the output file. The scan errors of the clusters are returned along with the report.
+func GetUnusedFinalizersMultiCluster(ctx context.Context, filterOpts *filters.Options, clusters []ClusterTarget, outputFormat string, opts Opts) (string, error) {
+	results, err := ScanClusters(ctx, filterOpts, clusters, outputFormat, opts)
+	if err != nil {
+		return "", err
+	}
+	output, err := FormatClusterResults(results, outputFormat)
+	if err != nil {
+		return "", err
+	}
+	if opts.OutputFile != "" {
+		if err := writeOutputFile(opts.OutputFile, output, time.Now()); err != nil {
+			return "", err
+		}
+	}
+
+	var errs []error
+	for _, cluster := range sortedKeys(results) {
+		if err := results[cluster].Err; err != nil {
+			errs = append(errs, fmt.Errorf("cluster %s: %w", cluster, err))
+		}
+	}
+	return output, errors.Join(errs...)
+}
+
+func scanCluster(ctx context.Context, filterOpts *filters.Options, cluster ClusterTarget, outputFormat string, opts Opts) (string, error) {
+	clients, err := buildClusterClients(cluster.ClientOptions)
+	if err != nil {
+		return "", err
+	}
+	opts.ClusterName = cluster.Name
+	opts.BackupDir = clusterBackupDir(opts.BackupDir, cluster.Name)
+	// The combined report is written once every cluster is scanned
+	opts.OutputFile = ""
+	opts.DiscoveryClient = nil
+	if opts.DiscoveryCacheDir != "" {
+		if opts.DiscoveryClient, err = newCachedDiscoveryClient(clients.Config, opts.DiscoveryCacheDir, opts.DiscoveryCacheTTL); err != nil {
+			return "", err
+		}
+	}
+	return GetUnusedfinalizers(ctx, filterOpts, clients.Clientset, clients.Dynamic, outputFormat, opts)
+}
+
+// FormatClusterResults combines the outputs of the scanned clusters into a single report identifying the
+// cluster of every finding: json and yaml are keyed by cluster, csv and ndjson get a cluster column, and
+// the other formats a section per cluster. Clusters whose scan failed are left out, unless their output is
+// complete anyway, see HasOutput.
+func FormatClusterResults(results map[string]ClusterResult, outputFormat string) (string, error) {
+	var clusters []string
+	for _, cluster := range sortedKeys(results) {
+		if HasOutput(results[cluster].Err) {
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	switch outputFormat {
+	case "json", "yaml":
+		combined := make(map[string]json.RawMessage, len(clusters))
+		for _, cluster := range clusters {
+			output := []byte(results[cluster].Output)
+			if outputFormat == "yaml" {
+				var err error
+				if output, err = yaml.YAMLToJSON(output); err != nil {
+					return "", fmt.Errorf("invalid output of cluster %s: %w", cluster, err)
+				}
+			}
+			combined[cluster] = output
+		}
+		output, err := json.MarshalIndent(combined, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		if outputFormat == "yaml" {
+			output, err = yaml.JSONToYAML(output)
+		}
+		return string(output), err
+	case "csv":
+		var buffer bytes.Buffer
+		writer := csv.NewWriter(&buffer)
+		for i, cluster := range clusters {
+			records, err := csv.NewReader(strings.NewReader(results[cluster].Output)).ReadAll()
+			if err != nil {
+				return "", fmt.Errorf("invalid output of cluster %s: %w", cluster, err)
+			}
+			for j, record := range records {
+				if j == 0 {
+					// Every cluster has the same header, it is only written once
+					if i == 0 {
+						writer.Write(append([]string{"cluster"}, record...))
+					}
+					continue
+				}
+				writer.Write(append([]string{cluster}, record...))
+			}
+		}
+		writer.Flush()
+		return buffer.String(), writer.Error()
+	case "ndjson":
+		var buffer bytes.Buffer
+		for _, cluster := range clusters {
+			name, err := json.Marshal(cluster)
+			if err != nil {
+				return "", err
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(results[cluster].Output, "\n"), "\n") {
+				// Every line is a json object, the cluster is added as its first field
+				if !strings.HasPrefix(line, "{") {
+					continue
+				}
+				separator := ","
+				if strings.TrimSpace(line[1:]) == "}" {
+					separator = ""
+				}
+				fmt.Fprintf(&buffer, "{\"cluster\":%s%s%s\n", name, separator, line[1:])
+			}
+		}
+		return buffer.String(), nil
+	}
+
+	var buffer bytes.Buffer
+	for _, cluster := range clusters {
+		fmt.Fprintf(&buffer, "Cluster: %q\n%s\n", cluster, strings.TrimSuffix(results[cluster].Output, "\n"))
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,100 @@
+package kor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+// discoveryClientset serves the given discovery, the fake clientset discovery has no preferred resources
+type discoveryClientset struct {
+	kubernetes.Interface
+	discovery discovery.DiscoveryInterface
+}
+
+func (c discoveryClientset) Discovery() discovery.DiscoveryInterface {
+	return c.discovery
+}
+
+func newTestClusterClients(stuck ...string) *Clients {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	var objects []runtime.Object
+	for _, name := range stuck {
+		obj := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, name)
+		obj.SetFinalizers([]string{"example.com/cleanup"})
+		obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, obj)
+	}
+	var discoveryCalls int
+	return &Clients{
+		Clientset: discoveryClientset{
+			Interface: fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}),
+			discovery: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+				GroupVersion: "testgroup/v1",
+				APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+			}}},
+		},
+		Dynamic: fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, objects...),
+	}
+}
+
+func TestGetUnusedFinalizersMultiCluster(t *testing.T) {
+	clients := map[string]*Clients{
+		"prod":    newTestClusterClients("stuck-prod"),
+		"staging": newTestClusterClients("stuck-staging-1", "stuck-staging-2"),
+	}
+	defer func(build func(ClientOptions) (*Clients, error)) { buildClusterClients = build }(buildClusterClients)
+	buildClusterClients = func(opts ClientOptions) (*Clients, error) {
+		if clients[opts.Context] == nil {
+			return nil, errors.New("context not found")
+		}
+		return clients[opts.Context], nil
+	}
+	clusters := ContextTargets("kubeconfig", []string{"prod", "staging", "missing"})
+
+	output, err := GetUnusedFinalizersMultiCluster(context.TODO(), &filters.Options{}, clusters, "json", Opts{GroupBy: "namespace"})
+	if err == nil || !strings.Contains(err.Error(), "cluster missing: context not found") {
+		t.Errorf("Expected the error of the missing cluster, got %v", err)
+	}
+	var report map[string]map[string]map[string][]ResourceInfo
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Expected a json report keyed by cluster, got %q: %v", output, err)
+	}
+	if len(report) != 2 || len(report["prod"][testNamespace]["testresources"]) != 1 || len(report["staging"][testNamespace]["testresources"]) != 2 {
+		t.Errorf("Unexpected report %v", report)
+	}
+
+	output, _ = GetUnusedFinalizersMultiCluster(context.TODO(), &filters.Options{}, clusters[:2], "csv", Opts{GroupBy: "namespace"})
+	expected := "cluster,namespace,resourceType,resourceName\n" +
+		"prod,test-namespace,testresources,stuck-prod\n" +
+		"staging,test-namespace,testresources,stuck-staging-1\n" +
+		"staging,test-namespace,testresources,stuck-staging-2\n"
+	if output != expected {
+		t.Errorf("Expected csv:\n%s\ngot:\n%s", expected, output)
+	}
+
+	output, _ = GetUnusedFinalizersMultiCluster(context.TODO(), &filters.Options{}, clusters[:2], "ndjson", Opts{GroupBy: "namespace"})
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], `{"cluster":"prod","namespace":"test-namespace"`) {
+		t.Errorf("Expected a line per finding starting with its cluster, got:\n%s", output)
+	}
+
+	if _, err := GetUnusedFinalizersMultiCluster(context.TODO(), &filters.Options{}, clusters, "json", Opts{GroupBy: "namespace", DeleteFlag: true}); err == nil {
+		t.Error("Expected interactive deletion across clusters to be rejected")
+	}
+}
@@ -0,0 +1,175 @@
+package kor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const defaultOwnerDepth = 5
+
+type ownerResource struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+// ownerLookup is a cached get of a single owner, shared by every object referencing it
+type ownerLookup struct {
+	once  sync.Once
+	owner *unstructured.Unstructured
+	err   error
+}
+
+// ownerResolver walks the owner references of an object up to a maximum depth.
+// It is safe for concurrent use.
+type ownerResolver struct {
+	ctx           context.Context // the scan the lookups belong to
+	dynamicClient dynamic.Interface
+	resources     map[schema.GroupKind]ownerResource
+	maxDepth      int
+
+	mu    sync.Mutex
+	cache map[string]*ownerLookup
+}
+
+// ownerChain lists the owners of an object, closest owner first.
+// partial is set when the walk stopped before reaching the root owner.
+type ownerChain struct {
+	owners  []string
+	partial string
+}
+
+func (c ownerChain) String() string {
+	if len(c.owners) == 0 {
+		return ""
+	}
+	chain := strings.Join(c.owners, " -> ")
+	if c.partial != "" {
+		chain += " (" + c.partial + ")"
+	}
+	return chain
+}
+
+func newOwnerResolver(ctx context.Context, resourceTypes []*metav1.APIResourceList, dynamicClient dynamic.Interface, maxDepth int) *ownerResolver {
+	if maxDepth <= 0 {
+		maxDepth = defaultOwnerDepth
+	}
+	resources := make(map[schema.GroupKind]ownerResource)
+	for _, apiResourceList := range resourceTypes {
+		gv, err := schema.ParseGroupVersion(apiResourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resourceType := range apiResourceList.APIResources {
+			if strings.Contains(resourceType.Name, "/") {
+				continue
+			}
+			resources[schema.GroupKind{Group: gv.Group, Kind: resourceType.Kind}] = ownerResource{
+				gvr:        gv.WithResource(resourceType.Name),
+				namespaced: resourceType.Namespaced,
+			}
+		}
+	}
+	return &ownerResolver{
+		ctx:           ctx,
+		dynamicClient: dynamicClient,
+		resources:     resources,
+		maxDepth:      maxDepth,
+		cache:         make(map[string]*ownerLookup),
+	}
+}
+
+// ownerReferenceOf returns the controller reference of an object, or its first owner reference
+func ownerReferenceOf(obj metav1.Object) *metav1.OwnerReference {
+	if ref := metav1.GetControllerOfNoCopy(obj); ref != nil {
+		return ref
+	}
+	if refs := obj.GetOwnerReferences(); len(refs) > 0 {
+		return &refs[0]
+	}
+	return nil
+}
+
+func (r *ownerResolver) get(namespace string, ref *metav1.OwnerReference) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	resource, ok := r.resources[schema.GroupKind{Group: gv.Group, Kind: ref.Kind}]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %s", ref.Kind)
+	}
+	if !resource.namespaced {
+		namespace = ""
+	}
+
+	key := resource.gvr.String() + "/" + namespace + "/" + ref.Name
+	r.mu.Lock()
+	lookup, ok := r.cache[key]
+	if !ok {
+		lookup = &ownerLookup{}
+		r.cache[key] = lookup
+	}
+	r.mu.Unlock()
+
+	lookup.once.Do(func() {
+		lookup.owner, lookup.err = r.dynamicClient.
+			Resource(resource.gvr).
+			Namespace(namespace).
+			Get(r.ctx, ref.Name, metav1.GetOptions{})
+	})
+	return lookup.owner, lookup.err
+}
+
+// controllerExists checks if the controlling owner of an object still exists. Owners that cannot be
+// resolved, e.g. of an unknown kind, count as gone so their objects are still reported.
+func (r *ownerResolver) controllerExists(obj *unstructured.Unstructured) bool {
+	ref := metav1.GetControllerOfNoCopy(obj)
+	if ref == nil {
+		return false
+	}
+	_, err := r.get(obj.GetNamespace(), ref)
+	return err == nil
+}
+
+// resolve follows owner references until the root owner, the depth limit or a cycle is reached
+func (r *ownerResolver) resolve(obj *unstructured.Unstructured) ownerChain {
+	var chain ownerChain
+	visited := map[string]bool{obj.GetKind() + "/" + obj.GetName(): true}
+	current := obj
+	for {
+		ref := ownerReferenceOf(current)
+		if ref == nil {
+			return chain
+		}
+		if len(chain.owners) == r.maxDepth {
+			chain.partial = fmt.Sprintf("depth limit of %d reached", r.maxDepth)
+			return chain
+		}
+		owner := ref.Kind + "/" + ref.Name
+		if visited[owner] {
+			chain.partial = fmt.Sprintf("owner reference cycle at %s", owner)
+			return chain
+		}
+		visited[owner] = true
+		chain.owners = append(chain.owners, owner)
+
+		next, err := r.get(current.GetNamespace(), ref)
+		if apierrors.IsNotFound(err) {
+			chain.partial = fmt.Sprintf("%s is missing", owner)
+			return chain
+		}
+		if err != nil {
+			chain.partial = fmt.Sprintf("%s could not be resolved: %v", owner, err)
+			return chain
+		}
+		current = next
+	}
+}
@@ -0,0 +1,100 @@
+package kor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/utils/ptr"
+)
+
+func setTestOwner(obj *unstructured.Unstructured, kind, name string) {
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "testgroup/v1", Kind: kind, Name: name, Controller: ptr.To(true)},
+	})
+}
+
+func TestOwnerResolverResolve(t *testing.T) {
+	child := CreateTestUnstructered("Child", "testgroup/v1", testNamespace, "child")
+	setTestOwner(child, "Parent", "parent")
+	parent := CreateTestUnstructered("Parent", "testgroup/v1", testNamespace, "parent")
+	setTestOwner(parent, "Root", "root")
+	root := CreateTestUnstructered("Root", "testgroup/v1", testNamespace, "root")
+
+	orphan := CreateTestUnstructered("Child", "testgroup/v1", testNamespace, "orphan")
+	setTestOwner(orphan, "Parent", "missing-parent")
+
+	cycleA := CreateTestUnstructered("Parent", "testgroup/v1", testNamespace, "cycle-a")
+	setTestOwner(cycleA, "Root", "cycle-b")
+	cycleB := CreateTestUnstructered("Root", "testgroup/v1", testNamespace, "cycle-b")
+	setTestOwner(cycleB, "Parent", "cycle-a")
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), parent, root, cycleA, cycleB)
+	apiResourceLists := []*metav1.APIResourceList{
+		{
+			GroupVersion: "testgroup/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "parents", Kind: "Parent", Namespaced: true},
+				{Name: "roots", Kind: "Root", Namespaced: true},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		object   *unstructured.Unstructured
+		maxDepth int
+		expected string
+	}{
+		{"FullChain", child, 0, "Parent/parent -> Root/root"},
+		{"DepthLimit", child, 1, "Parent/parent (depth limit of 1 reached)"},
+		{"MissingOwner", orphan, 0, "Parent/missing-parent (Parent/missing-parent is missing)"},
+		{"Cycle", cycleA, 0, "Root/cycle-b (owner reference cycle at Parent/cycle-a)"},
+		{"NoOwner", root, 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolver := newOwnerResolver(context.TODO(), apiResourceLists, dynamicClient, test.maxDepth)
+			if chain := resolver.resolve(test.object).String(); chain != test.expected {
+				t.Errorf("Expected owner chain %q, got %q", test.expected, chain)
+			}
+		})
+	}
+}
+
+func TestOwnerResolverSharedOwners(t *testing.T) {
+	parent := CreateTestUnstructered("Parent", "testgroup/v1", testNamespace, "parent")
+	dynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), parent)
+	apiResourceLists := []*metav1.APIResourceList{
+		{
+			GroupVersion: "testgroup/v1",
+			APIResources: []metav1.APIResource{{Name: "parents", Kind: "Parent", Namespaced: true}},
+		},
+	}
+
+	children := make([]*unstructured.Unstructured, 20)
+	for i := range children {
+		children[i] = CreateTestUnstructered("Child", "testgroup/v1", testNamespace, fmt.Sprintf("child-%d", i))
+		setTestOwner(children[i], "Parent", "parent")
+	}
+
+	resolver := newOwnerResolver(context.TODO(), apiResourceLists, dynamicClient, 0)
+	chains := make([]string, len(children))
+	forEachConcurrently(len(children), 4, func(i int) {
+		chains[i] = resolver.resolve(children[i]).String()
+	})
+
+	for i, chain := range chains {
+		if chain != "Parent/parent" {
+			t.Errorf("Expected owner chain Parent/parent for child %d, got %q", i, chain)
+		}
+	}
+	if gets := len(dynamicClient.Actions()); gets != 1 {
+		t.Errorf("Expected the shared owner to be fetched once, got %d calls", gets)
+	}
+}
@@ -0,0 +1,61 @@
+package kor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// progressInterval is how often the progress of a finalizer scan is reported at most, the last resource type is always reported
+const progressInterval = time.Second
+
+// scanProgress reports how far a finalizer scan got, so a scan of a big cluster does not appear to hang
+type scanProgress struct {
+	w         io.Writer
+	cluster   string
+	total     int // resource types to list
+	listed    int
+	flagged   int
+	lastPrint time.Time
+}
+
+// newScanProgress counts the resource types a finalizer scan lists, the cluster scoped ones only when they are scanned
+func newScanProgress(resourceLists []*metav1.APIResourceList, clusterScoped bool, opts Opts) *scanProgress {
+	progress := &scanProgress{w: os.Stderr, cluster: opts.ClusterName}
+	for _, resourceList := range resourceLists {
+		for _, resourceType := range resourceList.APIResources {
+			if slices.Contains(resourceType.Verbs, "list") && (resourceType.Namespaced || clusterScoped) {
+				progress.total++
+			}
+		}
+	}
+	return progress
+}
+
+// resourceTypeListed records a resource type whose objects were listed, and how many of them were flagged
+func (p *scanProgress) resourceTypeListed(gvr schema.GroupVersionResource, namespaced bool, flagged int) {
+	if p == nil {
+		return
+	}
+	p.listed++
+	p.flagged += flagged
+	if p.listed < p.total && time.Since(p.lastPrint) < progressInterval {
+		return
+	}
+	p.lastPrint = time.Now()
+
+	scope := "all namespaces"
+	if !namespaced {
+		scope = "cluster scoped"
+	}
+	var cluster string
+	if p.cluster != "" {
+		cluster = fmt.Sprintf("cluster %s: ", p.cluster)
+	}
+	fmt.Fprintf(p.w, "%s[%d/%d] listed %s (%s), %d resources flagged so far\n", cluster, p.listed, p.total, gvr.GroupResource(), scope, p.flagged)
+}
@@ -0,0 +1,67 @@
+package kor
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+func TestScanProgress(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	clusterGVR := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "clusterresources"}
+	var objects []runtime.Object
+	for _, name := range []string{"stuck-1", "stuck-2"} {
+		stuck := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, name)
+		stuck.SetFinalizers([]string{"example.com/cleanup"})
+		stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		objects = append(objects, stuck)
+	}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList", clusterGVR: "ClusterResourceList"}, objects...)
+	resourceLists := []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true},
+			{Name: "clusterresources", Kind: "ClusterResource", Verbs: []string{"list"}},
+			{Name: "unlistables", Kind: "Unlistable", Verbs: []string{"get"}, Namespaced: true},
+		},
+	}}
+
+	if total := newScanProgress(resourceLists, false, Opts{}).total; total != 1 {
+		t.Errorf("Expected only the namespaced resource type to be counted, got %d", total)
+	}
+	var output bytes.Buffer
+	progress := newScanProgress(resourceLists, true, Opts{ClusterName: "prod"})
+	progress.w = &output
+	opts := Opts{progress: progress}
+	if _, err := getResourcesWithFinalizersPendingDeletion(context.TODO(), resourceLists, dynamicClient, &filters.Options{}, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := getClusterScopedResourcesWithFinalizersPendingDeletion(context.TODO(), resourceLists, dynamicClient, &filters.Options{}, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The second resource type is listed within the interval, but is the last one and always reported
+	expected := "cluster prod: [1/2] listed testresources.testgroup (all namespaces), 2 resources flagged so far\n" +
+		"cluster prod: [2/2] listed clusterresources.testgroup (cluster scoped), 2 resources flagged so far\n"
+	if output.String() != expected {
+		t.Errorf("Expected progress:\n%s\ngot:\n%s", expected, output.String())
+	}
+
+	output.Reset()
+	progress.lastPrint = time.Now()
+	progress.total = 10
+	progress.resourceTypeListed(gvr, true, 0)
+	if strings.Contains(output.String(), "listed") {
+		t.Errorf("Expected the progress to be reported at most every %s, got %q", progressInterval, output.String())
+	}
+}
@@ -45,13 +45,14 @@ func processPvs(clientset kubernetes.Interface, filterOpts *filters.Options) ([]
 }
 
 func GetUnusedPvs(filterOpts *filters.Options, clientset kubernetes.Interface, outputFormat string, opts Opts) (string, error) {
+	opts = withDeleteThrottle(opts)
 	resources := make(map[string]map[string][]ResourceInfo)
 	diff, err := processPvs(clientset, filterOpts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to process pvs: %v\n", err)
 	}
 	if opts.DeleteFlag {
-		if diff, err = DeleteResource(diff, clientset, "", "PV", opts.NoInteractive); err != nil {
+		if diff, err = DeleteResource(context.TODO(), diff, clientset, "", "PV", filterOpts, opts); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to delete PV %s: %v\n", diff, err)
 		}
 	}
@@ -0,0 +1,126 @@
+package kor
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// redactedNamePrefix prefixes the redacted names, followed by the first 8 hex digits of their keyed hash
+const redactedNamePrefix = "redacted-"
+
+// RedactName returns the redacted name of a resource: a stable keyed hash, the same for a name across runs
+// with the same key. Without the key the name cannot be recovered, with it the name can be mapped back by
+// redacting the names of the scanned resources again, see Unredact.
+func RedactName(key, name string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(name))
+	return redactedNamePrefix + hex.EncodeToString(mac.Sum(nil))[:8]
+}
+
+// withRedactionKey generates the RedactionKey of a run with RedactNames when none is given, and reports it
+// on stderr, so the person running the scan can keep it to map the names back
+func withRedactionKey(opts Opts) (Opts, error) {
+	if !opts.RedactNames || opts.RedactionKey != "" {
+		return opts, nil
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return opts, fmt.Errorf("failed to generate the redaction key: %w", err)
+	}
+	opts.RedactionKey = hex.EncodeToString(key)
+	fmt.Fprintf(os.Stderr, "Names are redacted with the key %s, keep it to map them back with --unredact\n", opts.RedactionKey)
+	return opts, nil
+}
+
+// validateRedaction rejects the options exposing or acting on the names of the resources a redacted scan reports
+func validateRedaction(opts Opts) error {
+	if len(opts.Unredact) > 0 && opts.RedactionKey == "" {
+		return errors.New("--unredact needs the --redaction-key the names were redacted with")
+	}
+	if !opts.RedactNames {
+		return nil
+	}
+	if opts.DeleteFlag || opts.RemoveFinalizers || opts.MarkLabel != "" || opts.MarkAnnotation != "" {
+		return errors.New("redacted names are for sharing reports, --redact-names cannot be used to delete, remove finalizers or mark resources")
+	}
+	if opts.ShowOwners || opts.Explain || opts.OwnerTree {
+		return errors.New("owners are reported by name, --redact-names cannot be used with --show-owners, --explain or the tree output")
+	}
+	return nil
+}
+
+// redactedName returns the name of a resource as it is reported, redacted with RedactNames
+func redactedName(name string, opts Opts) string {
+	if !opts.RedactNames {
+		return name
+	}
+	return RedactName(opts.RedactionKey, name)
+}
+
+// redactResourceInfos returns the resources with their names redacted with RedactNames, the namespace and
+// resource type they are reported under are kept
+func redactResourceInfos(infos []ResourceInfo, opts Opts) []ResourceInfo {
+	if !opts.RedactNames {
+		return infos
+	}
+	redacted := slices.Clone(infos)
+	for i := range redacted {
+		redacted[i].Name = RedactName(opts.RedactionKey, redacted[i].Name)
+	}
+	return redacted
+}
+
+// redactFindings returns the findings with their names redacted with RedactNames. Their labels are dropped,
+// they often carry the name as well.
+func redactFindings(findings []FinalizerFinding, opts Opts) []FinalizerFinding {
+	if !opts.RedactNames {
+		return findings
+	}
+	redacted := slices.Clone(findings)
+	for i := range redacted {
+		redacted[i].Name = RedactName(opts.RedactionKey, redacted[i].Name)
+		redacted[i].Labels = nil
+	}
+	return redacted
+}
+
+// Unredact returns the findings whose redacted name with the key is one of the given redacted names
+func Unredact(key string, redactedNames []string, findings []FinalizerFinding) map[string][]FinalizerFinding {
+	unredacted := make(map[string][]FinalizerFinding)
+	for _, finding := range findings {
+		if name := RedactName(key, finding.Name); slices.Contains(redactedNames, name) {
+			unredacted[name] = append(unredacted[name], finding)
+		}
+	}
+	return unredacted
+}
+
+// formatUnredacted renders the names the redacted names of a report map back to, the names that are not
+// found any more are reported as well
+func formatUnredacted(redactedNames []string, unredacted map[string][]FinalizerFinding) string {
+	var buf bytes.Buffer
+	table := tablewriter.NewWriter(&buf)
+	table.SetHeader([]string{"#", "REDACTED NAME", "NAMESPACE", "RESOURCE TYPE", "NAME"})
+	var index int
+	for _, redacted := range redactedNames {
+		if len(unredacted[redacted]) == 0 {
+			table.Append(getTableRow(index, redacted, "", "", "not found"))
+			index++
+		}
+		for _, finding := range unredacted[redacted] {
+			table.Append(getTableRow(index, redacted, reportedNamespace(finding.Namespace), finding.GroupVersionResource.GroupResource().String(), finding.Name))
+			index++
+		}
+	}
+	table.Render()
+	return buf.String()
+}
@@ -0,0 +1,115 @@
+package kor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+func TestRedactName(t *testing.T) {
+	redacted := RedactName("key", "my-customer-db")
+	if !regexp.MustCompile(`^redacted-[0-9a-f]{8}$`).MatchString(redacted) {
+		t.Errorf("Expected the first 8 hex digits of the hash, got %q", redacted)
+	}
+	if RedactName("key", "my-customer-db") != redacted {
+		t.Error("Expected the redacted name to be stable for the same key")
+	}
+	if RedactName("other-key", "my-customer-db") == redacted || RedactName("key", "other-db") == redacted {
+		t.Error("Expected the redacted name to depend on the key and the name")
+	}
+}
+
+func TestValidateRedaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Opts
+		wantErr bool
+	}{
+		{"no redaction", Opts{DeleteFlag: true, ShowOwners: true}, false},
+		{"report", Opts{RedactNames: true, ShowReason: true}, false},
+		{"delete", Opts{RedactNames: true, DeleteFlag: true}, true},
+		{"remove finalizers", Opts{RedactNames: true, RemoveFinalizers: true}, true},
+		{"mark", Opts{RedactNames: true, MarkLabel: "kor/stuck"}, true},
+		{"owners", Opts{RedactNames: true, ShowOwners: true}, true},
+		{"tree", Opts{RedactNames: true, OwnerTree: true}, true},
+		{"unredact", Opts{Unredact: []string{"redacted-0123abcd"}, RedactionKey: "key"}, false},
+		{"unredact without key", Opts{Unredact: []string{"redacted-0123abcd"}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := validateRedaction(test.opts); (err != nil) != test.wantErr {
+				t.Errorf("Expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetUnusedFinalizersRedactNames(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	stuck := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "customer-db")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck)
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+	var discoveryCalls int
+	opts := Opts{GroupBy: "namespace", RedactNames: true, RedactionKey: "key", DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}}}
+	redacted := RedactName("key", "customer-db")
+
+	output, err := GetUnusedfinalizers(context.TODO(), &filters.Options{}, clientset, dynamicClient, "json", opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var report map[string]map[string][]ResourceInfo
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Expected a json report, got %q: %v", output, err)
+	}
+	if infos := report[testNamespace]["testresources"]; len(infos) != 1 || infos[0].Name != redacted {
+		t.Errorf("Expected the name redacted under its namespace and resource type, got %v", report)
+	}
+
+	output, err = GetUnusedfinalizers(context.TODO(), &filters.Options{}, clientset, dynamicClient, "table", opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(output, redacted) || strings.Contains(output, "customer-db") {
+		t.Errorf("Expected only the redacted name in the table:\n%s", output)
+	}
+
+	var stream bytes.Buffer
+	if err := StreamUnusedFinalizers(context.TODO(), &filters.Options{}, clientset, dynamicClient, &stream, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(stream.String(), redacted) || strings.Contains(stream.String(), "customer-db") {
+		t.Errorf("Expected only the redacted name in the stream: %s", stream.String())
+	}
+
+	unredactOpts := opts
+	unredactOpts.RedactNames = false
+	unredactOpts.Unredact = []string{redacted, "redacted-00000000"}
+	output, err = GetUnusedfinalizers(context.TODO(), &filters.Options{}, clientset, dynamicClient, "table", unredactOpts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{redacted, "customer-db", "testresources.testgroup", "redacted-00000000", "not found"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the unredacted names:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package kor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+// FinalizerReport summarizes the resources a finalizer scan found stuck pending deletion
+type FinalizerReport struct {
+	// Total is the number of resources found stuck
+	Total int `json:"total"`
+	// ByResourceType counts the resources per resource type, qualified with its group, e.g. certificates.cert-manager.io
+	ByResourceType map[string]int `json:"byResourceType"`
+	// ByNamespace counts the resources per namespace, cluster scoped resources are counted under _cluster
+	ByNamespace map[string]int `json:"byNamespace"`
+	// Finalizers counts the resources waiting for each distinct finalizer
+	Finalizers map[string]int `json:"finalizers"`
+	// Oldest is the resource whose deletion has been pending the longest, nil when no deletion was requested
+	// for any of them, e.g. when they only have dangling finalizers
+	Oldest *FinalizerFinding `json:"oldest,omitempty"`
+}
+
+// NewFinalizerReport builds the report of the findings of a finalizer scan
+func NewFinalizerReport(findings []FinalizerFinding) *FinalizerReport {
+	report := &FinalizerReport{
+		Total:          len(findings),
+		ByResourceType: make(map[string]int),
+		ByNamespace:    make(map[string]int),
+		Finalizers:     make(map[string]int),
+	}
+	for i, finding := range findings {
+		report.ByResourceType[finding.GroupVersionResource.GroupResource().String()]++
+		report.ByNamespace[reportedNamespace(finding.Namespace)]++
+		for _, finalizer := range finding.Finalizers {
+			report.Finalizers[finalizer]++
+		}
+		if finding.DeletionTimestamp != nil && (report.Oldest == nil || finding.DeletionTimestamp.Before(report.Oldest.DeletionTimestamp)) {
+			report.Oldest = &findings[i]
+		}
+	}
+	return report
+}
+
+// GetFinalizerReport scans the resources stuck pending deletion because of their finalizers, like
+// GetUnusedFinalizersStructured, and returns their report
+func GetFinalizerReport(ctx context.Context, filterOpts *filters.Options, clientset kubernetes.Interface, dynamicClient dynamic.Interface, opts Opts) (*FinalizerReport, error) {
+	findings, err := GetUnusedFinalizersStructured(ctx, filterOpts, clientset, dynamicClient, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewFinalizerReport(findings), nil
+}
+
+// SummaryTable renders the report as a table per breakdown, the largest counts first
+func (r *FinalizerReport) SummaryTable() string {
+	if r.Total == 0 {
+		return "No resources stuck pending deletion\n"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d resources stuck pending deletion\n", r.Total)
+	if r.Oldest != nil {
+		fmt.Fprintf(&buf, "Oldest: %s %s/%s, pending for %s\n", r.Oldest.GroupVersionResource.GroupResource(), reportedNamespace(r.Oldest.Namespace),
+			r.Oldest.Name, duration.HumanDuration(time.Since(r.Oldest.DeletionTimestamp.Time)))
+	}
+	for _, breakdown := range []struct {
+		header string
+		counts map[string]int
+	}{
+		{"RESOURCE TYPE", r.ByResourceType},
+		{"NAMESPACE", r.ByNamespace},
+		{"FINALIZER", r.Finalizers},
+	} {
+		buf.WriteString("\n")
+		table := tablewriter.NewWriter(&buf)
+		table.SetHeader([]string{"#", breakdown.header, "RESOURCES"})
+		for i, key := range sortedByCount(breakdown.counts) {
+			table.Append(getTableRow(i, key, fmt.Sprintf("%d", breakdown.counts[key])))
+		}
+		table.Render()
+	}
+	return buf.String()
+}
+
+// sortedByCount returns the keys of the counts, the largest count first and by key when equal
+func sortedByCount(counts map[string]int) []string {
+	keys := sortedKeys(counts)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return counts[keys[i]] > counts[keys[j]]
+	})
+	return keys
+}
@@ -0,0 +1,79 @@
+package kor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+func TestNewFinalizerReport(t *testing.T) {
+	now := time.Now()
+	certificates := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	pvs := schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}
+	findings := []FinalizerFinding{
+		{Namespace: "team-a", GroupVersionResource: certificates, Name: "recent", Finalizers: []string{"cert-manager.io/cleanup"}, DeletionTimestamp: &metav1.Time{Time: now.Add(-time.Hour)}},
+		{Namespace: "team-a", GroupVersionResource: certificates, Name: "dangling", Finalizers: []string{"uninstalled.io/cleanup"}},
+		{Namespace: "team-b", GroupVersionResource: certificates, Name: "oldest", Finalizers: []string{"cert-manager.io/cleanup"}, DeletionTimestamp: &metav1.Time{Time: now.Add(-48 * time.Hour)}},
+		{GroupVersionResource: pvs, Name: "pv", Finalizers: []string{"kubernetes.io/pv-protection", "cert-manager.io/cleanup"}, DeletionTimestamp: &metav1.Time{Time: now.Add(-2 * time.Hour)}},
+	}
+
+	report := NewFinalizerReport(findings)
+	if report.Total != 4 || report.ByResourceType["certificates.cert-manager.io"] != 3 || report.ByResourceType["persistentvolumes"] != 1 {
+		t.Errorf("Unexpected resource type breakdown %+v", report)
+	}
+	if report.ByNamespace["team-a"] != 2 || report.ByNamespace["team-b"] != 1 || report.ByNamespace[clusterScopeKey] != 1 {
+		t.Errorf("Unexpected namespace breakdown %v", report.ByNamespace)
+	}
+	if report.Finalizers["cert-manager.io/cleanup"] != 3 || report.Finalizers["kubernetes.io/pv-protection"] != 1 || len(report.Finalizers) != 3 {
+		t.Errorf("Unexpected finalizers %v", report.Finalizers)
+	}
+	if report.Oldest == nil || report.Oldest.Name != "oldest" {
+		t.Errorf("Expected the oldest pending deletion, got %+v", report.Oldest)
+	}
+
+	table := report.SummaryTable()
+	for _, expected := range []string{"4 resources stuck pending deletion", "Oldest: certificates.cert-manager.io team-b/oldest, pending for 2d", "| 1 | cert-manager.io/cleanup", "FINALIZER"} {
+		if !strings.Contains(table, expected) {
+			t.Errorf("Expected %q in the summary table:\n%s", expected, table)
+		}
+	}
+	if table := NewFinalizerReport(nil).SummaryTable(); table != "No resources stuck pending deletion\n" {
+		t.Errorf("Unexpected summary without findings %q", table)
+	}
+}
+
+func TestGetFinalizerReport(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	stuck := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, stuck)
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}})
+	var discoveryCalls int
+	opts := Opts{DiscoveryClient: staticDiscovery{calls: &discoveryCalls, resources: []*metav1.APIResourceList{{
+		GroupVersion: "testgroup/v1",
+		APIResources: []metav1.APIResource{{Name: "testresources", Kind: "TestResource", Verbs: []string{"list"}, Namespaced: true}},
+	}}}}
+
+	report, err := GetFinalizerReport(context.TODO(), &filters.Options{}, clientset, dynamicClient, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Total != 1 || report.ByNamespace[testNamespace] != 1 || report.Finalizers["example.com/cleanup"] != 1 || report.Oldest.Name != "stuck" {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if discoveryCalls != 1 {
+		t.Errorf("Expected a single discovery, got %d", discoveryCalls)
+	}
+}
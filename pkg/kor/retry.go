@@ -0,0 +1,88 @@
+package kor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+const (
+	// defaultListAttempts is how many times a List request is made before a resource type is skipped
+	defaultListAttempts = 3
+	// initialListRetryDelay is waited for before the first retry, and doubled before every following one
+	initialListRetryDelay = 500 * time.Millisecond
+	// maxListRetryDelay bounds the exponential backoff between List retries
+	maxListRetryDelay = 10 * time.Second
+)
+
+// listRetry retries List requests failing with a transient error, with an exponential backoff
+// between the attempts. Throttled requests wait at least the Retry-After the API server asked for.
+type listRetry struct {
+	attempts int
+
+	sleep func(context.Context, time.Duration) error
+}
+
+func newListRetry(attempts int) *listRetry {
+	if attempts <= 0 {
+		attempts = defaultListAttempts
+	}
+	return &listRetry{attempts: attempts, sleep: sleepContext}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// do runs a List request, retrying it until it succeeds, fails with an error that is not
+// transient or the attempts are used up. A nil listRetry makes a single attempt.
+func (r *listRetry) do(ctx context.Context, request func() error) error {
+	if r == nil {
+		return request()
+	}
+	delay := initialListRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := request()
+		if err == nil || attempt >= r.attempts || !isRetryableListError(err) {
+			return err
+		}
+
+		wait := delay
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && time.Duration(seconds)*time.Second > wait {
+			wait = time.Duration(seconds) * time.Second
+		}
+		fmt.Fprintf(os.Stderr, "Listing resources failed: %v, retrying in %s\n", err, wait)
+		if err := r.sleep(ctx, wait); err != nil {
+			return err
+		}
+		delay = min(2*delay, maxListRetryDelay)
+	}
+}
+
+// isRetryableListError reports whether a List request failed for a transient reason, e.g. the API
+// server throttling or timing out, or the connection to it dropping. Errors like Forbidden will not
+// go away by retrying, neither will a cancelled scan.
+func isRetryableListError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsProbableEOF(err) ||
+		utilnet.IsTimeout(err)
+}
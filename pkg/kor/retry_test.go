@@ -0,0 +1,92 @@
+package kor
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newTestListRetry(attempts int) (*listRetry, *[]time.Duration) {
+	var sleeps []time.Duration
+	retry := newListRetry(attempts)
+	retry.sleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return retry, &sleeps
+}
+
+func TestListRetryBackoff(t *testing.T) {
+	retry, sleeps := newTestListRetry(4)
+
+	calls := 0
+	err := retry.do(context.TODO(), func() error {
+		calls++
+		switch calls {
+		case 1:
+			return apierrors.NewServerTimeout(schema.GroupResource{Resource: "widgets"}, "list", 0)
+		case 2:
+			return syscall.ECONNRESET
+		case 3:
+			return apierrors.NewTooManyRequests("slow down", 5)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the retried request to succeed, got %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected 4 calls, got %d", calls)
+	}
+	expected := []time.Duration{initialListRetryDelay, 2 * initialListRetryDelay, 5 * time.Second}
+	if len(*sleeps) != len(expected) {
+		t.Fatalf("Expected waits %v, got %v", expected, *sleeps)
+	}
+	for i := range expected {
+		if (*sleeps)[i] != expected[i] {
+			t.Errorf("Expected waits %v, got %v", expected, *sleeps)
+		}
+	}
+}
+
+func TestListRetryGivesUp(t *testing.T) {
+	retry, _ := newTestListRetry(3)
+
+	calls := 0
+	err := retry.do(context.TODO(), func() error {
+		calls++
+		return apierrors.NewTooManyRequests("slow down", 0)
+	})
+	if !apierrors.IsTooManyRequests(err) {
+		t.Errorf("Expected the throttling error after the last attempt, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestListRetryNotRetryable(t *testing.T) {
+	for name, listErr := range map[string]error{
+		"Forbidden": apierrors.NewForbidden(schema.GroupResource{Resource: "widgets"}, "", nil),
+		"Cancelled": context.Canceled,
+	} {
+		t.Run(name, func(t *testing.T) {
+			retry, sleeps := newTestListRetry(3)
+			calls := 0
+			err := retry.do(context.TODO(), func() error {
+				calls++
+				return listErr
+			})
+			if err != listErr {
+				t.Errorf("Expected %v, got %v", listErr, err)
+			}
+			if calls != 1 || len(*sleeps) != 0 {
+				t.Errorf("Expected a single call without retries, got %d calls and waits %v", calls, *sleeps)
+			}
+		})
+	}
+}
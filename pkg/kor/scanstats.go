@@ -0,0 +1,56 @@
+package kor
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// resourceTypeStats is how listing a resource type went during a finalizer scan, to find the resource
+// types slowing a scan down, e.g. a custom resource whose conversion webhook is broken
+type resourceTypeStats struct {
+	gvr          schema.GroupVersionResource
+	listDuration time.Duration // including the retries of failed pages
+	examined     int
+	flagged      int // stuck pending deletion, before owned resources are skipped
+	err          error
+}
+
+// formatScanStats renders the stats of every listed resource type as a table, the slowest first
+func formatScanStats(stats []resourceTypeStats) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].listDuration != stats[j].listDuration {
+			return stats[i].listDuration > stats[j].listDuration
+		}
+		return stats[i].gvr.String() < stats[j].gvr.String()
+	})
+
+	var buf bytes.Buffer
+	table := tablewriter.NewWriter(&buf)
+	table.SetHeader([]string{"#", "RESOURCE TYPE", "VERSION", "LIST DURATION", "EXAMINED", "FLAGGED", "ERROR"})
+	var total time.Duration
+	var examined, flagged int
+	for i, stat := range stats {
+		total += stat.listDuration
+		examined += stat.examined
+		flagged += stat.flagged
+		var listErr string
+		if stat.err != nil {
+			listErr = stat.err.Error()
+		}
+		table.Append(getTableRow(i, stat.gvr.GroupResource().String(), stat.gvr.Version, stat.listDuration.Round(time.Millisecond).String(),
+			fmt.Sprintf("%d", stat.examined), fmt.Sprintf("%d", stat.flagged), listErr))
+	}
+	table.Render()
+
+	return fmt.Sprintf("Scan statistics:\n%s%d resource types listed in %s, %d objects examined, %d flagged\n",
+		buf.String(), len(stats), total.Round(time.Millisecond), examined, flagged)
+}
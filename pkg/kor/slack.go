@@ -2,6 +2,7 @@ package kor
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +10,12 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/duration"
 )
 
 type SendMessageToSlack interface {
@@ -104,3 +111,75 @@ func writeOutputToFile(outputBuffer string) (string, error) {
 
 	return outputFilePath, nil
 }
+
+// maxSlackSummaryFindings bounds how many stuck resources are listed in the Slack summary
+const maxSlackSummaryFindings = 10
+
+// formatSlackSummary summarizes the findings for Slack, with their count per namespace and the
+// resources pending deletion for the longest
+func formatSlackSummary(findings []FinalizerFinding, clusterName string, now time.Time) string {
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("*kor found %d resources stuck on finalizers", len(findings)))
+	if clusterName != "" {
+		summary.WriteString(" in " + clusterName)
+	}
+	summary.WriteString("*\n")
+
+	counts := make(map[string]int)
+	for _, finding := range findings {
+		counts[reportedNamespace(finding.Namespace)]++
+	}
+	for _, namespace := range sortedKeys(counts) {
+		summary.WriteString(fmt.Sprintf("• %s: %d\n", namespace, counts[namespace]))
+	}
+
+	// Dangling finalizers are reported before any deletion was requested, so they come last
+	longest := slices.Clone(findings)
+	sort.SliceStable(longest, func(i, j int) bool {
+		if longest[i].DeletionTimestamp == nil || longest[j].DeletionTimestamp == nil {
+			return longest[j].DeletionTimestamp == nil && longest[i].DeletionTimestamp != nil
+		}
+		return longest[i].DeletionTimestamp.Before(longest[j].DeletionTimestamp)
+	})
+	if len(longest) > maxSlackSummaryFindings {
+		longest = longest[:maxSlackSummaryFindings]
+	}
+	summary.WriteString("Stuck the longest:\n")
+	for _, finding := range longest {
+		line := fmt.Sprintf("• %s/%s/%s", reportedNamespace(finding.Namespace), finding.GroupVersionResource.Resource, finding.Name)
+		if finding.DeletionTimestamp != nil {
+			line += " pending for " + duration.HumanDuration(now.Sub(finding.DeletionTimestamp.Time))
+		}
+		summary.WriteString(line + " (" + strings.Join(finding.Finalizers, ", ") + ")\n")
+	}
+	return summary.String()
+}
+
+// notifySlackSummary posts the summary of the findings to the Slack webhook, nothing is posted when
+// there are none. A failed notification is reported and never fails the scan. With NotifyDryRun the
+// message is printed instead.
+func notifySlackSummary(findings []FinalizerFinding, opts Opts) {
+	if len(findings) == 0 {
+		return
+	}
+	summary := formatSlackSummary(findings, opts.ClusterName, time.Now())
+	if opts.NotifyDryRun {
+		fmt.Fprintf(os.Stderr, "Dry run, not posting to Slack:\n%s", summary)
+		return
+	}
+
+	payload, err := json.Marshal(map[string]string{"text": summary})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to send the summary to Slack: %v\n", err)
+		return
+	}
+	resp, err := http.Post(opts.SlackSummaryWebhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to send the summary to Slack: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		fmt.Fprintf(os.Stderr, "Failed to send the summary to Slack: webhook returned status code %d\n", resp.StatusCode)
+	}
+}
@@ -2,10 +2,17 @@ package kor
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type SendToSlackTestCase struct {
@@ -72,3 +79,75 @@ func TestWriteOutputToFile(t *testing.T) {
 		t.Errorf("Expected file content:\n%s\nGot:\n%s", expectedOutput, string(fileContent))
 	}
 }
+
+func testSlackFindings(now time.Time) []FinalizerFinding {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	var findings []FinalizerFinding
+	for i, namespace := range []string{"team-a", "team-a", "team-b"} {
+		findings = append(findings, FinalizerFinding{
+			Namespace:            namespace,
+			GroupVersionResource: gvr,
+			Name:                 fmt.Sprintf("data-%d", i),
+			Finalizers:           []string{"kubernetes.io/pvc-protection"},
+			DeletionTimestamp:    &metav1.Time{Time: now.Add(-time.Duration(i+1) * time.Hour)},
+		})
+	}
+	return findings
+}
+
+func TestFormatSlackSummary(t *testing.T) {
+	now := time.Now()
+	summary := formatSlackSummary(testSlackFindings(now), "prod", now)
+
+	for _, expected := range []string{
+		"*kor found 3 resources stuck on finalizers in prod*",
+		"• team-a: 2\n• team-b: 1\n",
+		"• team-b/persistentvolumeclaims/data-2 pending for 3h (kubernetes.io/pvc-protection)",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("Expected the summary to contain %q, got:\n%s", expected, summary)
+		}
+	}
+	if strings.Index(summary, "data-2") > strings.Index(summary, "data-0") {
+		t.Errorf("Expected the resources stuck the longest first, got:\n%s", summary)
+	}
+}
+
+func TestNotifySlackSummary(t *testing.T) {
+	var received []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Expected a json payload, got %v", err)
+		}
+		received = append(received, payload["text"])
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	findings := testSlackFindings(time.Now())
+	notifySlackSummary(findings, Opts{SlackSummaryWebhookURL: server.URL})
+	if len(received) != 1 || !strings.Contains(received[0], "kor found 3 resources") {
+		t.Errorf("Expected the summary to be posted, got %v", received)
+	}
+
+	notifySlackSummary(findings, Opts{SlackSummaryWebhookURL: server.URL, NotifyDryRun: true})
+	notifySlackSummary(nil, Opts{SlackSummaryWebhookURL: server.URL})
+	if len(received) != 1 {
+		t.Errorf("Expected nothing to be posted on a dry run or without findings, got %v", received)
+	}
+
+	// Server-side dry runs of the deletions still notify
+	notifySlackSummary(findings, Opts{SlackSummaryWebhookURL: server.URL, DryRun: true})
+	if len(received) != 2 {
+		t.Errorf("Expected the summary to be posted on a server-side dry run, got %v", received)
+	}
+
+	// A failing webhook is only reported
+	status = http.StatusInternalServerError
+	notifySlackSummary(findings, Opts{SlackSummaryWebhookURL: server.URL})
+	if len(received) != 3 {
+		t.Errorf("Expected the summary to be posted, got %v", received)
+	}
+}
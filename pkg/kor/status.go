@@ -0,0 +1,53 @@
+package kor
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// compileStatusPaths parses the status JSONPath configured per resource type. The braces of the
+// JSONPath template are optional, e.g. .status.conditions[?(@.reason=="DeletionBlocked")].message
+func compileStatusPaths(statusPaths map[string]string) (map[string]*jsonpath.JSONPath, error) {
+	compiled := make(map[string]*jsonpath.JSONPath, len(statusPaths))
+	for resourceType, statusPath := range statusPaths {
+		template := strings.TrimSpace(statusPath)
+		if !strings.HasPrefix(template, "{") {
+			template = "{" + template + "}"
+		}
+		path := jsonpath.New(resourceType).AllowMissingKeys(true)
+		if err := path.Parse(template); err != nil {
+			return nil, fmt.Errorf("invalid status path %q for %s: %w", statusPath, resourceType, err)
+		}
+		compiled[resourceType] = path
+	}
+	return compiled, nil
+}
+
+// statusDiagnostic extracts the explanation an operator recorded in the object status with the path
+// configured for its resource type. An empty string is returned when no path is configured, or when
+// the object does not have the field.
+func statusDiagnostic(obj *unstructured.Unstructured, gvr schema.GroupVersionResource, statusPaths map[string]*jsonpath.JSONPath) string {
+	for resourceType, path := range statusPaths {
+		if !matchesResourceType(gvr, resourceType) {
+			continue
+		}
+		results, err := path.FindResults(obj.UnstructuredContent())
+		if err != nil {
+			return ""
+		}
+		var values []string
+		for _, result := range results {
+			for _, value := range result {
+				if text := strings.TrimSpace(fmt.Sprint(value.Interface())); text != "" {
+					values = append(values, text)
+				}
+			}
+		}
+		return strings.Join(values, "; ")
+	}
+	return ""
+}
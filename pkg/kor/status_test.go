@@ -0,0 +1,42 @@
+package kor
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestStatusDiagnostic(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	obj := CreateTestUnstructered("Widget", "example.com/v1", testNamespace, "widget")
+	obj.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "reason": "Available", "message": "ready"},
+			map[string]interface{}{"type": "Deleting", "reason": "DeletionBlocked", "message": "volume still attached"},
+		},
+	}
+
+	statusPaths, err := compileStatusPaths(map[string]string{
+		"widgets.example.com": `.status.conditions[?(@.reason=="DeletionBlocked")].message`,
+		"gadgets":             "{.status.phase}",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error compiling status paths: %v", err)
+	}
+
+	if status := statusDiagnostic(obj, gvr, statusPaths); status != "volume still attached" {
+		t.Errorf("Expected the blocking condition message, got %q", status)
+	}
+	gadgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "gadgets"}
+	if status := statusDiagnostic(obj, gadgets, statusPaths); status != "" {
+		t.Errorf("Expected no status for a missing field, got %q", status)
+	}
+	others := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	if status := statusDiagnostic(obj, others, statusPaths); status != "" {
+		t.Errorf("Expected no status without a configured path, got %q", status)
+	}
+
+	if _, err := compileStatusPaths(map[string]string{"widgets": ".status[?("}); err == nil {
+		t.Error("Expected an invalid status path to fail")
+	}
+}
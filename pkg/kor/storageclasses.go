@@ -99,13 +99,14 @@ func processStorageClasses(clientset kubernetes.Interface, filterOpts *filters.O
 }
 
 func GetUnusedStorageClasses(filterOpts *filters.Options, clientset kubernetes.Interface, outputFormat string, opts Opts) (string, error) {
+	opts = withDeleteThrottle(opts)
 	resources := make(map[string]map[string][]ResourceInfo)
 	diff, err := processStorageClasses(clientset, filterOpts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to process storageClasses: %v\n", err)
 	}
 	if opts.DeleteFlag {
-		if diff, err = DeleteResource(diff, clientset, "", "StorageClass", opts.NoInteractive); err != nil {
+		if diff, err = DeleteResource(context.TODO(), diff, clientset, "", "StorageClass", filterOpts, opts); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to delete StorageClass %s: %v\n", diff, err)
 		}
 	}
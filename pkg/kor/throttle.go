@@ -0,0 +1,80 @@
+package kor
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// maxThrottledRetries is how many times a delete request is retried when the API server throttles it
+	maxThrottledRetries = 5
+	// defaultThrottleDelay is waited for when a throttled response carries no Retry-After
+	defaultThrottleDelay = time.Second
+	// maxThrottleSlowdown bounds how far the delete rate is reduced below the base rate
+	maxThrottleSlowdown = 16
+)
+
+// deleteThrottle paces delete requests at a rate that starts at the base rate. The rate is halved
+// every time the API server answers with 429 Too Many Requests, and recovers towards the base rate
+// with every request that goes through.
+type deleteThrottle struct {
+	baseRate float64 // requests per second, 0 for no limit
+	rate     float64
+	last     time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newDeleteThrottle(baseRate float64) *deleteThrottle {
+	return &deleteThrottle{baseRate: baseRate, rate: baseRate, now: time.Now, sleep: time.Sleep}
+}
+
+// withDeleteThrottle sets up the throttle shared by every deletion of a run, so DeleteRate and the rate
+// reduced after throttled requests hold across its namespaces and resource types. A run that already has
+// one keeps it.
+func withDeleteThrottle(opts Opts) Opts {
+	if opts.throttle == nil {
+		opts.throttle = newDeleteThrottle(opts.DeleteRate)
+	}
+	return opts
+}
+
+func (t *deleteThrottle) wait() {
+	if t.rate <= 0 || t.last.IsZero() {
+		return
+	}
+	interval := time.Duration(float64(time.Second) / t.rate)
+	if remaining := interval - t.now().Sub(t.last); remaining > 0 {
+		t.sleep(remaining)
+	}
+}
+
+// do runs a delete request at the current rate. Throttled requests are retried after the
+// Retry-After delay the API server asked for.
+func (t *deleteThrottle) do(request func() error) error {
+	for attempt := 0; ; attempt++ {
+		t.wait()
+		err := request()
+		t.last = t.now()
+		if !apierrors.IsTooManyRequests(err) || attempt == maxThrottledRetries {
+			if err == nil && t.rate < t.baseRate {
+				t.rate = min(t.baseRate, t.rate*1.25)
+			}
+			return err
+		}
+
+		delay := defaultThrottleDelay
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+		if t.baseRate > 0 {
+			t.rate = max(t.baseRate/maxThrottleSlowdown, t.rate/2)
+		}
+		fmt.Fprintf(os.Stderr, "API server is throttling deletions, retrying in %s\n", delay)
+		t.sleep(delay)
+	}
+}
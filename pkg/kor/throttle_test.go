@@ -0,0 +1,110 @@
+package kor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/yonahd/kor/pkg/filters"
+)
+
+func newTestDeleteThrottle(baseRate float64) (*deleteThrottle, *[]time.Duration) {
+	clock := time.Unix(0, 0)
+	var sleeps []time.Duration
+	throttle := newDeleteThrottle(baseRate)
+	throttle.now = func() time.Time { return clock }
+	throttle.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		clock = clock.Add(d)
+	}
+	return throttle, &sleeps
+}
+
+func TestDeleteThrottleRetryAfter(t *testing.T) {
+	throttle, sleeps := newTestDeleteThrottle(10)
+
+	calls := 0
+	err := throttle.do(func() error {
+		calls++
+		if calls == 1 {
+			return apierrors.NewTooManyRequests("slow down", 3)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the retried request to succeed, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+	if len(*sleeps) == 0 || (*sleeps)[0] != 3*time.Second {
+		t.Errorf("Expected to wait the 3s Retry-After, got %v", *sleeps)
+	}
+	if throttle.rate >= throttle.baseRate {
+		t.Errorf("Expected the rate to be lowered below %v, got %v", throttle.baseRate, throttle.rate)
+	}
+
+	// The rate recovers towards the base rate with every request that goes through
+	for i := 0; i < 10; i++ {
+		if err := throttle.do(func() error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if throttle.rate != throttle.baseRate {
+		t.Errorf("Expected the rate to recover to %v, got %v", throttle.baseRate, throttle.rate)
+	}
+}
+
+func TestDeleteThrottleGivesUp(t *testing.T) {
+	throttle, _ := newTestDeleteThrottle(0)
+
+	calls := 0
+	err := throttle.do(func() error {
+		calls++
+		return apierrors.NewTooManyRequests("slow down", 1)
+	})
+	if !apierrors.IsTooManyRequests(err) {
+		t.Errorf("Expected the throttling error after the last retry, got %v", err)
+	}
+	if calls != maxThrottledRetries+1 {
+		t.Errorf("Expected %d calls, got %d", maxThrottledRetries+1, calls)
+	}
+}
+
+func TestDeleteThrottlePacing(t *testing.T) {
+	throttle, sleeps := newTestDeleteThrottle(4)
+	for i := 0; i < 3; i++ {
+		if err := throttle.do(func() error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(*sleeps) != 2 || (*sleeps)[0] != 250*time.Millisecond {
+		t.Errorf("Expected two 250ms waits between requests, got %v", *sleeps)
+	}
+}
+
+func TestDeleteThrottleSharedAcrossBatches(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: "ns-a"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: "ns-b"}},
+	)
+	throttle, sleeps := newTestDeleteThrottle(4)
+	opts := withDeleteThrottle(Opts{NoInteractive: true, throttle: throttle})
+	if opts.throttle != throttle {
+		t.Fatal("Expected the throttle of the run to be kept")
+	}
+	for _, batch := range []struct{ namespace, name string }{{"ns-a", "first"}, {"ns-b", "second"}} {
+		if _, err := DeleteResource(context.TODO(), []ResourceInfo{{Name: batch.name}}, clientset, batch.namespace, "ConfigMap", &filters.Options{}, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first deletion of the second batch is paced after the last one of the first batch
+	if len(*sleeps) != 1 || (*sleeps)[0] != 250*time.Millisecond {
+		t.Errorf("Expected a 250ms wait between the batches, got %v", *sleeps)
+	}
+}
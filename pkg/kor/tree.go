@@ -0,0 +1,123 @@
+package kor
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ownerTreeNode is an object in the deletion blocking graph of a namespace
+type ownerTreeNode struct {
+	name     string
+	details  string
+	stuck    bool
+	children map[string]*ownerTreeNode
+}
+
+func (n *ownerTreeNode) child(name string) *ownerTreeNode {
+	if n.children == nil {
+		n.children = make(map[string]*ownerTreeNode)
+	}
+	if n.children[name] == nil {
+		n.children[name] = &ownerTreeNode{name: name}
+	}
+	return n.children[name]
+}
+
+// buildOwnerTrees places every stuck object below its owners, so objects sharing a root owner end up
+// in the same tree. Owners that are stuck themselves, e.g. waiting for their foregroundDeletion
+// dependents, are marked where they appear in the tree.
+func buildOwnerTrees(items []stuckItem, namespaces map[string]bool) map[string]*ownerTreeNode {
+	trees := make(map[string]*ownerTreeNode)
+	for _, item := range items {
+		namespace := reportedNamespace(item.object.GetNamespace())
+		if !namespaces[namespace] {
+			continue
+		}
+		if trees[namespace] == nil {
+			trees[namespace] = &ownerTreeNode{name: namespace}
+		}
+
+		node := trees[namespace]
+		for i := len(item.chain.owners) - 1; i >= 0; i-- {
+			node = node.child(item.chain.owners[i])
+			if i == len(item.chain.owners)-1 && item.chain.partial != "" {
+				node.details = item.chain.partial
+			}
+		}
+		node = node.child(fmt.Sprintf("%s/%s", item.object.GetKind(), item.object.GetName()))
+		node.stuck = true
+		node.details = "waiting for " + strings.Join(item.object.GetFinalizers(), ", ")
+	}
+
+	// A stuck owner is listed as a stuck object of its own as well, so mark every occurrence
+	for _, tree := range trees {
+		stuck := make(map[string]string)
+		collectStuckNodes(tree, stuck)
+		markStuckNodes(tree, stuck)
+	}
+	return trees
+}
+
+func collectStuckNodes(node *ownerTreeNode, stuck map[string]string) {
+	for _, child := range node.children {
+		if child.stuck {
+			stuck[child.name] = child.details
+		}
+		collectStuckNodes(child, stuck)
+	}
+}
+
+func markStuckNodes(node *ownerTreeNode, stuck map[string]string) {
+	for _, child := range node.children {
+		if details, ok := stuck[child.name]; ok {
+			child.stuck = true
+			child.details = details
+		}
+		markStuckNodes(child, stuck)
+	}
+}
+
+func writeOwnerTree(buffer *bytes.Buffer, node *ownerTreeNode, prefix string) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := node.children[name]
+		branch, indent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, indent = "└── ", "    "
+		}
+		line := child.name
+		if child.stuck {
+			line += " [stuck]"
+		}
+		if child.details != "" {
+			line += " (" + child.details + ")"
+		}
+		buffer.WriteString(prefix + branch + line + "\n")
+		writeOwnerTree(buffer, child, prefix+indent)
+	}
+}
+
+// formatOwnerTrees renders the stuck objects of every namespace as trees rooted at their top level owner
+func formatOwnerTrees(items []stuckItem, namespaces map[string]bool) string {
+	trees := buildOwnerTrees(items, namespaces)
+	sortedNamespaces := make([]string, 0, len(trees))
+	for namespace := range trees {
+		sortedNamespaces = append(sortedNamespaces, namespace)
+	}
+	sort.Strings(sortedNamespaces)
+
+	var buffer bytes.Buffer
+	for _, namespace := range sortedNamespaces {
+		buffer.WriteString(fmt.Sprintf("Unused resources in namespace: %q\n", namespace))
+		writeOwnerTree(&buffer, trees[namespace], "")
+		buffer.WriteString("\n")
+	}
+	return buffer.String()
+}
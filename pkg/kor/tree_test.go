@@ -0,0 +1,42 @@
+package kor
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testStuckItem(kind, name string, finalizers []string, owners ...string) stuckItem {
+	obj := CreateTestUnstructered(kind, "testgroup/v1", testNamespace, name)
+	obj.SetFinalizers(finalizers)
+	obj.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	return stuckItem{object: obj, chain: ownerChain{owners: owners}}
+}
+
+func TestFormatOwnerTrees(t *testing.T) {
+	replicaSet := testStuckItem("ReplicaSet", "web-abc", []string{metav1.FinalizerDeleteDependents}, "Deployment/web")
+	pod1 := testStuckItem("Pod", "web-abc-1", []string{"example.com/cleanup"}, "ReplicaSet/web-abc", "Deployment/web")
+	pod2 := testStuckItem("Pod", "web-abc-2", []string{"example.com/cleanup"}, "ReplicaSet/web-abc", "Deployment/web")
+	orphan := testStuckItem("Pod", "orphan", []string{"example.com/cleanup"}, "Job/missing")
+	orphan.chain.partial = "Job/missing is missing"
+	standalone := testStuckItem("ConfigMap", "settings", []string{"example.com/cleanup"})
+	otherNamespace := stuckItem{object: &unstructured.Unstructured{}}
+	otherNamespace.object.SetNamespace("other")
+
+	output := formatOwnerTrees([]stuckItem{pod1, replicaSet, pod2, orphan, standalone, otherNamespace}, map[string]bool{testNamespace: true})
+	expected := `Unused resources in namespace: "test-namespace"
+├── ConfigMap/settings [stuck] (waiting for example.com/cleanup)
+├── Deployment/web
+│   └── ReplicaSet/web-abc [stuck] (waiting for foregroundDeletion)
+│       ├── Pod/web-abc-1 [stuck] (waiting for example.com/cleanup)
+│       └── Pod/web-abc-2 [stuck] (waiting for example.com/cleanup)
+└── Job/missing (Job/missing is missing)
+    └── Pod/orphan [stuck] (waiting for example.com/cleanup)
+
+`
+	if output != expected {
+		t.Errorf("Expected tree:\n%s\nGot:\n%s", expected, output)
+	}
+}
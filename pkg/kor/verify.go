@@ -0,0 +1,327 @@
+package kor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	deletionStateGone        = "gone"
+	deletionStateTerminating = "still terminating"
+	deletionStatePresent     = "still present"
+	deletionStateUnknown     = "unknown"
+)
+
+const (
+	deletionActionDeleted   = "deleted"
+	deletionActionFailed    = "failed"
+	deletionActionDeclined  = "declined"
+	deletionActionFlagged   = "flagged as in use"
+	deletionActionAdvisory  = "skipped, advisory only"
+	deletionActionRemoved   = finalizersRemovedReason
+	deletionActionProtected = protectedSkippedReason
+)
+
+// waitForDeletionInterval is how often the resources of a delete run are polled while waiting for them to be gone
+const waitForDeletionInterval = 2 * time.Second
+
+// ErrDeletionIncomplete is returned along with the output when resources are still present once the
+// wait for their deletion timed out
+var ErrDeletionIncomplete = errors.New("deletion incomplete")
+
+// FindingsError is returned along with the output by finalizer scans with FailOnFindings that found
+// resources stuck pending deletion
+type FindingsError struct {
+	Count int
+}
+
+func (e *FindingsError) Error() string {
+	return fmt.Sprintf("found %d resources stuck pending deletion", e.Count)
+}
+
+// HasOutput reports whether a finalizer scan returning err still returned its complete output, which
+// is the case without an error, for ErrDeletionIncomplete and for a FindingsError
+func HasOutput(err error) bool {
+	var findingsErr *FindingsError
+	return err == nil || errors.Is(err, ErrDeletionIncomplete) || errors.As(err, &findingsErr)
+}
+
+// DeletionAction is what a delete run did with a resource it found
+type DeletionAction struct {
+	Namespace string `json:"namespace"`
+	Resource  string `json:"resource"`
+	Name      string `json:"name"`
+	Action    string `json:"action"`
+}
+
+// DeletionVerification is the state of a resource re-listed after kor attempted to delete it
+type DeletionVerification struct {
+	Namespace string `json:"namespace"`
+	Resource  string `json:"resource"`
+	Name      string `json:"name"`
+	Requested bool   `json:"requested"` // false when the delete request itself failed
+	State     string `json:"state"`
+}
+
+// deletionRun is the resources of a resource type in a namespace a delete run tried to delete, and
+// whether each delete request succeeded
+type deletionRun struct {
+	namespace string
+	gvr       schema.GroupVersionResource
+	attempts  map[string]bool
+}
+
+// deletedResourceName returns the name of a resource reported as deleted by a delete run, dry run or not
+func deletedResourceName(name string) (string, bool) {
+	return strings.CutSuffix(strings.TrimSuffix(name, dryRunSuffix), "-DELETED")
+}
+
+// deletionAttempts returns the resources a delete run tried to delete, and whether the request succeeded.
+// Successful deletions carry the -DELETED suffix in the result, or report their removed finalizers, failed
+// ones report the failure or are missing from it and declined ones are kept as they are.
+func deletionAttempts(requested, result []ResourceInfo) map[string]bool {
+	declined := make(map[string]bool)
+	attempts := make(map[string]bool)
+	for _, info := range result {
+		if name, deleted := deletedResourceName(info.Name); deleted {
+			attempts[name] = true
+		} else if strings.HasPrefix(info.Reason, finalizersRemovedReason) {
+			attempts[info.Name] = true
+		} else if isFailedReason(info.Reason) {
+			attempts[info.Name] = false
+		} else {
+			declined[info.Name] = true
+		}
+	}
+	for _, info := range requested {
+		if _, ok := attempts[info.Name]; !ok && !declined[info.Name] {
+			attempts[info.Name] = false
+		}
+	}
+	return attempts
+}
+
+// deletionActions returns what a delete run did with every requested resource, from the result it returned
+func deletionActions(namespace, resource string, requested, result []ResourceInfo) []DeletionAction {
+	actions := make([]DeletionAction, 0, len(requested))
+	outcomes := make(map[string]string)
+	for _, info := range result {
+		if name, deleted := deletedResourceName(info.Name); deleted {
+			outcomes[name] = deletionActionDeleted
+		} else if strings.HasPrefix(info.Reason, finalizersRemovedReason) {
+			outcomes[info.Name] = deletionActionRemoved
+		} else if isFailedReason(info.Reason) {
+			outcomes[info.Name] = deletionActionFailed
+		} else if info.Reason == "flagged as in use" {
+			outcomes[info.Name] = deletionActionFlagged
+		} else if strings.HasPrefix(info.Reason, protectedSkippedReason) {
+			outcomes[info.Name] = deletionActionProtected
+		} else {
+			outcomes[info.Name] = deletionActionDeclined
+		}
+	}
+	for _, info := range requested {
+		action, ok := outcomes[info.Name]
+		if !ok {
+			action = deletionActionFailed
+		}
+		actions = append(actions, DeletionAction{Namespace: namespace, Resource: resource, Name: info.Name, Action: action})
+	}
+	return actions
+}
+
+// formatDeletionActions renders the actions of a delete run as a table with a summary of the actions taken
+func formatDeletionActions(actions []DeletionAction) string {
+	if len(actions) == 0 {
+		return ""
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].Namespace != actions[j].Namespace {
+			return actions[i].Namespace < actions[j].Namespace
+		}
+		if actions[i].Resource != actions[j].Resource {
+			return actions[i].Resource < actions[j].Resource
+		}
+		return actions[i].Name < actions[j].Name
+	})
+
+	var buf bytes.Buffer
+	table := tablewriter.NewWriter(&buf)
+	table.SetHeader([]string{"#", "NAMESPACE", "RESOURCE", "NAME", "ACTION"})
+	counts := make(map[string]int)
+	for i, action := range actions {
+		counts[action.Action]++
+		table.Append(getTableRow(i, action.Namespace, action.Resource, action.Name, action.Action))
+	}
+	table.Render()
+
+	var summary []string
+	for _, action := range []string{deletionActionDeleted, deletionActionFailed, deletionActionDeclined, deletionActionFlagged, deletionActionRemoved, deletionActionProtected, deletionActionAdvisory} {
+		if counts[action] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[action], action))
+		}
+	}
+	return fmt.Sprintf("Actions taken:\n%s%s\n", buf.String(), strings.Join(summary, ", "))
+}
+
+// verifyDeletions re-lists the resources of a deletion run and reports whether each attempted
+// deletion went through, or if the object resisted it.
+func verifyDeletions(ctx context.Context, dynamicClient dynamic.Interface, namespace string, gvr schema.GroupVersionResource, attempts map[string]bool) []DeletionVerification {
+	if len(attempts) == 0 {
+		return nil
+	}
+
+	states := make(map[string]string)
+	resourceList, err := dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for _, item := range resourceList.Items {
+			if item.GetDeletionTimestamp() != nil {
+				states[item.GetName()] = deletionStateTerminating
+			} else {
+				states[item.GetName()] = deletionStatePresent
+			}
+		}
+	}
+
+	verifications := make([]DeletionVerification, 0, len(attempts))
+	for name, requested := range attempts {
+		state, ok := states[name]
+		switch {
+		case err != nil:
+			state = deletionStateUnknown
+		case !ok:
+			state = deletionStateGone
+		}
+		verifications = append(verifications, DeletionVerification{
+			Namespace: reportedNamespace(namespace),
+			Resource:  gvr.Resource,
+			Name:      name,
+			Requested: requested,
+			State:     state,
+		})
+	}
+	return verifications
+}
+
+// waitForDeletions polls the resources of the delete runs until every one of them is gone or the
+// timeout elapses, and returns the ones remaining. Failed delete requests are waited for as well,
+// their resource may still be deleted by someone else.
+func waitForDeletions(ctx context.Context, dynamicClient dynamic.Interface, runs []deletionRun, timeout, interval time.Duration) []DeletionVerification {
+	type target struct {
+		namespace string
+		gvr       schema.GroupVersionResource
+		name      string
+	}
+	remaining := make(map[target]DeletionVerification)
+	for _, run := range runs {
+		for name, requested := range run.attempts {
+			remaining[target{run.namespace, run.gvr, name}] = DeletionVerification{
+				Namespace: reportedNamespace(run.namespace),
+				Resource:  run.gvr.Resource,
+				Name:      name,
+				Requested: requested,
+				State:     deletionStateUnknown,
+			}
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		for target, verification := range remaining {
+			object, err := dynamicClient.Resource(target.gvr).Namespace(target.namespace).Get(ctx, target.name, metav1.GetOptions{})
+			switch {
+			case apierrors.IsNotFound(err):
+				delete(remaining, target)
+				continue
+			case ctx.Err() != nil:
+				// The timeout elapsed during this poll, the previous one is the last known state
+				continue
+			case err != nil:
+				verification.State = deletionStateUnknown
+			case object.GetDeletionTimestamp() != nil:
+				verification.State = deletionStateTerminating
+			default:
+				verification.State = deletionStatePresent
+			}
+			remaining[target] = verification
+		}
+		if len(remaining) == 0 || sleepContext(ctx, interval) != nil {
+			break
+		}
+	}
+
+	verifications := make([]DeletionVerification, 0, len(remaining))
+	for _, verification := range remaining {
+		verifications = append(verifications, verification)
+	}
+	return verifications
+}
+
+// formatRemainingDeletions renders the resources still present after waiting for their deletion as a table
+func formatRemainingDeletions(remaining []DeletionVerification, timeout time.Duration) string {
+	if len(remaining) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Still present after waiting %s for the deletion:\n%s%d resources remaining\n", timeout, formatDeletionVerifications(remaining), len(remaining))
+}
+
+// formatDeletionReport renders the deletion verifications as a table with a summary of the outcome
+func formatDeletionReport(verifications []DeletionVerification) string {
+	if len(verifications) == 0 {
+		return ""
+	}
+	var gone, lingering, failed int
+	for _, verification := range verifications {
+		if !verification.Requested {
+			failed++
+		}
+		switch verification.State {
+		case deletionStateGone:
+			gone++
+		case deletionStateTerminating, deletionStatePresent:
+			lingering++
+		}
+	}
+	return fmt.Sprintf("Deletion verification:\n%s%d gone, %d lingering, %d failed delete requests\n", formatDeletionVerifications(verifications), gone, lingering, failed)
+}
+
+func formatDeletionVerifications(verifications []DeletionVerification) string {
+	sort.Slice(verifications, func(i, j int) bool {
+		if verifications[i].Namespace != verifications[j].Namespace {
+			return verifications[i].Namespace < verifications[j].Namespace
+		}
+		if verifications[i].Resource != verifications[j].Resource {
+			return verifications[i].Resource < verifications[j].Resource
+		}
+		return verifications[i].Name < verifications[j].Name
+	})
+
+	var buf bytes.Buffer
+	table := tablewriter.NewWriter(&buf)
+	table.SetHeader([]string{"#", "NAMESPACE", "RESOURCE", "NAME", "DELETE REQUEST", "STATE"})
+	for i, verification := range verifications {
+		request := "succeeded"
+		if !verification.Requested {
+			request = "failed"
+		}
+		table.Append(getTableRow(i, verification.Namespace, verification.Resource, verification.Name, request, verification.State))
+	}
+	table.Render()
+	return buf.String()
+}
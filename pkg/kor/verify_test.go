@@ -0,0 +1,138 @@
+package kor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDeletionAttempts(t *testing.T) {
+	requested := []ResourceInfo{{Name: "deleted"}, {Name: "declined"}, {Name: "failed"}}
+	result := []ResourceInfo{{Name: "deleted-DELETED"}, {Name: "declined", Reason: "not deleted - user declined"}}
+
+	attempts := deletionAttempts(requested, result)
+	expected := map[string]bool{"deleted": true, "failed": false}
+	if len(attempts) != len(expected) {
+		t.Fatalf("Expected attempts %v, got %v", expected, attempts)
+	}
+	for name, requested := range expected {
+		if got, ok := attempts[name]; !ok || got != requested {
+			t.Errorf("Expected attempt %s with request succeeded %v, got %v", name, requested, attempts)
+		}
+	}
+}
+
+func TestVerifyDeletions(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	terminating := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "terminating")
+	terminating.SetFinalizers([]string{"example.com/cleanup"})
+	terminating.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	present := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "present")
+
+	scheme := runtime.NewScheme()
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(scheme,
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, terminating, present)
+
+	verifications := verifyDeletions(context.TODO(), dynamicClient, testNamespace, gvr, map[string]bool{
+		"gone":        true,
+		"terminating": true,
+		"present":     false,
+	})
+	states := make(map[string]string)
+	for _, verification := range verifications {
+		states[verification.Name] = verification.State
+	}
+	for name, expected := range map[string]string{
+		"gone":        deletionStateGone,
+		"terminating": deletionStateTerminating,
+		"present":     deletionStatePresent,
+	} {
+		if states[name] != expected {
+			t.Errorf("Expected %s to be %q, got %q", name, expected, states[name])
+		}
+	}
+
+	report := formatDeletionReport(verifications)
+	if !strings.Contains(report, "1 gone, 2 lingering, 1 failed delete requests") {
+		t.Errorf("Unexpected deletion report summary:\n%s", report)
+	}
+}
+
+func TestWaitForDeletions(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "testgroup", Version: "v1", Resource: "testresources"}
+	terminating := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "terminating")
+	terminating.SetFinalizers([]string{"example.com/cleanup"})
+	terminating.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	leaving := CreateTestUnstructered("TestResource", "testgroup/v1", testNamespace, "leaving")
+
+	scheme := runtime.NewScheme()
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(scheme,
+		map[schema.GroupVersionResource]string{gvr: "TestResourceList"}, terminating, leaving)
+	// leaving is gone from the second poll on
+	polls := 0
+	dynamicClient.PrependReactor("get", "testresources", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.GetAction).GetName() != "leaving" {
+			return false, nil, nil
+		}
+		polls++
+		if polls > 1 {
+			return true, nil, apierrors.NewNotFound(gvr.GroupResource(), "leaving")
+		}
+		return false, nil, nil
+	})
+
+	runs := []deletionRun{{testNamespace, gvr, map[string]bool{"gone": true, "leaving": true, "terminating": true}}}
+	remaining := waitForDeletions(context.TODO(), dynamicClient, runs, 50*time.Millisecond, 10*time.Millisecond)
+	if len(remaining) != 1 || remaining[0].Name != "terminating" || remaining[0].State != deletionStateTerminating {
+		t.Fatalf("Expected only terminating to remain, got %v", remaining)
+	}
+	if polls < 2 {
+		t.Errorf("Expected leaving to be polled until it was gone, got %d polls", polls)
+	}
+
+	report := formatRemainingDeletions(remaining, time.Minute)
+	if !strings.Contains(report, "Still present after waiting 1m0s") || !strings.Contains(report, "1 resources remaining") {
+		t.Errorf("Unexpected remaining deletions report:\n%s", report)
+	}
+}
+
+func TestDeletionActions(t *testing.T) {
+	requested := []ResourceInfo{{Name: "deleted"}, {Name: "declined"}, {Name: "flagged"}, {Name: "failed"}, {Name: "protected"}}
+	result := []ResourceInfo{
+		{Name: "deleted-DELETED"},
+		{Name: "declined", Reason: "not deleted - user declined"},
+		{Name: "flagged", Reason: "flagged as in use"},
+		{Name: "protected", Reason: protectedSkippedReason + ": kubernetes.io/pv-protection"},
+	}
+
+	actions := deletionActions(testNamespace, "testresources", requested, result)
+	expected := map[string]string{
+		"deleted":   deletionActionDeleted,
+		"declined":  deletionActionDeclined,
+		"flagged":   deletionActionFlagged,
+		"failed":    deletionActionFailed,
+		"protected": deletionActionProtected,
+	}
+	if len(actions) != len(expected) {
+		t.Fatalf("Expected %d actions, got %v", len(expected), actions)
+	}
+	for _, action := range actions {
+		if action.Action != expected[action.Name] {
+			t.Errorf("Expected %s to be %q, got %q", action.Name, expected[action.Name], action.Action)
+		}
+	}
+
+	actions = append(actions, DeletionAction{Namespace: testNamespace, Resource: "certificates", Name: "cert", Action: deletionActionAdvisory})
+	report := formatDeletionActions(actions)
+	if !strings.Contains(report, "1 deleted, 1 failed, 1 declined, 1 flagged as in use, 1 protected, skipped, 1 skipped, advisory only") {
+		t.Errorf("Unexpected actions report summary:\n%s", report)
+	}
+}